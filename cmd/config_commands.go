@@ -139,7 +139,7 @@ var configSetCmd = &cobra.Command{
 					fmt.Println("  -", theme)
 				}
 				os.Exit(0)
-			case "auto-open", "enable-dir-listing", "enable-log-persistence":
+			case "auto-open", "enable-dir-listing", "enable-log-persistence", "spa", "show-hidden":
 				fmt.Println(i18n.T("cmd.bool.options"))
 				fmt.Println("  - true, yes, y, 1, on")
 				fmt.Println("  - false, no, n, 0, off")
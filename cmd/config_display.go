@@ -55,6 +55,8 @@ func displayConfigTable(cfg config.Config) {
 		{"language", formatLanguageValue(cfg.Language), i18n.T("config.language_desc")},
 		{"enable-log-persistence", formatBoolValue(cfg.EnableLogPersistence), i18n.T("config.enable_log_persistence_desc")},
 		{"start-port", fmt.Sprintf("%d", cfg.StartPort), i18n.T("config.start_port_desc")},
+		{"spa", formatBoolValue(cfg.SPA), i18n.T("config.spa_desc")},
+		{"show-hidden", formatBoolValue(cfg.ShowHidden), i18n.T("config.show_hidden_desc")},
 	})
 
 	// 设置列对齐方式
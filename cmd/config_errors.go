@@ -49,7 +49,7 @@ func generateConfigCommandHelp(cmdName string, args []string) string {
 				// 使用全局定义的有效主题列表
 				themesStr := strings.Join(dirlist.GetSupportedThemes(), ", ")
 				msg.WriteString(i18n.T("cmd.theme.options") + themesStr + "\n")
-			} else if args[0] == "auto-open" || args[0] == "enable-dir-listing" || args[0] == "enable-log-persistence" {
+			} else if args[0] == "auto-open" || args[0] == "enable-dir-listing" || args[0] == "enable-log-persistence" || args[0] == "spa" || args[0] == "show-hidden" {
 				msg.WriteString(i18n.T("cmd.bool.options") + "\n")
 			} else if args[0] == "language" {
 				// 使用语言模块提供的支持语言列表
@@ -85,6 +85,8 @@ func generateInvalidKeyError(key string) error {
 	msg.WriteString("  - " + i18n.T("error.language_desc") + "\n")
 	msg.WriteString("  - " + i18n.T("error.enable_log_persistence_desc") + "\n")
 	msg.WriteString("  - " + i18n.T("error.start_port_desc") + "\n")
+	msg.WriteString("  - " + i18n.T("error.spa_desc") + "\n")
+	msg.WriteString("  - " + i18n.T("error.show_hidden_desc") + "\n")
 
 	return fmt.Errorf(msg.String())
 }
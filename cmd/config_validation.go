@@ -19,6 +19,8 @@ var validConfigKeys = []string{
 	"language",               // 界面语言
 	"enable-log-persistence", // 是否启用日志持久化
 	"start-port",             // 从哪个端口开始递增寻找空闲端口
+	"spa",                    // 是否启用单页应用模式
+	"show-hidden",            // 是否显示隐藏文件
 	// 在这里添加其他支持的配置键
 }
 
@@ -50,7 +52,7 @@ func parseBoolValue(value string) (bool, error) {
 // 设置配置值（根据类型转换）
 func setConfigValue(key, value string) error {
 	switch key {
-	case "auto-open", "enable-dir-listing", "enable-log-persistence":
+	case "auto-open", "enable-dir-listing", "enable-log-persistence", "spa", "show-hidden":
 		// 将输入转换为布尔值
 		boolValue, err := parseBoolValue(value)
 		if err != nil {
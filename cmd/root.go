@@ -128,6 +128,26 @@ func UpdateCommandDescriptions() {
 					subcmd.Long = i18n.T("cmd.config.set.long")
 				}
 			}
+		case "user":
+			cmd.Short = i18n.T("cmd.user.short")
+			cmd.Long = i18n.T("cmd.user.long")
+			// 更新user的子命令
+			for _, subcmd := range cmd.Commands() {
+				switch subcmd.Name() {
+				case "add", "remove", "passwd", "list":
+					subcmd.Short = i18n.T("cmd.user." + subcmd.Name() + ".short")
+				}
+			}
+		case "token":
+			cmd.Short = i18n.T("cmd.token.short")
+			cmd.Long = i18n.T("cmd.token.long")
+			// 更新token的子命令
+			for _, subcmd := range cmd.Commands() {
+				switch subcmd.Name() {
+				case "create", "list", "revoke":
+					subcmd.Short = i18n.T("cmd.token." + subcmd.Name() + ".short")
+				}
+			}
 		case "start":
 			cmd.Short = i18n.T("cmd.start.short")
 			cmd.Long = i18n.T("cmd.start.long")
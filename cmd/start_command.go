@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"slices"
+	"syscall"
 
 	"github.com/CC11001100/servergo/pkg/auth"
 	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/fulltext"
 	"github.com/CC11001100/servergo/pkg/i18n"
 	"github.com/CC11001100/servergo/pkg/logger"
 	"github.com/CC11001100/servergo/pkg/server"
@@ -15,7 +20,7 @@ import (
 
 // startCmd 表示启动服务器的命令
 var startCmd = &cobra.Command{
-	Use:     "start",
+	Use:     "start [dir...]",
 	Aliases: startCmdAliases,
 	Short:   i18n.T("cmd.start.short"),
 	Long:    i18n.T("cmd.start.long"),
@@ -41,52 +46,106 @@ var startCmd = &cobra.Command{
 			os.Exit(0)
 		}
 
-		// 探测可用端口
-		actualPort, err := utils.FindAvailablePort(getStartPort())
+		// 处理挂载点和位置参数中的目录
+		mounts, err := buildMounts(cmd, args)
 		if err != nil {
-			return fmt.Errorf(i18n.T("error.no_port_available"))
+			return err
+		}
+
+		// 解析反向代理规则
+		proxies, err := buildProxies()
+		if err != nil {
+			return err
+		}
+
+		// 解析监听地址，使用 --bind 时只在指定的地址上探测端口
+		binds, err := parseBindAddrs()
+		if err != nil {
+			return err
 		}
 
-		// 如果使用的不是用户指定的端口，提示用户
-		if port > 0 && port != actualPort {
-			logger.Warning(i18n.Tf("error.port_unavailable", port))
-			logger.Info(i18n.Tf("server.starting", actualPort))
-		} else if port == 0 {
-			startPort := getStartPort()
-			if startPort > 0 && startPort != actualPort {
-				// 使用了配置文件中的起始端口，但实际使用的是不同的端口
-				logger.Info(i18n.Tf("server.using_config_start_port", startPort))
-				logger.Info(i18n.Tf("server.starting", actualPort))
-			} else {
-				// 随机选择的端口
-				logger.Info(i18n.Tf("server.starting", actualPort))
+		// 探测可用端口，所有监听地址都指定了端口或只监听Unix套接字时不需要探测
+		actualPort := binds.fixedPort
+		if actualPort == 0 && binds.tcp {
+			actualPort, err = utils.FindAvailablePort(getStartPort(), binds.probeHosts...)
+			if err != nil {
+				return fmt.Errorf(i18n.T("error.no_port_available"))
+			}
+
+			// 如果使用的不是用户指定的端口，提示用户
+			if port > 0 && port != actualPort {
+				logger.Warning(i18n.Tf("error.port_unavailable", port))
+				logger.Info(i18n.Tf("server.starting", localURL(actualPort)))
+			} else if port == 0 {
+				startPort := getStartPort()
+				if startPort > 0 && startPort != actualPort {
+					// 使用了配置文件中的起始端口，但实际使用的是不同的端口
+					logger.Info(i18n.Tf("server.using_config_start_port", startPort))
+					logger.Info(i18n.Tf("server.starting", localURL(actualPort)))
+				} else {
+					// 随机选择的端口
+					logger.Info(i18n.Tf("server.starting", localURL(actualPort)))
+				}
 			}
 		}
 
-		// 转换认证类型
-		var authTypeEnum auth.AuthType
-		switch authType {
-		case "basic":
-			authTypeEnum = auth.BasicAuth
-		case "token":
-			authTypeEnum = auth.TokenAuth
-		case "form":
-			authTypeEnum = auth.FormAuth
-		default:
-			authTypeEnum = auth.NoAuth
+		// 转换认证类型，多种认证方式用逗号分隔，例如: basic,token
+		authTypes, err := auth.ParseAuthTypes(authType)
+		if err != nil {
+			return err
+		}
+
+		// 指定了htpasswd用户文件但没有指定认证类型时，使用Basic认证
+		if htpasswdFile != "" && !cmd.Flags().Changed("auth") {
+			authTypes = []auth.AuthType{auth.BasicAuth}
+		}
+
+		// 启用了令牌认证时读取托管令牌文件，没有指定时使用 ~/.servergo/tokens
+		if tokenFile == "" && slices.Contains(authTypes, auth.TokenAuth) {
+			if tokenFile, err = auth.DefaultTokenStorePath(); err != nil {
+				return err
+			}
 		}
 
 		// 创建服务器配置
 		serverConfig := server.Config{
-			Port:             actualPort,
-			Dir:              dir,
-			AuthType:         authTypeEnum,
-			Username:         username,
-			Password:         password,
-			Token:            token,
-			EnableLoginPage:  enableLoginPage,
-			EnableDirListing: enableDirListing,
-			Theme:            theme,
+			Port:                actualPort,
+			Bind:                binds.addrs,
+			TrustedProxies:      trustedProxies,
+			ProxyProtocol:       proxyProtocol,
+			Dir:                 dir,
+			Mounts:              mounts,
+			Proxies:             proxies,
+			AuthTypes:           authTypes,
+			Username:            username,
+			Password:            password,
+			Token:               token,
+			EnableLoginPage:     enableLoginPage,
+			Htpasswd:            htpasswdFile,
+			TokenFile:           tokenFile,
+			ACLPolicy:           aclPolicy,
+			SessionMaxAge:       sessionMaxAge,
+			SessionIdleTimeout:  sessionIdleTimeout,
+			RememberMeMaxAge:    rememberMeMaxAge,
+			EnableDirListing:    enableDirListing,
+			Theme:               theme,
+			SPA:                 spaMode,
+			SPAExclude:          spaExclude,
+			ShowHidden:          showHidden,
+			Exclude:             excludePatterns,
+			EnableUpload:        enableUpload,
+			EnableWebDAV:        enableWebDAV,
+			TLSCertFile:         tlsCert,
+			TLSKeyFile:          tlsKey,
+			TLSSelfSigned:       tlsSelfSigned,
+			EnableCompression:   enableCompression,
+			CompressionMinSize:  compressionMinSize,
+			CompressionTypes:    compressionTypes,
+			LiveReload:          liveReload,
+			FullTextIndex:       fullTextIndex,
+			FullTextMaxFileSize: fullTextMaxFileSize,
+			FullTextExtensions:  fullTextExtensions,
+			ShutdownTimeout:     shutdownTimeout,
 		}
 
 		// 创建并启动文件服务器
@@ -95,14 +154,22 @@ var startCmd = &cobra.Command{
 			return err
 		}
 
-		// 如果配置为自动打开浏览器，则在启动服务器后打开
-		if autoOpen {
+		// 如果配置为自动打开浏览器，则在启动服务器后打开，只监听Unix套接字时浏览器无法访问
+		if autoOpen && binds.tcp {
 			// 在新的goroutine中启动浏览器，避免阻塞服务器启动
-			serverURL := fmt.Sprintf("http://localhost:%d", actualPort)
-			go openBrowser(serverURL)
+			go openBrowser(srv.URL())
 		}
 
-		return srv.Start()
+		// Ctrl-C 或 SIGTERM 触发优雅关闭，等待进行中的下载完成
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			// 收到第一个信号后恢复默认的信号处理，再按一次 Ctrl-C 可以立即退出
+			<-ctx.Done()
+			stop()
+		}()
+
+		return srv.Start(ctx)
 	},
 }
 
@@ -113,6 +180,11 @@ func init() {
 	// 端口默认值为0，表示自动探测可用端口
 	startCmd.Flags().IntVarP(&port, "port", "p", 0, i18n.T("flag.port"))
 	startCmd.Flags().StringVarP(&dir, "dir", "d", ".", i18n.T("flag.dir"))
+	startCmd.Flags().StringArrayVar(&bindAddrs, "bind", nil, i18n.T("flag.bind"))
+	startCmd.Flags().StringArrayVar(&mountSpecs, "mount", nil, i18n.T("flag.mount"))
+	startCmd.Flags().StringArrayVar(&proxySpecs, "proxy", nil, i18n.T("flag.proxy"))
+	startCmd.Flags().StringSliceVar(&trustedProxies, "trusted-proxies", nil, i18n.T("flag.trusted_proxies"))
+	startCmd.Flags().BoolVar(&proxyProtocol, "proxy-protocol", false, i18n.T("flag.proxy_protocol"))
 
 	// 所有配置项的命令行标志默认值设为空或false
 	// 实际的默认值会从配置文件中读取，如果配置文件中没有才会使用 pkg/config/config.go 中定义的默认值
@@ -120,12 +192,51 @@ func init() {
 	startCmd.Flags().BoolVarP(&enableDirListing, "dir-list", "i", false, i18n.T("flag.dir_list"))
 	startCmd.Flags().StringVarP(&theme, "theme", "m", "", i18n.T("flag.theme"))
 
+	// 添加单页应用相关的标志
+	startCmd.Flags().BoolVar(&spaMode, "spa", false, i18n.T("flag.spa"))
+	startCmd.Flags().StringSliceVar(&spaExclude, "spa-exclude", nil, i18n.T("flag.spa_exclude"))
+
+	// 添加隐藏文件相关的标志
+	startCmd.Flags().BoolVar(&showHidden, "show-hidden", false, i18n.T("flag.show_hidden"))
+	startCmd.Flags().StringArrayVar(&excludePatterns, "exclude", nil, i18n.T("flag.exclude"))
+
+	// 添加文件上传相关的标志
+	startCmd.Flags().BoolVar(&enableUpload, "upload", false, i18n.T("flag.upload"))
+	startCmd.Flags().BoolVar(&enableWebDAV, "webdav", false, i18n.T("flag.webdav"))
+
+	// 添加HTTPS相关的标志
+	startCmd.Flags().StringVar(&tlsCert, "tls-cert", "", i18n.T("flag.tls_cert"))
+	startCmd.Flags().StringVar(&tlsKey, "tls-key", "", i18n.T("flag.tls_key"))
+	startCmd.Flags().BoolVar(&tlsSelfSigned, "tls-self-signed", false, i18n.T("flag.tls_self_signed"))
+
+	// 添加压缩相关的标志
+	startCmd.Flags().BoolVar(&enableCompression, "compress", false, i18n.T("flag.compress"))
+	startCmd.Flags().IntVar(&compressionMinSize, "compress-min-size", server.DefaultCompressionMinSize, i18n.T("flag.compress_min_size"))
+	startCmd.Flags().StringSliceVar(&compressionTypes, "compress-types", server.DefaultCompressionTypes, i18n.T("flag.compress_types"))
+
+	// 添加开发相关的标志
+	startCmd.Flags().BoolVar(&liveReload, "live-reload", false, i18n.T("flag.live_reload"))
+
+	// 添加全文搜索相关的标志
+	startCmd.Flags().BoolVar(&fullTextIndex, "fulltext", false, i18n.T("flag.fulltext"))
+	startCmd.Flags().Int64Var(&fullTextMaxFileSize, "fulltext-max-size", fulltext.DefaultMaxFileSize, i18n.T("flag.fulltext_max_size"))
+	startCmd.Flags().StringSliceVar(&fullTextExtensions, "fulltext-ext", fulltext.DefaultExtensions, i18n.T("flag.fulltext_ext"))
+
+	// 添加优雅关闭相关的标志
+	startCmd.Flags().DurationVar(&shutdownTimeout, "shutdown-timeout", server.DefaultShutdownTimeout, i18n.T("flag.shutdown_timeout"))
+
 	// 添加认证相关的标志
 	startCmd.Flags().StringVarP(&authType, "auth", "a", "none", i18n.T("flag.auth_type"))
 	startCmd.Flags().StringVarP(&username, "username", "u", "", i18n.T("flag.username"))
 	startCmd.Flags().StringVarP(&password, "password", "w", "", i18n.T("flag.password"))
 	startCmd.Flags().StringVarP(&token, "token", "t", "", i18n.T("flag.token"))
 	startCmd.Flags().BoolVarP(&enableLoginPage, "login-page", "l", false, i18n.T("flag.login_page"))
+	startCmd.Flags().StringVar(&htpasswdFile, "htpasswd", "", i18n.T("flag.htpasswd"))
+	startCmd.Flags().StringVar(&tokenFile, "tokens", "", i18n.T("flag.tokens"))
+	startCmd.Flags().StringVar(&aclPolicy, "acl", "", i18n.T("flag.acl"))
+	startCmd.Flags().DurationVar(&sessionMaxAge, "session-max-age", auth.DefaultSessionMaxAge, i18n.T("flag.session_max_age"))
+	startCmd.Flags().DurationVar(&sessionIdleTimeout, "session-idle-timeout", auth.DefaultSessionIdleTimeout, i18n.T("flag.session_idle_timeout"))
+	startCmd.Flags().DurationVar(&rememberMeMaxAge, "remember-me-max-age", auth.DefaultRememberMeMaxAge, i18n.T("flag.remember_me_max_age"))
 
 	// 添加日志相关的标志
 	startCmd.Flags().StringVar(&logLevel, "log-level", "info", i18n.T("flag.log_level"))
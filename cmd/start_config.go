@@ -26,6 +26,12 @@ func processStartConfig(cmd *cobra.Command) error {
 	if !cmd.Flags().Changed("dir-list") {
 		enableDirListing = cfg.EnableDirListing
 	}
+	if !cmd.Flags().Changed("spa") {
+		spaMode = cfg.SPA
+	}
+	if !cmd.Flags().Changed("show-hidden") {
+		showHidden = cfg.ShowHidden
+	}
 	if !cmd.Flags().Changed("theme") {
 		theme = cfg.Theme
 	}
@@ -1,21 +1,77 @@
 package cmd
 
+import "time"
+
 // 命令行标志
 var (
 	// 是否自动打开浏览器（命令行标志）
 	autoOpen bool
 
+	// 监听地址，例如: "127.0.0.1:8080"、"[::1]:8080"、"unix:/run/servergo.sock"，不带端口时使用 --port 或探测到的端口
+	bindAddrs []string
+
+	// 挂载点，格式为 "前缀=目录"，例如: "/docs=./site"
+	mountSpecs []string
+
+	// 反向代理规则，格式为 "前缀=上游地址"，例如: "/api=http://127.0.0.1:3000"
+	proxySpecs []string
+
+	// 反向代理后部署相关标志
+	trustedProxies []string // 可信代理的IP或网段，例如: "127.0.0.1,10.0.0.0/8"
+	proxyProtocol  bool     // 是否在TCP监听器上接受PROXY协议头部
+
 	// 认证相关标志
 	authType        string // 认证类型：none, basic, token, form
 	username        string // 用户名
 	password        string // 密码
 	token           string // 令牌
 	enableLoginPage bool   // 是否启用登录页面
+	htpasswdFile    string // htpasswd用户文件
+	tokenFile       string // 托管令牌文件
+	aclPolicy       string // 访问控制策略文件
+
+	// 表单认证会话相关标志
+	sessionMaxAge      time.Duration // 会话的最长有效期
+	sessionIdleTimeout time.Duration // 会话的空闲超时
+	rememberMeMaxAge   time.Duration // 勾选"记住我"时会话的有效期
 
 	// 目录浏览相关标志
 	enableDirListing bool   // 是否启用目录列表功能
 	theme            string // 目录列表主题
 
+	// 单页应用相关标志
+	spaMode    bool     // 是否启用单页应用模式
+	spaExclude []string // 不回退到index.html的路径前缀
+
+	// 隐藏文件相关标志
+	showHidden      bool     // 是否显示隐藏文件
+	excludePatterns []string // 额外隐藏的路径（gitignore语法）
+
+	// 文件上传相关标志
+	enableUpload bool // 是否允许上传文件
+	enableWebDAV bool // 是否启用WebDAV
+
+	// HTTPS相关标志
+	tlsCert       string // 证书文件路径
+	tlsKey        string // 私钥文件路径
+	tlsSelfSigned bool   // 是否使用自签名证书
+
+	// 压缩相关标志
+	enableCompression  bool     // 是否压缩响应
+	compressionMinSize int      // 最小压缩大小
+	compressionTypes   []string // 允许压缩的MIME类型
+
+	// 开发相关标志
+	liveReload bool // 是否启用实时刷新
+
+	// 全文搜索相关标志
+	fullTextIndex       bool     // 是否建立全文索引
+	fullTextMaxFileSize int64    // 被索引文件大小的上限
+	fullTextExtensions  []string // 被索引的文件扩展名
+
+	// 关闭相关标志
+	shutdownTimeout time.Duration // 优雅关闭的宽限期
+
 	// 日志相关标志
 	logLevel             string // 日志级别
 	enableLogPersistence bool   // 是否启用日志持久化
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"fmt"
 	"os/exec"
 	"runtime"
 
 	"github.com/CC11001100/servergo/pkg/config"
 	"github.com/CC11001100/servergo/pkg/i18n"
 	"github.com/CC11001100/servergo/pkg/logger"
+	"github.com/CC11001100/servergo/pkg/server"
+	"github.com/spf13/cobra"
 )
 
 // 打开系统默认浏览器访问URL
@@ -49,3 +52,88 @@ func getStartPort() int {
 	// 如果都没有指定，则返回0表示随机选择
 	return 0
 }
+
+// localURL 返回本机访问指定端口的地址，启用HTTPS相关标志时使用https协议
+func localURL(port int) string {
+	scheme := "http"
+	if tlsSelfSigned || tlsCert != "" || tlsKey != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d", scheme, port)
+}
+
+// buildMounts 根据 --mount 参数和位置参数确定要挂载的目录
+// 只有一个位置参数时把它作为服务目录；有多个目录时每个目录挂载到以目录名命名的前缀下；
+// 使用 --mount 时，显式指定的 --dir 挂载到根路径
+func buildMounts(cmd *cobra.Command, args []string) ([]server.Mount, error) {
+	if len(mountSpecs) == 0 && len(args) == 1 {
+		dir = args[0]
+		return nil, nil
+	}
+
+	mounts := make([]server.Mount, 0, len(mountSpecs)+len(args))
+	for _, spec := range mountSpecs {
+		m, err := server.ParseMount(spec)
+		if err != nil {
+			return nil, err
+		}
+		mounts = append(mounts, m)
+	}
+	mounts = append(mounts, server.MountsFromDirs(args)...)
+
+	if len(mounts) > 0 && cmd.Flags().Changed("dir") {
+		mounts = append(mounts, server.Mount{Prefix: "/", Dir: dir})
+	}
+	return mounts, nil
+}
+
+// bindPlan --bind 参数的解析结果
+type bindPlan struct {
+	addrs      []string // 所有监听地址，传给 server.Config.Bind
+	probeHosts []string // 没有指定端口的TCP地址的主机部分，需要在这些地址上探测可用端口
+	fixedPort  int      // 所有TCP地址都指定了端口时为第一个TCP地址的端口，否则为0
+	tcp        bool     // 是否监听TCP端口，只监听Unix套接字时为false，不需要探测端口也不能打开浏览器
+}
+
+// parseBindAddrs 解析 --bind 参数，没有指定时监听所有接口
+func parseBindAddrs() (bindPlan, error) {
+	plan := bindPlan{addrs: append([]string{}, bindAddrs...)}
+	if len(plan.addrs) == 0 {
+		plan.tcp = true
+		return plan, nil
+	}
+
+	for _, addr := range plan.addrs {
+		if _, ok := server.UnixSocketPath(addr); ok {
+			continue
+		}
+		host, p, err := server.ParseBindAddr(addr)
+		if err != nil {
+			return plan, err
+		}
+		if !plan.tcp {
+			plan.tcp = true
+			plan.fixedPort = p
+		}
+		if p == 0 {
+			plan.probeHosts = append(plan.probeHosts, host)
+		}
+	}
+	if len(plan.probeHosts) > 0 {
+		plan.fixedPort = 0
+	}
+	return plan, nil
+}
+
+// buildProxies 解析 --proxy 参数
+func buildProxies() ([]server.ProxyRule, error) {
+	proxies := make([]server.ProxyRule, 0, len(proxySpecs))
+	for _, spec := range proxySpecs {
+		rule, err := server.ParseProxyRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, rule)
+	}
+	return proxies, nil
+}
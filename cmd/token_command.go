@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// 令牌管理相关标志
+var (
+	tokenStoreFile string        // 托管令牌文件，为空时使用 ~/.servergo/tokens
+	tokenScope     string        // 新令牌的权限范围：read, write, admin
+	tokenPath      string        // 新令牌可以访问的URL路径前缀
+	tokenExpires   time.Duration // 新令牌的有效期，为0时永不过期
+)
+
+// tokenTimeLayout 列出令牌时的时间格式
+const tokenTimeLayout = "2006-01-02 15:04"
+
+// tokenCmd 表示令牌管理相关的命令，管理令牌认证使用的托管令牌
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: i18n.T("cmd.token.short"),
+	Long:  i18n.T("cmd.token.long"),
+}
+
+// tokenCreateCmd 创建令牌，令牌只在创建时打印一次
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: i18n.T("cmd.token.create.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scope, err := auth.ParseTokenScope(tokenScope)
+		if err != nil {
+			return err
+		}
+		tokens, err := openTokenStore()
+		if err != nil {
+			return err
+		}
+		secret, err := tokens.Create(args[0], scope, tokenPath, tokenExpires)
+		if err != nil {
+			return err
+		}
+		if err := tokens.Save(); err != nil {
+			return fmt.Errorf(i18n.Tf("error.token_store_save_failed", err))
+		}
+		// 提示信息输出到标准错误，标准输出只有令牌，便于在脚本中使用，例如: TOKEN=$(servergo token create ci-deploy)
+		fmt.Fprintln(os.Stderr, i18n.Tf("token.created", args[0], tokens.Path()))
+		fmt.Println(secret)
+		return nil
+	},
+}
+
+// tokenListCmd 列出所有令牌，不显示令牌本身
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: i18n.T("cmd.token.list.short"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokens, err := openTokenStore()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, i18n.T("token.list_header"))
+		now := time.Now()
+		for _, token := range tokens.Tokens() {
+			expires := i18n.T("token.never_expires")
+			if token.ExpiresAt != 0 {
+				expires = time.Unix(token.ExpiresAt, 0).Format(tokenTimeLayout)
+				if token.Expired(now) {
+					expires += " " + i18n.T("token.expired")
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", token.Name, token.Scope, token.Path,
+				time.Unix(token.CreatedAt, 0).Format(tokenTimeLayout), expires)
+		}
+		return w.Flush()
+	},
+}
+
+// tokenRevokeCmd 吊销令牌，运行中的服务器随之拒绝该令牌
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <name>",
+	Short: i18n.T("cmd.token.revoke.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokens, err := openTokenStore()
+		if err != nil {
+			return err
+		}
+		if !tokens.Revoke(args[0]) {
+			return fmt.Errorf(i18n.Tf("error.token_not_found", args[0]))
+		}
+		if err := tokens.Save(); err != nil {
+			return fmt.Errorf(i18n.Tf("error.token_store_save_failed", err))
+		}
+		logger.Info(i18n.Tf("token.revoked", args[0]))
+		return nil
+	},
+}
+
+// openTokenStore 打开令牌文件，文件不存在时返回空的令牌文件
+func openTokenStore() (*auth.TokenStore, error) {
+	path := tokenStoreFile
+	if path == "" {
+		var err error
+		if path, err = auth.DefaultTokenStorePath(); err != nil {
+			return nil, err
+		}
+	}
+	tokens, err := auth.OpenTokenStore(path)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.Tf("error.token_store_load_failed", err))
+	}
+	return tokens, nil
+}
+
+func init() {
+	RootCmd.AddCommand(tokenCmd)
+
+	// 添加子命令
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+
+	tokenCmd.PersistentFlags().StringVar(&tokenStoreFile, "tokens", "", i18n.T("flag.token_store"))
+	tokenCreateCmd.Flags().StringVar(&tokenScope, "scope", string(auth.ScopeRead), i18n.T("flag.token_scope"))
+	tokenCreateCmd.Flags().StringVar(&tokenPath, "path", "/", i18n.T("flag.token_path"))
+	tokenCreateCmd.Flags().DurationVar(&tokenExpires, "expires", 0, i18n.T("flag.token_expires"))
+}
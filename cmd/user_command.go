@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/config"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// 用户管理相关标志
+var (
+	userHtpasswdFile string // htpasswd用户文件，为空时使用 ~/.servergo/htpasswd
+	userPassword     string // 新密码，为空时从终端或标准输入读取
+)
+
+// userCmd 表示用户管理相关的命令，管理 --htpasswd 使用的用户文件
+var userCmd = &cobra.Command{
+	Use:   "user",
+	Short: i18n.T("cmd.user.short"),
+	Long:  i18n.T("cmd.user.long"),
+}
+
+// userAddCmd 添加用户
+var userAddCmd = &cobra.Command{
+	Use:   "add <username>",
+	Short: i18n.T("cmd.user.add.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		users, err := openUserFile()
+		if err != nil {
+			return err
+		}
+		if users.Has(args[0]) {
+			return fmt.Errorf(i18n.Tf("error.user_exists", args[0]))
+		}
+		if err := setUserPassword(users, args[0]); err != nil {
+			return err
+		}
+		logger.Info(i18n.Tf("user.added", args[0], users.Path()))
+		return nil
+	},
+}
+
+// userPasswdCmd 修改用户的密码
+var userPasswdCmd = &cobra.Command{
+	Use:   "passwd <username>",
+	Short: i18n.T("cmd.user.passwd.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		users, err := openUserFile()
+		if err != nil {
+			return err
+		}
+		if !users.Has(args[0]) {
+			return fmt.Errorf(i18n.Tf("error.user_not_found", args[0]))
+		}
+		if err := setUserPassword(users, args[0]); err != nil {
+			return err
+		}
+		logger.Info(i18n.Tf("user.password_changed", args[0]))
+		return nil
+	},
+}
+
+// userRemoveCmd 删除用户，已登录的表单认证会话随之失效
+var userRemoveCmd = &cobra.Command{
+	Use:   "remove <username>",
+	Short: i18n.T("cmd.user.remove.short"),
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		users, err := openUserFile()
+		if err != nil {
+			return err
+		}
+		if !users.Remove(args[0]) {
+			return fmt.Errorf(i18n.Tf("error.user_not_found", args[0]))
+		}
+		if err := users.Save(); err != nil {
+			return fmt.Errorf(i18n.Tf("error.htpasswd_save_failed", err))
+		}
+		logger.Info(i18n.Tf("user.removed", args[0]))
+		return nil
+	},
+}
+
+// userListCmd 列出所有用户
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: i18n.T("cmd.user.list.short"),
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		users, err := openUserFile()
+		if err != nil {
+			return err
+		}
+		for _, name := range users.Users() {
+			fmt.Println(name)
+		}
+		return nil
+	},
+}
+
+// openUserFile 打开用户文件，文件不存在时返回空的用户文件
+func openUserFile() (*auth.HtpasswdFile, error) {
+	path := userHtpasswdFile
+	if path == "" {
+		dir, err := config.GetConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "htpasswd")
+	}
+	users, err := auth.OpenHtpasswd(path)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.Tf("error.htpasswd_load_failed", err))
+	}
+	return users, nil
+}
+
+// setUserPassword 设置用户的密码并保存文件
+func setUserPassword(users *auth.HtpasswdFile, username string) error {
+	password, err := readNewPassword()
+	if err != nil {
+		return err
+	}
+	if err := users.Set(username, password); err != nil {
+		return err
+	}
+	if err := users.Save(); err != nil {
+		return fmt.Errorf(i18n.Tf("error.htpasswd_save_failed", err))
+	}
+	return nil
+}
+
+// readNewPassword 读取新密码：优先使用 --password；标准输入是终端时提示输入两次且不回显，
+// 否则从标准输入读取一行，便于在脚本中使用，例如: echo secret | servergo user add alice
+func readNewPassword() (string, error) {
+	if userPassword != "" {
+		return userPassword, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if err == nil {
+				err = errors.New(i18n.T("error.password_empty"))
+			}
+			return "", err
+		}
+		return line, nil
+	}
+
+	fmt.Fprint(os.Stderr, i18n.T("user.password_prompt"))
+	first, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if len(first) == 0 {
+		return "", errors.New(i18n.T("error.password_empty"))
+	}
+	fmt.Fprint(os.Stderr, i18n.T("user.password_confirm"))
+	second, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(first) != string(second) {
+		return "", errors.New(i18n.T("error.password_mismatch"))
+	}
+	return string(first), nil
+}
+
+func init() {
+	RootCmd.AddCommand(userCmd)
+
+	// 添加子命令
+	userCmd.AddCommand(userAddCmd)
+	userCmd.AddCommand(userPasswdCmd)
+	userCmd.AddCommand(userRemoveCmd)
+	userCmd.AddCommand(userListCmd)
+
+	userCmd.PersistentFlags().StringVar(&userHtpasswdFile, "htpasswd", "", i18n.T("flag.user_htpasswd"))
+	userAddCmd.Flags().StringVarP(&userPassword, "password", "p", "", i18n.T("flag.user_password"))
+	userPasswdCmd.Flags().StringVarP(&userPassword, "password", "p", "", i18n.T("flag.user_password"))
+}
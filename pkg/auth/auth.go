@@ -1,7 +1,14 @@
 package auth
 
 import (
+	"crypto/subtle"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/CC11001100/servergo/pkg/config"
+	"github.com/CC11001100/servergo/pkg/i18n"
 	"github.com/CC11001100/servergo/pkg/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -24,6 +31,11 @@ const (
 type Authenticator interface {
 	// Middleware 返回一个Gin中间件，用于处理认证
 	Middleware() gin.HandlerFunc
+	// Authenticate 检查请求是否通过认证，不写入任何错误响应，多个认证器组合使用时依次调用
+	// 返回认证的用户名（令牌认证等没有用户名时为空）和是否通过认证
+	Authenticate(c *gin.Context) (user string, ok bool)
+	// Challenge 认证失败时写入响应并中止请求，例如返回401或重定向到登录页面
+	Challenge(c *gin.Context)
 	// AuthType 返回认证类型
 	AuthType() AuthType
 	// LoginPageEnabled 返回是否启用了登录页
@@ -36,6 +48,8 @@ type Authenticator interface {
 type Config struct {
 	// AuthType 认证类型
 	Type AuthType
+	// Types 同时启用的多种认证方式，任一方式通过即可，不为空时忽略Type
+	Types []AuthType
 	// Username 用户名，用于BasicAuth和FormAuth
 	Username string
 	// Password 密码，用于BasicAuth和FormAuth
@@ -46,6 +60,20 @@ type Config struct {
 	EnableLoginPage bool
 	// Realm 认证域，用于BasicAuth
 	Realm string
+	// SecureCookie 是否给认证Cookie设置Secure属性，启用HTTPS时应为true
+	SecureCookie bool
+	// SessionMaxAge 表单认证会话的最长有效期，为0时使用 DefaultSessionMaxAge
+	SessionMaxAge time.Duration
+	// SessionIdleTimeout 表单认证会话的空闲超时，为0时使用 DefaultSessionIdleTimeout
+	SessionIdleTimeout time.Duration
+	// RememberMeMaxAge 勾选"记住我"时会话的有效期，为0时使用 DefaultRememberMeMaxAge
+	RememberMeMaxAge time.Duration
+	// SessionDir 保存会话签名密钥的目录，为空时使用 ~/.servergo
+	SessionDir string
+	// Users htpasswd用户文件，设置后BasicAuth和FormAuth验证文件中的用户，忽略Username和Password
+	Users *HtpasswdFile
+	// Tokens 托管令牌文件，设置后TokenAuth还接受文件中未过期的令牌
+	Tokens *TokenStore
 }
 
 // NewAuthenticator 根据配置创建一个认证器
@@ -68,12 +96,30 @@ func NewAuthenticator(config Config) Authenticator {
 		config.Password = utils.GenerateRandomPassword(16, false)
 	}
 
-	// 如果是TokenAuth且没有指定token，生成随机token
-	if config.Type == TokenAuth && config.Token == "" {
+	types := config.Types
+	if len(types) == 0 {
+		types = []AuthType{config.Type}
+	}
+
+	// 如果启用了TokenAuth且没有指定token，生成随机token
+	if slices.Contains(types, TokenAuth) && config.Token == "" {
 		config.Token = utils.GenerateRandomPassword(32, true) // token使用更长的长度和特殊字符
 	}
 
-	switch config.Type {
+	// 启用了多种认证方式时，任一方式通过即可
+	if len(types) > 1 {
+		authenticators := make([]Authenticator, 0, len(types))
+		for _, t := range types {
+			authenticators = append(authenticators, newAuthenticator(t, config))
+		}
+		return NewChain(authenticators...)
+	}
+	return newAuthenticator(types[0], config)
+}
+
+// newAuthenticator 创建一种认证方式的认证器
+func newAuthenticator(authType AuthType, config Config) Authenticator {
+	switch authType {
 	case BasicAuth:
 		return NewBasicAuth(config)
 	case TokenAuth:
@@ -85,6 +131,56 @@ func NewAuthenticator(config Config) Authenticator {
 	}
 }
 
+// ParseAuthTypes 解析逗号分隔的认证方式，例如 "basic,token"
+//
+// 返回值:
+//   - []AuthType: 去掉重复项后的认证方式，按指定的顺序排列，认证失败时使用第一种方式的响应
+//   - error: 包含未知的认证方式，或者 none 与其他方式一起指定时返回错误
+func ParseAuthTypes(spec string) ([]AuthType, error) {
+	var types []AuthType
+	for _, name := range strings.Split(spec, ",") {
+		t := AuthType(strings.ToLower(strings.TrimSpace(name)))
+		switch t {
+		case NoAuth, BasicAuth, TokenAuth, FormAuth:
+		default:
+			return nil, fmt.Errorf(i18n.Tf("error.auth_type_invalid", name))
+		}
+		if !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if len(types) > 1 && slices.Contains(types, NoAuth) {
+		return nil, fmt.Errorf(i18n.Tf("error.auth_type_invalid", spec))
+	}
+	return types, nil
+}
+
+// authMiddleware 使用认证器的 Authenticate 和 Challenge 创建中间件，认证通过后用户名保存在 gin.AuthUserKey 中
+func authMiddleware(a Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := a.Authenticate(c)
+		if !ok {
+			a.Challenge(c)
+			return
+		}
+		if user != "" {
+			c.Set(gin.AuthUserKey, user)
+		}
+		c.Next()
+	}
+}
+
+// checkCredentials 检查用户名和密码，配置了htpasswd用户文件时验证文件中的用户，
+// 否则与单个用户比较，使用固定时间比较避免通过响应时间猜测密码
+func checkCredentials(users *HtpasswdFile, expectedUser, expectedPass, username, password string) bool {
+	if users != nil {
+		return users.Verify(username, password)
+	}
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(expectedUser)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(password), []byte(expectedPass)) == 1
+	return userOK && passOK
+}
+
 // GetGlobalConfig 获取全局配置
 func (c *Config) GetGlobalConfig() config.Config {
 	return config.DefaultConfig()
@@ -105,6 +201,14 @@ func (a *NoAuthenticator) Middleware() gin.HandlerFunc {
 	}
 }
 
+// Authenticate 所有请求都通过认证
+func (a *NoAuthenticator) Authenticate(c *gin.Context) (string, bool) {
+	return "", true
+}
+
+// Challenge 不会被调用
+func (a *NoAuthenticator) Challenge(c *gin.Context) {}
+
 // AuthType 返回认证类型
 func (a *NoAuthenticator) AuthType() AuthType {
 	return NoAuth
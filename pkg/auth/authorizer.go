@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/CC11001100/servergo/pkg/i18n"
+)
+
+// Permission 表示对路径的操作权限，可以用 | 组合多个权限
+type Permission uint8
+
+const (
+	// PermRead 读取文件内容，并在目录列表、搜索结果中看到该路径
+	PermRead Permission = 1 << iota
+	// PermList 查看目录列表、在目录中搜索以及打包下载目录
+	PermList
+	// PermWrite 上传、覆盖文件和创建目录
+	PermWrite
+	// PermDelete 删除文件和目录，移动时源路径需要该权限
+	PermDelete
+
+	// PermAll 所有权限
+	PermAll = PermRead | PermList | PermWrite | PermDelete
+)
+
+// permissionNames 策略文件中的权限名称
+var permissionNames = map[string]Permission{
+	"read":   PermRead,
+	"list":   PermList,
+	"write":  PermWrite,
+	"delete": PermDelete,
+}
+
+// AnyUser 策略文件中匹配所有用户（包括未认证的用户）的用户名
+const AnyUser = "*"
+
+// Authorizer 接口定义了授权器的方法
+// Authenticator 确认请求的用户是谁，Authorizer 决定该用户能对哪些路径做什么
+type Authorizer interface {
+	// Allowed 判断用户对URL路径是否有指定的权限，user 为空表示未认证的用户
+	Allowed(user, urlPath string, perm Permission) bool
+}
+
+// AllowAllAuthorizer 实现了一个不限制任何权限的授权器，没有配置策略文件时使用
+type AllowAllAuthorizer struct{}
+
+// NewAllowAll 创建一个不限制任何权限的授权器
+func NewAllowAll() *AllowAllAuthorizer {
+	return &AllowAllAuthorizer{}
+}
+
+// Allowed 总是返回true
+func (a *AllowAllAuthorizer) Allowed(user, urlPath string, perm Permission) bool {
+	return true
+}
+
+// policyFile 策略文件的格式
+//
+//	default: [read, list]
+//	paths:
+//	  /private:
+//	    alice: [read, list, write, delete]
+//	    bob: [read, list]
+//	    "*": []
+type policyFile struct {
+	Default *[]string                      `yaml:"default"` // 没有匹配的规则时的权限，不写时不限制
+	Paths   map[string]map[string][]string `yaml:"paths"`   // URL路径前缀 -> 用户名 -> 权限
+}
+
+// policyRule 一个路径前缀下各个用户的权限
+type policyRule struct {
+	prefix string
+	users  map[string]Permission
+}
+
+// PolicyAuthorizer 按YAML策略文件中的规则授权
+// 使用与请求路径匹配的最长前缀的规则：规则中列出了该用户时使用该用户的权限，否则使用 "*" 的权限；
+// 规则中既没有该用户也没有 "*" 时继续匹配更短的前缀，都没有匹配时使用 default 的权限
+type PolicyAuthorizer struct {
+	rules       []policyRule // 按前缀从长到短排序
+	defaultPerm Permission
+}
+
+// LoadPolicy 读取YAML策略文件
+//
+// 参数:
+//   - path: 文件路径，例如: "/etc/servergo/acl.yaml"
+//
+// 返回值:
+//   - *PolicyAuthorizer: 授权器
+//   - error: 文件无法读取、格式错误或包含未知的权限名称时返回错误
+func LoadPolicy(path string) (*PolicyAuthorizer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return a, nil
+}
+
+// ParsePolicy 解析YAML格式的策略
+func ParsePolicy(data []byte) (*PolicyAuthorizer, error) {
+	var file policyFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	a := &PolicyAuthorizer{defaultPerm: PermAll}
+	if file.Default != nil {
+		perm, err := parsePermissions(*file.Default)
+		if err != nil {
+			return nil, err
+		}
+		a.defaultPerm = perm
+	}
+
+	seen := map[string]bool{}
+	for prefix, users := range file.Paths {
+		prefix = cleanURLPath(prefix)
+		if seen[prefix] {
+			return nil, fmt.Errorf(i18n.Tf("error.acl_duplicate_path", prefix))
+		}
+		seen[prefix] = true
+
+		rule := policyRule{prefix: prefix, users: make(map[string]Permission, len(users))}
+		for user, names := range users {
+			perm, err := parsePermissions(names)
+			if err != nil {
+				return nil, err
+			}
+			rule.users[user] = perm
+		}
+		a.rules = append(a.rules, rule)
+	}
+
+	sort.Slice(a.rules, func(i, j int) bool {
+		return len(a.rules[i].prefix) > len(a.rules[j].prefix)
+	})
+	return a, nil
+}
+
+// parsePermissions 把权限名称列表转换为权限
+func parsePermissions(names []string) (Permission, error) {
+	var perm Permission
+	for _, name := range names {
+		p, ok := permissionNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf(i18n.Tf("error.acl_permission_invalid", name))
+		}
+		perm |= p
+	}
+	return perm, nil
+}
+
+// cleanURLPath 规范化URL路径，例如 "private/" 规范化为 "/private"
+func cleanURLPath(p string) string {
+	return path.Clean("/" + strings.TrimSpace(p))
+}
+
+// Allowed 判断用户对URL路径是否有指定的权限，perm 包含多个权限时需要全部具有
+func (a *PolicyAuthorizer) Allowed(user, urlPath string, perm Permission) bool {
+	return a.permissions(user, cleanURLPath(urlPath))&perm == perm
+}
+
+// permissions 返回用户对路径的所有权限
+func (a *PolicyAuthorizer) permissions(user, urlPath string) Permission {
+	for _, rule := range a.rules {
+		if rule.prefix != "/" && urlPath != rule.prefix && !strings.HasPrefix(urlPath, rule.prefix+"/") {
+			continue
+		}
+		if perm, ok := rule.users[user]; ok && user != "" {
+			return perm
+		}
+		if perm, ok := rule.users[AnyUser]; ok {
+			return perm
+		}
+	}
+	return a.defaultPerm
+}
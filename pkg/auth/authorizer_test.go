@@ -0,0 +1,77 @@
+package auth
+
+import "testing"
+
+// TestPolicyAuthorizer 测试按最长前缀和用户名匹配规则
+func TestPolicyAuthorizer(t *testing.T) {
+	a, err := ParsePolicy([]byte(`
+default: [read, list]
+paths:
+  /private:
+    alice: [read, list, write, delete]
+    bob: [read, list]
+    "*": []
+  /private/shared:
+    "*": [read]
+  /uploads/:
+    "*": [read, list, write]
+`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() 错误: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		path     string
+		perm     Permission
+		expected bool
+	}{
+		{name: "默认规则允许读取", user: "carol", path: "/docs/a.txt", perm: PermRead, expected: true},
+		{name: "默认规则不允许写入", user: "carol", path: "/docs/a.txt", perm: PermWrite},
+		{name: "列出的用户", user: "alice", path: "/private/a.txt", perm: PermWrite | PermDelete, expected: true},
+		{name: "只读用户", user: "bob", path: "/private", perm: PermList, expected: true},
+		{name: "只读用户不能写入", user: "bob", path: "/private/a.txt", perm: PermWrite},
+		{name: "其他用户使用*的权限", user: "carol", path: "/private/a.txt", perm: PermRead},
+		{name: "未认证的用户使用*的权限", path: "/private", perm: PermRead},
+		{name: "前缀只匹配完整的路径段", user: "carol", path: "/private-notes", perm: PermRead, expected: true},
+		{name: "更长的前缀优先", user: "carol", path: "/private/shared/a.txt", perm: PermRead, expected: true},
+		{name: "更长的前缀只授予读取", user: "carol", path: "/private/shared", perm: PermList},
+		{name: "规则路径结尾的斜线", user: "carol", path: "/uploads/a.txt", perm: PermWrite, expected: true},
+		{name: "请求路径中的..", user: "carol", path: "/uploads/../private/a.txt", perm: PermRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.Allowed(tt.user, tt.path, tt.perm); got != tt.expected {
+				t.Errorf("Allowed(%q, %q, %d) = %v, 期望 %v", tt.user, tt.path, tt.perm, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestParsePolicyErrors 测试格式错误的策略
+func TestParsePolicyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+	}{
+		{name: "未知的权限", policy: "paths:\n  /a:\n    alice: [admin]\n"},
+		{name: "未知的字段", policy: "rules: []\n"},
+		{name: "重复的路径", policy: "paths:\n  /a:\n    alice: [read]\n  /a/:\n    bob: [read]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParsePolicy([]byte(tt.policy)); err == nil {
+				t.Errorf("期望返回错误")
+			}
+		})
+	}
+
+	// 空的策略不限制任何权限
+	a, err := ParsePolicy(nil)
+	if err != nil || !a.Allowed("", "/a", PermAll) {
+		t.Errorf("空的策略应该允许所有操作, 错误: %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package auth
 
 import (
+	"net/http"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -9,6 +12,7 @@ type BasicAuthenticator struct {
 	username string
 	password string
 	realm    string
+	users    *HtpasswdFile
 }
 
 // NewBasicAuth 创建一个BasicAuth认证器
@@ -22,15 +26,28 @@ func NewBasicAuth(config Config) *BasicAuthenticator {
 		username: config.Username,
 		password: config.Password,
 		realm:    realm,
+		users:    config.Users,
 	}
 }
 
-// Middleware 返回Gin的Basic认证中间件
+// Middleware 返回Basic认证中间件，认证通过后用户名保存在 gin.AuthUserKey 中
 func (a *BasicAuthenticator) Middleware() gin.HandlerFunc {
-	accounts := gin.Accounts{
-		a.username: a.password,
+	return authMiddleware(a)
+}
+
+// Authenticate 检查 Authorization 请求头中的用户名和密码
+func (a *BasicAuthenticator) Authenticate(c *gin.Context) (string, bool) {
+	username, password, ok := c.Request.BasicAuth()
+	if !ok || !checkCredentials(a.users, a.username, a.password, username, password) {
+		return "", false
 	}
-	return gin.BasicAuth(accounts)
+	return username, true
+}
+
+// Challenge 返回401，浏览器会弹出认证对话框
+func (a *BasicAuthenticator) Challenge(c *gin.Context) {
+	c.Header("WWW-Authenticate", "Basic realm="+strconv.Quote(a.realm))
+	c.AbortWithStatus(http.StatusUnauthorized)
 }
 
 // AuthType 返回认证类型
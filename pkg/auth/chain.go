@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ChainAuthenticator 组合多个认证器，任一认证器通过即可
+// 例如同时启用Basic和令牌认证时，浏览器使用用户名和密码，脚本使用令牌；
+// 都没有通过时使用第一个认证器的响应，例如Basic认证的401或表单认证的重定向
+type ChainAuthenticator struct {
+	authenticators []Authenticator
+}
+
+// NewChain 创建组合多个认证器的认证器
+//
+// 参数:
+//   - authenticators: 按顺序尝试的认证器，至少一个
+func NewChain(authenticators ...Authenticator) *ChainAuthenticator {
+	return &ChainAuthenticator{authenticators: authenticators}
+}
+
+// Authenticators 返回组合的所有认证器
+func (a *ChainAuthenticator) Authenticators() []Authenticator {
+	return a.authenticators
+}
+
+// Middleware 返回依次尝试所有认证器的中间件
+// 表单认证的登录页面、注销和静态资源仍然由表单认证器处理
+func (a *ChainAuthenticator) Middleware() gin.HandlerFunc {
+	next := authMiddleware(a)
+	return func(c *gin.Context) {
+		for _, authenticator := range a.authenticators {
+			if form, ok := authenticator.(*FormAuthenticator); ok && form.serveAuthPages(c) {
+				return
+			}
+		}
+		next(c)
+	}
+}
+
+// Authenticate 依次尝试每个认证器，返回第一个通过认证的结果
+func (a *ChainAuthenticator) Authenticate(c *gin.Context) (string, bool) {
+	for _, authenticator := range a.authenticators {
+		if user, ok := authenticator.Authenticate(c); ok {
+			return user, true
+		}
+	}
+	return "", false
+}
+
+// Challenge 使用第一个认证器的响应
+func (a *ChainAuthenticator) Challenge(c *gin.Context) {
+	a.authenticators[0].Challenge(c)
+}
+
+// AuthType 返回第一个认证器的认证类型
+func (a *ChainAuthenticator) AuthType() AuthType {
+	return a.authenticators[0].AuthType()
+}
+
+// LoginPageEnabled 返回是否有认证器启用了登录页
+func (a *ChainAuthenticator) LoginPageEnabled() bool {
+	for _, authenticator := range a.authenticators {
+		if authenticator.LoginPageEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCredentials 返回第一个认证器的认证凭据
+func (a *ChainAuthenticator) GetCredentials() (username, password string) {
+	return a.authenticators[0].GetCredentials()
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestParseAuthTypes 测试解析逗号分隔的认证方式
+func TestParseAuthTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		expected  []AuthType
+		expectErr bool
+	}{
+		{name: "单个认证方式", spec: "basic", expected: []AuthType{BasicAuth}},
+		{name: "多个认证方式", spec: "basic, Token", expected: []AuthType{BasicAuth, TokenAuth}},
+		{name: "去掉重复项", spec: "form,token,form", expected: []AuthType{FormAuth, TokenAuth}},
+		{name: "不认证", spec: "none", expected: []AuthType{NoAuth}},
+		{name: "未知的认证方式", spec: "basic,ldap", expectErr: true},
+		{name: "none与其他方式一起指定", spec: "none,basic", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAuthTypes(tt.spec)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseAuthTypes() 错误: %v", err)
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("ParseAuthTypes() = %v, 期望 %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("ParseAuthTypes() = %v, 期望 %v", got, tt.expected)
+				}
+			}
+		})
+	}
+}
+
+// newChainRouter 创建同时启用多种认证方式的测试路由
+func newChainRouter(t *testing.T, types ...AuthType) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	a := NewAuthenticator(Config{Types: types, Username: "admin", Password: "secret", Token: "tok", SessionDir: t.TempDir()})
+
+	router := gin.New()
+	router.Use(a.Middleware())
+	router.GET("/file.txt", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(gin.AuthUserKey))
+	})
+	return router
+}
+
+// TestChainAuthenticator 测试任一认证方式通过即可
+func TestChainAuthenticator(t *testing.T) {
+	tests := []struct {
+		name         string
+		types        []AuthType
+		setup        func(req *http.Request)
+		expectedCode int
+		expectedUser string
+	}{
+		{
+			name:         "Basic认证通过",
+			types:        []AuthType{BasicAuth, TokenAuth},
+			setup:        func(req *http.Request) { req.SetBasicAuth("admin", "secret") },
+			expectedCode: http.StatusOK,
+			expectedUser: "admin",
+		},
+		{
+			name:         "Bearer令牌通过",
+			types:        []AuthType{BasicAuth, TokenAuth},
+			setup:        func(req *http.Request) { req.Header.Set("Authorization", "Bearer tok") },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "查询参数中的令牌通过",
+			types:        []AuthType{FormAuth, TokenAuth},
+			setup:        func(req *http.Request) { req.URL.RawQuery = "token=tok" },
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "都没有通过时使用第一种方式的响应",
+			types:        []AuthType{BasicAuth, TokenAuth},
+			setup:        func(req *http.Request) { req.Header.Set("Authorization", "Bearer wrong") },
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "表单认证在前时重定向到登录页面",
+			types:        []AuthType{FormAuth, TokenAuth},
+			setup:        func(req *http.Request) {},
+			expectedCode: http.StatusFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newChainRouter(t, tt.types...)
+			req, _ := http.NewRequest(http.MethodGet, "/file.txt", nil)
+			tt.setup(req)
+			w := serve(router, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			if w.Code == http.StatusOK && w.Body.String() != tt.expectedUser {
+				t.Errorf("认证的用户 = %q, 期望 %q", w.Body.String(), tt.expectedUser)
+			}
+			if tt.types[0] == BasicAuth && w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("Basic认证失败时应该返回 WWW-Authenticate")
+			}
+		})
+	}
+}
+
+// TestChainAuthenticatorLogin 测试组合认证时仍然可以通过登录页面登录
+func TestChainAuthenticatorLogin(t *testing.T) {
+	router := newChainRouter(t, TokenAuth, FormAuth)
+
+	req, _ := http.NewRequest(http.MethodGet, "/auth/login", nil)
+	if w := serve(router, req); w.Code != http.StatusOK {
+		t.Fatalf("登录页面 状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+
+	_, cookie := login(t, router, "secret", false)
+	if cookie == nil {
+		t.Fatalf("登录后没有设置会话Cookie")
+	}
+	req, _ = http.NewRequest(http.MethodGet, "/file.txt", nil)
+	if w := serve(router, req, cookie); w.Code != http.StatusOK || w.Body.String() != "admin" {
+		t.Errorf("登录后 状态码 = %d, 用户 = %q", w.Code, w.Body.String())
+	}
+}
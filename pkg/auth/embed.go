@@ -47,6 +47,7 @@ type LoginPageData struct {
 	Subheader        string
 	UsernameLabel    string
 	PasswordLabel    string
+	RememberLabel    string
 	ButtonText       string
 	Footer           string
 	ErrorEmptyFields string
@@ -78,6 +79,7 @@ func GetLoginHTMLContent() (string, error) {
 		Subheader:        i18n.T("login.subheader"),
 		UsernameLabel:    i18n.T("login.username"),
 		PasswordLabel:    i18n.T("login.password"),
+		RememberLabel:    i18n.T("login.remember_me"),
 		ButtonText:       i18n.T("login.button"),
 		Footer:           i18n.T("login.footer"),
 		ErrorEmptyFields: i18n.T("login.error.empty_fields"),
@@ -1,116 +1,220 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
 // FormAuthenticator 实现了基于表单的认证
+// 登录成功后签发HMAC签名的会话令牌保存在Cookie中，见 SessionManager
 type FormAuthenticator struct {
 	username        string
 	password        string
 	enableLoginPage bool
+	secureCookie    bool
+	sessions        *SessionManager
+	users           *HtpasswdFile
 }
 
 // NewFormAuth 创建一个FormAuth认证器
+// 无法读取或保存会话签名密钥时使用临时密钥，重启后需要重新登录
 func NewFormAuth(config Config) *FormAuthenticator {
+	opts := SessionOptions{
+		MaxAge:         config.SessionMaxAge,
+		IdleTimeout:    config.SessionIdleTimeout,
+		RememberMaxAge: config.RememberMeMaxAge,
+		Dir:            config.SessionDir,
+	}
+	sessions, err := NewSessionManager(opts)
+	if err != nil {
+		logger.Warning(i18n.Tf("auth.session_key_error", err))
+		sessions = newEphemeralSessionManager(opts)
+	}
+
 	return &FormAuthenticator{
 		username:        config.Username,
 		password:        config.Password,
 		enableLoginPage: config.EnableLoginPage,
+		secureCookie:    config.SecureCookie,
+		sessions:        sessions,
+		users:           config.Users,
 	}
 }
 
 // Middleware 返回表单认证中间件
 func (a *FormAuthenticator) Middleware() gin.HandlerFunc {
+	next := authMiddleware(a)
 	return func(c *gin.Context) {
-		// 静态资源路径不需要认证
-		if strings.HasPrefix(c.Request.URL.Path, "/auth/") &&
-			(strings.HasSuffix(c.Request.URL.Path, ".css") ||
-				strings.HasSuffix(c.Request.URL.Path, ".js")) {
-			// 尝试从嵌入式文件系统中读取静态资源
-			filename := strings.TrimPrefix(c.Request.URL.Path, "/auth/")
-			content, err := GetFileContent(filename)
+		if a.serveAuthPages(c) {
+			return
+		}
+		next(c)
+	}
+}
+
+// serveAuthPages 处理登录页面、登录表单、注销和登录页面的静态资源，这些路径不需要认证
+//
+// 返回值:
+//   - bool: 请求已经处理时返回true
+func (a *FormAuthenticator) serveAuthPages(c *gin.Context) bool {
+	// 静态资源路径不需要认证
+	if strings.HasPrefix(c.Request.URL.Path, "/auth/") &&
+		(strings.HasSuffix(c.Request.URL.Path, ".css") ||
+			strings.HasSuffix(c.Request.URL.Path, ".js")) {
+		// 尝试从嵌入式文件系统中读取静态资源
+		filename := strings.TrimPrefix(c.Request.URL.Path, "/auth/")
+		content, err := GetFileContent(filename)
+		if err != nil {
+			c.String(http.StatusNotFound, "File not found")
+			c.Abort()
+			return true
+		}
+
+		// 设置正确的Content-Type
+		if strings.HasSuffix(filename, ".css") {
+			c.Header("Content-Type", "text/css")
+		} else if strings.HasSuffix(filename, ".js") {
+			c.Header("Content-Type", "application/javascript")
+		}
+
+		c.String(http.StatusOK, content)
+		c.Abort()
+		return true
+	}
+
+	// 处理登录页面请求
+	if c.Request.URL.Path == "/auth/login" {
+		if c.Request.Method == "GET" {
+			// 获取登录页面HTML
+			loginHTML, err := GetLoginHTMLContent()
 			if err != nil {
-				c.String(http.StatusNotFound, "File not found")
+				c.String(http.StatusInternalServerError, i18n.T("login.error.server"))
 				c.Abort()
-				return
+				return true
 			}
 
-			// 设置正确的Content-Type
-			if strings.HasSuffix(filename, ".css") {
-				c.Header("Content-Type", "text/css")
-			} else if strings.HasSuffix(filename, ".js") {
-				c.Header("Content-Type", "application/javascript")
-			}
-
-			c.String(http.StatusOK, content)
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			c.String(http.StatusOK, loginHTML)
 			c.Abort()
-			return
-		}
-
-		// 处理登录页面请求
-		if c.Request.URL.Path == "/auth/login" {
-			if c.Request.Method == "GET" {
-				// 获取登录页面HTML
-				loginHTML, err := GetLoginHTMLContent()
-				if err != nil {
-					c.String(http.StatusInternalServerError, i18n.T("login.error.server"))
-					c.Abort()
-					return
-				}
-
-				c.Header("Content-Type", "text/html; charset=utf-8")
-				c.String(http.StatusOK, loginHTML)
+			return true
+		} else if c.Request.Method == "POST" {
+			// 处理登录表单提交
+			username := c.PostForm("username")
+			password := c.PostForm("password")
+
+			if a.checkCredentials(username, password) {
+				// 签发新会话，勾选"记住我"时使用持久Cookie
+				cred, _ := a.credential(username)
+				token, session := a.sessions.Issue(username, cred, c.PostForm("remember") != "")
+				http.SetCookie(c.Writer, a.sessions.Cookie(token, session, a.secureCookie))
+
+				// 重定向到根目录
+				c.Redirect(http.StatusFound, "/")
+				c.Abort()
+				return true
+			} else {
+				// 验证失败，重定向到登录页面并显示错误
+				errorMsg := i18n.T("login.error.credentials")
+				c.Redirect(http.StatusFound, fmt.Sprintf("/auth/login?error=%s", errorMsg))
 				c.Abort()
-				return
-			} else if c.Request.Method == "POST" {
-				// 处理登录表单提交
-				username := c.PostForm("username")
-				password := c.PostForm("password")
-
-				if username == a.username && password == a.password {
-					// 设置cookie表示已登录
-					c.SetCookie("servergo_auth", "true", 3600, "/", "", false, true)
-
-					// 重定向到根目录
-					c.Redirect(http.StatusFound, "/")
-					c.Abort()
-					return
-				} else {
-					// 验证失败，重定向到登录页面并显示错误
-					errorMsg := i18n.T("login.error.credentials")
-					c.Redirect(http.StatusFound, fmt.Sprintf("/auth/login?error=%s", errorMsg))
-					c.Abort()
-					return
-				}
+				return true
 			}
 		}
+	}
 
-		// 处理登出请求
-		if c.Request.URL.Path == "/auth/logout" {
-			c.SetCookie("servergo_auth", "", -1, "/", "", false, true)
-			c.Redirect(http.StatusFound, "/auth/login")
-			c.Abort()
-			return
+	// 处理登出请求，在服务端注销会话，之前复制的Cookie也不能再使用
+	if c.Request.URL.Path == "/auth/logout" {
+		if session, ok := a.session(c); ok {
+			if err := a.sessions.Revoke(session); err != nil {
+				logger.Warning(i18n.Tf("auth.session_revoke_error", err))
+			}
 		}
+		a.clearCookie(c)
+		c.Redirect(http.StatusFound, "/auth/login")
+		c.Abort()
+		return true
+	}
 
-		// 检查Cookie认证状态
-		auth, _ := c.Cookie("servergo_auth")
-		if auth != "true" {
-			// 未认证，重定向到登录页面
-			c.Redirect(http.StatusFound, "/auth/login")
-			c.Abort()
-			return
+	return false
+}
+
+// Authenticate 检查会话Cookie，通过时更新最后访问时间，使空闲超时从这次访问开始计算
+func (a *FormAuthenticator) Authenticate(c *gin.Context) (string, bool) {
+	session, ok := a.session(c)
+	if !ok {
+		return "", false
+	}
+	if token, refreshed := a.sessions.Refresh(session); refreshed {
+		http.SetCookie(c.Writer, a.sessions.Cookie(token, session, a.secureCookie))
+	}
+	return session.User, true
+}
+
+// Challenge 未认证时重定向到登录页面
+func (a *FormAuthenticator) Challenge(c *gin.Context) {
+	c.Redirect(http.StatusFound, "/auth/login")
+	c.Abort()
+}
+
+// checkCredentials 检查用户名和密码
+func (a *FormAuthenticator) checkCredentials(username, password string) bool {
+	return checkCredentials(a.users, a.username, a.password, username, password)
+}
+
+// credential 返回用户当前凭据的指纹，用户不存在时返回false
+// 使用htpasswd文件时指纹包含用户当前的密码哈希，执行 servergo user passwd 后该用户已登录的会话失效；
+// 单用户模式下只有配置的用户名存在，指纹包含配置的密码
+func (a *FormAuthenticator) credential(user string) (string, bool) {
+	if a.users != nil {
+		hash, ok := a.users.passwordHash(user)
+		if !ok {
+			return "", false
 		}
+		return a.sessions.Fingerprint(user, hash), true
+	}
+	if subtle.ConstantTimeCompare([]byte(user), []byte(a.username)) != 1 {
+		return "", false
+	}
+	return a.sessions.Fingerprint(user, a.password), true
+}
 
-		// 认证通过，继续请求
-		c.Next()
+// session 读取并验证请求中的会话Cookie，Cookie无效（过期、被篡改或已注销）、
+// 用户已经从htpasswd文件中删除或修改了密码，或者会话不是用当前的用户名和密码登录的时清除Cookie
+func (a *FormAuthenticator) session(c *gin.Context) (*Session, bool) {
+	token, err := c.Cookie(SessionCookieName)
+	if err != nil || token == "" {
+		return nil, false
 	}
+	session, err := a.sessions.Verify(token)
+	if err == nil {
+		cred, ok := a.credential(session.User)
+		if !ok || subtle.ConstantTimeCompare([]byte(cred), []byte(session.Cred)) != 1 {
+			err = ErrSessionInvalid
+		}
+	}
+	if err != nil {
+		a.clearCookie(c)
+		return nil, false
+	}
+	return session, true
+}
+
+// clearCookie 删除会话Cookie
+func (a *FormAuthenticator) clearCookie(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     SessionCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		Secure:   a.secureCookie,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
 }
 
 // SetupRoutes 设置表单认证的路由
@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newFormRouter 创建使用表单认证的测试路由
+func newFormRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	a := NewFormAuth(Config{Username: "admin", Password: "secret", SessionDir: t.TempDir()})
+
+	router := gin.New()
+	router.Use(a.Middleware())
+	router.GET("/file.txt", func(c *gin.Context) {
+		c.String(http.StatusOK, "content")
+	})
+	return router
+}
+
+// serve 发送请求，cookies 不为空时带上这些Cookie
+func serve(router *gin.Engine, req *http.Request, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// login 提交登录表单，返回响应中的会话Cookie
+func login(t *testing.T, router *gin.Engine, password string, remember bool) (*httptest.ResponseRecorder, *http.Cookie) {
+	form := url.Values{"username": {"admin"}, "password": {password}}
+	if remember {
+		form.Set("remember", "1")
+	}
+	req, _ := http.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := serve(router, req)
+
+	for _, cookie := range w.Result().Cookies() {
+		if cookie.Name == SessionCookieName && cookie.Value != "" {
+			return w, cookie
+		}
+	}
+	return w, nil
+}
+
+// TestFormAuthSession 测试表单认证的登录、访问和注销
+func TestFormAuthSession(t *testing.T) {
+	router := newFormRouter(t)
+
+	// 手动伪造的旧Cookie不能通过认证
+	req, _ := http.NewRequest(http.MethodGet, "/file.txt", nil)
+	w := serve(router, req, &http.Cookie{Name: "servergo_auth", Value: "true"}, &http.Cookie{Name: SessionCookieName, Value: "true"})
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/auth/login" {
+		t.Fatalf("伪造的Cookie 状态码 = %d, 期望重定向到登录页面", w.Code)
+	}
+
+	// 密码错误时不签发会话
+	if _, cookie := login(t, router, "wrong", false); cookie != nil {
+		t.Fatalf("密码错误时不应该设置会话Cookie")
+	}
+
+	w, cookie := login(t, router, "secret", false)
+	if cookie == nil {
+		t.Fatalf("登录后没有设置会话Cookie, 响应头: %v", w.Header())
+	}
+	if !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode || cookie.MaxAge != 0 {
+		t.Errorf("会话Cookie属性 = %+v", cookie)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "/file.txt", nil)
+	if w := serve(router, req, cookie); w.Code != http.StatusOK {
+		t.Fatalf("登录后 状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+
+	// 注销后，之前的Cookie也不能再使用
+	req, _ = http.NewRequest(http.MethodGet, "/auth/logout", nil)
+	serve(router, req, cookie)
+	req, _ = http.NewRequest(http.MethodGet, "/file.txt", nil)
+	if w := serve(router, req, cookie); w.Code != http.StatusFound {
+		t.Errorf("注销后 状态码 = %d, 期望 %d", w.Code, http.StatusFound)
+	}
+}
+
+// TestFormAuthRememberMe 测试勾选"记住我"时使用持久Cookie
+func TestFormAuthRememberMe(t *testing.T) {
+	router := newFormRouter(t)
+
+	_, cookie := login(t, router, "secret", true)
+	if cookie == nil {
+		t.Fatalf("登录后没有设置会话Cookie")
+	}
+	if cookie.MaxAge <= 0 {
+		t.Errorf("记住我的Cookie MaxAge = %d, 期望大于0", cookie.MaxAge)
+	}
+}
+
+// TestFormAuthCredentialBinding 测试会话只在签发它的用户名和密码下有效
+// 同一台机器上的实例共用签名密钥，其他实例或修改密码后都不能使用之前的会话
+func TestFormAuthCredentialBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := t.TempDir()
+	newRouter := func(username, password string) *gin.Engine {
+		router := gin.New()
+		router.Use(NewFormAuth(Config{Username: username, Password: password, SessionDir: dir}).Middleware())
+		router.GET("/file.txt", func(c *gin.Context) {
+			c.String(http.StatusOK, "content")
+		})
+		return router
+	}
+
+	_, cookie := login(t, newRouter("admin", "secret"), "secret", false)
+	if cookie == nil {
+		t.Fatalf("登录后没有设置会话Cookie")
+	}
+
+	tests := []struct {
+		name         string
+		router       *gin.Engine
+		expectedCode int
+	}{
+		{name: "相同的用户名和密码（例如重启后）", router: newRouter("admin", "secret"), expectedCode: http.StatusOK},
+		{name: "修改了密码", router: newRouter("admin", "changed"), expectedCode: http.StatusFound},
+		{name: "修改了用户名", router: newRouter("root", "secret"), expectedCode: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/file.txt", nil)
+			if w := serve(tt.router, req, cookie); w.Code != tt.expectedCode {
+				t.Errorf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+		})
+	}
+}
+
+// TestFormAuthHtpasswd 测试使用htpasswd用户登录，修改密码或删除用户后已登录的会话失效
+func TestFormAuthHtpasswd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	users, _ := OpenHtpasswd(path)
+	users.Set("admin", "from-file")
+	users.Save()
+
+	gin.SetMode(gin.TestMode)
+	a := NewFormAuth(Config{Username: "admin", Password: "secret", SessionDir: t.TempDir(), Users: users})
+	router := gin.New()
+	router.Use(a.Middleware())
+	router.GET("/file.txt", func(c *gin.Context) {
+		c.String(http.StatusOK, "content")
+	})
+
+	if _, cookie := login(t, router, "secret", false); cookie != nil {
+		t.Fatalf("配置了htpasswd文件时不应该使用 --password 登录")
+	}
+	_, cookie := login(t, router, "from-file", false)
+	if cookie == nil {
+		t.Fatalf("htpasswd用户登录后没有设置会话Cookie")
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/file.txt", nil)
+	if w := serve(router, req, cookie); w.Code != http.StatusOK {
+		t.Fatalf("登录后 状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+
+	users.Set("admin", "changed")
+	req, _ = http.NewRequest(http.MethodGet, "/file.txt", nil)
+	if w := serve(router, req, cookie); w.Code != http.StatusFound {
+		t.Errorf("修改密码后 状态码 = %d, 期望 %d", w.Code, http.StatusFound)
+	}
+
+	_, cookie = login(t, router, "changed", false)
+	if cookie == nil {
+		t.Fatalf("使用新密码登录后没有设置会话Cookie")
+	}
+	users.Remove("admin")
+	req, _ = http.NewRequest(http.MethodGet, "/file.txt", nil)
+	if w := serve(router, req, cookie); w.Code != http.StatusFound {
+		t.Errorf("用户被删除后 状态码 = %d, 期望 %d", w.Code, http.StatusFound)
+	}
+}
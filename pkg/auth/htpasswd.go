@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/CC11001100/servergo/pkg/i18n"
+)
+
+// htpasswdReloadInterval 检查htpasswd文件是否被修改的最小间隔，修改后无需重启即可生效
+const htpasswdReloadInterval = time.Second
+
+// shaPrefix htpasswd中SHA-1密码的前缀（htpasswd -s）
+const shaPrefix = "{SHA}"
+
+// htpasswdLine htpasswd文件中的一行，注释和空行只保存原文
+type htpasswdLine struct {
+	user string // 用户名，为空表示注释或空行
+	hash string // 密码的哈希，例如: "$2y$10$..." 或 "{SHA}..."
+	raw  string // 注释或空行的原文
+}
+
+// HtpasswdFile 兼容Apache htpasswd格式的用户文件，每行一个 "用户名:密码哈希"
+// 支持bcrypt（$2a$、$2b$、$2y$）和SHA-1（{SHA}）格式，新设置的密码总是使用bcrypt；
+// 文件被修改后（例如执行 servergo user add）在下次验证时自动重新读取
+type HtpasswdFile struct {
+	path string
+
+	mu        sync.RWMutex
+	lines     []htpasswdLine
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+// LoadHtpasswd 读取htpasswd文件
+//
+// 参数:
+//   - path: 文件路径，例如: "/etc/servergo/htpasswd"
+//
+// 返回值:
+//   - *HtpasswdFile: 用户文件
+//   - error: 文件不存在、无法读取或格式错误时返回错误
+func LoadHtpasswd(path string) (*HtpasswdFile, error) {
+	f := &HtpasswdFile{path: path}
+	if err := f.reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// OpenHtpasswd 打开htpasswd文件用于编辑，文件不存在时返回空的用户文件，保存时创建
+func OpenHtpasswd(path string) (*HtpasswdFile, error) {
+	f, err := LoadHtpasswd(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &HtpasswdFile{path: path}, nil
+	}
+	return f, err
+}
+
+// Path 返回文件路径
+func (f *HtpasswdFile) Path() string {
+	return f.path
+}
+
+// reload 重新读取文件
+func (f *HtpasswdFile) reload() error {
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	lines, err := parseHtpasswd(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lines = lines
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+	f.checkedAt = time.Now()
+	return nil
+}
+
+// reloadIfChanged 文件的修改时间或大小变化时重新读取，读取失败时继续使用之前的内容
+func (f *HtpasswdFile) reloadIfChanged() {
+	f.mu.RLock()
+	recent := time.Since(f.checkedAt) < htpasswdReloadInterval
+	f.mu.RUnlock()
+	if recent {
+		return
+	}
+
+	f.mu.Lock()
+	f.checkedAt = time.Now()
+	modTime, size := f.modTime, f.size
+	f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil || info.ModTime().Equal(modTime) && info.Size() == size {
+		return
+	}
+	f.reload()
+}
+
+// parseHtpasswd 解析htpasswd文件的内容，以 # 开头的行是注释
+func parseHtpasswd(data []byte) ([]htpasswdLine, error) {
+	var lines []htpasswdLine
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			lines = append(lines, htpasswdLine{raw: text})
+			continue
+		}
+		user, hash, ok := strings.Cut(trimmed, ":")
+		if !ok || user == "" || hash == "" {
+			return nil, fmt.Errorf(i18n.Tf("error.htpasswd_line_invalid", n))
+		}
+		lines = append(lines, htpasswdLine{user: user, hash: hash})
+	}
+	return lines, scanner.Err()
+}
+
+// find 返回用户所在的行，不存在时返回-1，调用时需要持有锁
+func (f *HtpasswdFile) find(user string) int {
+	for i, line := range f.lines {
+		if line.user != "" && line.user == user {
+			return i
+		}
+	}
+	return -1
+}
+
+// Verify 检查用户名和密码
+//
+// 返回值:
+//   - bool: 用户存在且密码正确时返回true，不支持的哈希格式（例如 $apr1$）总是返回false
+func (f *HtpasswdFile) Verify(user, password string) bool {
+	f.reloadIfChanged()
+
+	f.mu.RLock()
+	i := f.find(user)
+	var hash string
+	if i >= 0 {
+		hash = f.lines[i].hash
+	}
+	f.mu.RUnlock()
+
+	if i < 0 {
+		// 用户不存在时也计算一次哈希，避免通过响应时间判断用户名是否存在
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return false
+	}
+	return checkHash(hash, password)
+}
+
+// dummyHash 用户不存在时用于比较的bcrypt哈希，第一次使用时才计算，不拖慢命令行和测试的启动
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("servergo"), bcrypt.DefaultCost)
+	return hash
+})
+
+// checkHash 按哈希的格式检查密码
+func checkHash(hash, password string) bool {
+	switch {
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	case strings.HasPrefix(hash, shaPrefix):
+		sum := sha1.Sum([]byte(password))
+		expected := shaPrefix + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1
+	default:
+		return false
+	}
+}
+
+// Has 判断用户是否存在
+func (f *HtpasswdFile) Has(user string) bool {
+	f.reloadIfChanged()
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.find(user) >= 0
+}
+
+// passwordHash 返回用户当前的密码哈希，用于计算会话的凭据指纹，修改密码后指纹随之变化
+func (f *HtpasswdFile) passwordHash(user string) (string, bool) {
+	f.reloadIfChanged()
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if i := f.find(user); i >= 0 {
+		return f.lines[i].hash, true
+	}
+	return "", false
+}
+
+// Users 按文件中的顺序返回所有用户名
+func (f *HtpasswdFile) Users() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	users := make([]string, 0, len(f.lines))
+	for _, line := range f.lines {
+		if line.user != "" {
+			users = append(users, line.user)
+		}
+	}
+	return users
+}
+
+// Set 设置用户的密码，用户不存在时添加到文件末尾，密码使用bcrypt哈希
+//
+// 返回值:
+//   - error: 用户名包含冒号、空白字符或为空时返回错误
+func (f *HtpasswdFile) Set(user, password string) error {
+	if user == "" || strings.ContainsAny(user, ": \t\r\n") || strings.HasPrefix(user, "#") {
+		return fmt.Errorf(i18n.Tf("error.htpasswd_user_invalid", user))
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := f.find(user); i >= 0 {
+		f.lines[i].hash = string(hash)
+	} else {
+		f.lines = append(f.lines, htpasswdLine{user: user, hash: string(hash)})
+	}
+	return nil
+}
+
+// Remove 删除用户
+//
+// 返回值:
+//   - bool: 用户存在并被删除时返回true
+func (f *HtpasswdFile) Remove(user string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	i := f.find(user)
+	if i < 0 {
+		return false
+	}
+	f.lines = append(f.lines[:i], f.lines[i+1:]...)
+	return true
+}
+
+// Save 保存文件，注释和空行保持不变；先写入临时文件再重命名，运行中的服务器不会读到写了一半的文件
+func (f *HtpasswdFile) Save() error {
+	f.mu.RLock()
+	var buf bytes.Buffer
+	for _, line := range f.lines {
+		if line.user == "" {
+			buf.WriteString(line.raw)
+		} else {
+			buf.WriteString(line.user + ":" + line.hash)
+		}
+		buf.WriteByte('\n')
+	}
+	f.mu.RUnlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
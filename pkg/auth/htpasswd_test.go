@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// writeHtpasswd 在临时目录中创建htpasswd文件
+func writeHtpasswd(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("创建htpasswd文件失败: %v", err)
+	}
+	return path
+}
+
+// TestHtpasswdVerify 测试验证不同格式的密码哈希
+func TestHtpasswdVerify(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("alice-pass"), bcrypt.MinCost)
+	path := writeHtpasswd(t, "# 注释\n\nalice:"+string(hash)+"\nbob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\ncarol:$apr1$abc$def\n")
+
+	users, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("LoadHtpasswd() 错误: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		expected bool
+	}{
+		{name: "bcrypt", user: "alice", password: "alice-pass", expected: true},
+		{name: "bcrypt密码错误", user: "alice", password: "wrong"},
+		{name: "SHA", user: "bob", password: "password", expected: true},
+		{name: "SHA密码错误", user: "bob", password: "alice-pass"},
+		{name: "不支持的格式", user: "carol", password: "anything"},
+		{name: "用户不存在", user: "dave", password: "alice-pass"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := users.Verify(tt.user, tt.password); got != tt.expected {
+				t.Errorf("Verify(%q, %q) = %v, 期望 %v", tt.user, tt.password, got, tt.expected)
+			}
+		})
+	}
+
+	if got := strings.Join(users.Users(), ","); got != "alice,bob,carol" {
+		t.Errorf("Users() = %q, 期望 %q", got, "alice,bob,carol")
+	}
+}
+
+// TestHtpasswdInvalid 测试格式错误的文件
+func TestHtpasswdInvalid(t *testing.T) {
+	if _, err := LoadHtpasswd(writeHtpasswd(t, "alice\n")); err == nil {
+		t.Errorf("缺少密码哈希时期望返回错误")
+	}
+	if _, err := LoadHtpasswd(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("文件不存在时期望返回错误")
+	}
+}
+
+// TestHtpasswdEdit 测试添加、修改和删除用户，保存后注释保持不变
+func TestHtpasswdEdit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf", "htpasswd")
+	users, err := OpenHtpasswd(path)
+	if err != nil {
+		t.Fatalf("OpenHtpasswd() 错误: %v", err)
+	}
+
+	for _, name := range []string{"", "a:b", "a b", "#a"} {
+		if err := users.Set(name, "x"); err == nil {
+			t.Errorf("Set(%q) 期望返回错误", name)
+		}
+	}
+
+	users.Set("alice", "one")
+	users.Set("bob", "two")
+	users.Set("alice", "three")
+	if err := users.Save(); err != nil {
+		t.Fatalf("Save() 错误: %v", err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("文件权限 = %o, 期望 600", info.Mode().Perm())
+	}
+
+	// 在文件开头加上注释，重新打开后删除用户
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, append([]byte("# servergo users\n"), data...), 0600)
+	users, err = OpenHtpasswd(path)
+	if err != nil {
+		t.Fatalf("OpenHtpasswd() 错误: %v", err)
+	}
+	if !users.Verify("alice", "three") || users.Verify("alice", "one") {
+		t.Errorf("修改密码后应该只有新密码有效")
+	}
+	if !users.Remove("bob") || users.Remove("bob") {
+		t.Errorf("Remove() 应该只在用户存在时返回true")
+	}
+	users.Save()
+
+	data, _ = os.ReadFile(path)
+	if !strings.HasPrefix(string(data), "# servergo users\nalice:$2") || strings.Contains(string(data), "bob:") {
+		t.Errorf("保存后的文件内容 = %q", data)
+	}
+}
+
+// TestHtpasswdReload 测试文件被修改后自动重新读取
+func TestHtpasswdReload(t *testing.T) {
+	path := writeHtpasswd(t, "bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n")
+	users, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("LoadHtpasswd() 错误: %v", err)
+	}
+
+	editor, _ := OpenHtpasswd(path)
+	editor.Remove("bob")
+	editor.Set("alice", "secret")
+	editor.Save()
+
+	// 模拟距离上次检查已经超过间隔
+	users.mu.Lock()
+	users.checkedAt = time.Time{}
+	users.mu.Unlock()
+
+	if users.Has("bob") || !users.Verify("alice", "secret") {
+		t.Errorf("文件修改后应该使用新的用户")
+	}
+}
+
+// TestBasicAuthHtpasswd 测试Basic认证验证htpasswd文件中的多个用户
+func TestBasicAuthHtpasswd(t *testing.T) {
+	users, err := LoadHtpasswd(writeHtpasswd(t, "bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"))
+	if err != nil {
+		t.Fatalf("LoadHtpasswd() 错误: %v", err)
+	}
+	users.Set("alice", "alice-pass")
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewBasicAuth(Config{Username: "admin", Password: "secret", Users: users}).Middleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(gin.AuthUserKey))
+	})
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		expected int
+	}{
+		{name: "bcrypt用户", user: "alice", password: "alice-pass", expected: http.StatusOK},
+		{name: "SHA用户", user: "bob", password: "password", expected: http.StatusOK},
+		{name: "密码错误", user: "alice", password: "password", expected: http.StatusUnauthorized},
+		{name: "不再使用单个用户", user: "admin", password: "secret", expected: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.SetBasicAuth(tt.user, tt.password)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expected {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expected)
+			}
+			if w.Code == http.StatusOK && w.Body.String() != tt.user {
+				t.Errorf("认证的用户 = %q, 期望 %q", w.Body.String(), tt.user)
+			}
+			if w.Code == http.StatusUnauthorized && !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic realm=") {
+				t.Errorf("WWW-Authenticate = %q", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
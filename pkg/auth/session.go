@@ -0,0 +1,328 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CC11001100/servergo/pkg/config"
+)
+
+// SessionCookieName 保存会话令牌的Cookie名称
+const SessionCookieName = "servergo_session"
+
+// 会话的默认有效期
+const (
+	// DefaultSessionMaxAge 没有勾选"记住我"时会话的最长有效期，同时Cookie在浏览器关闭后失效
+	DefaultSessionMaxAge = 12 * time.Hour
+	// DefaultSessionIdleTimeout 没有勾选"记住我"时，超过该时间没有访问会话就会失效
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	// DefaultRememberMeMaxAge 勾选"记住我"时会话的有效期，不受空闲超时限制
+	DefaultRememberMeMaxAge = 30 * 24 * time.Hour
+)
+
+// sessionRefreshInterval 距离上次访问超过该时间才重新签发Cookie更新访问时间，避免每个请求都写Cookie
+const sessionRefreshInterval = time.Minute
+
+// 会话签名密钥和已注销会话列表的文件名，保存在 ~/.servergo/ 下
+const (
+	sessionKeyFile     = "session.key"
+	revokedSessionFile = "revoked-sessions.json"
+)
+
+// 会话验证失败的原因
+var (
+	ErrSessionInvalid = errors.New("session: invalid token")
+	ErrSessionExpired = errors.New("session: expired")
+	ErrSessionRevoked = errors.New("session: revoked")
+)
+
+// Session 已登录的会话，序列化后签名保存在Cookie中
+type Session struct {
+	ID        string `json:"sid"`           // 会话ID，注销时按ID使会话失效
+	User      string `json:"sub"`           // 用户名
+	IssuedAt  int64  `json:"iat"`           // 登录时间，Unix秒
+	ExpiresAt int64  `json:"exp"`           // 过期时间，Unix秒
+	LastSeen  int64  `json:"seen"`          // 最后一次访问的时间，Unix秒，用于空闲超时
+	Remember  bool   `json:"rem,omitempty"` // 是否勾选了"记住我"
+	Cred      string `json:"cred"`          // 登录时凭据的指纹，密码修改后会话失效，见 SessionManager.Fingerprint
+}
+
+// SessionOptions 会话管理器的配置，时长为0时使用默认值
+type SessionOptions struct {
+	MaxAge         time.Duration // 会话的最长有效期，例如: 12 * time.Hour
+	IdleTimeout    time.Duration // 空闲超时，例如: 30 * time.Minute
+	RememberMaxAge time.Duration // 勾选"记住我"时的有效期，例如: 30 * 24 * time.Hour
+	Dir            string        // 保存签名密钥和已注销会话的目录，为空时使用 ~/.servergo
+}
+
+// SessionManager 签发和验证HMAC签名的会话令牌
+// 令牌中包含过期时间和最后访问时间，签名密钥保存在文件中，重启后已登录的会话仍然有效；
+// 注销的会话ID记录在服务端直到过期，复制的Cookie在注销后也不能再使用
+type SessionManager struct {
+	opts SessionOptions
+	key  []byte
+	now  func() time.Time
+
+	mu          sync.Mutex
+	revoked     map[string]int64 // 已注销的会话ID -> 会话的过期时间
+	revokedPath string
+}
+
+// NewSessionManager 创建会话管理器，读取或生成签名密钥，并读取已注销的会话
+//
+// 参数:
+//   - opts: 会话配置
+//
+// 返回值:
+//   - *SessionManager: 会话管理器
+//   - error: 无法读取或保存签名密钥时返回错误
+func NewSessionManager(opts SessionOptions) (*SessionManager, error) {
+	opts = opts.withDefaults()
+	if opts.Dir == "" {
+		dir, err := config.GetConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Dir = dir
+	}
+
+	key, err := loadOrCreateKey(filepath.Join(opts.Dir, sessionKeyFile))
+	if err != nil {
+		return nil, err
+	}
+
+	m := &SessionManager{
+		opts:        opts,
+		key:         key,
+		now:         time.Now,
+		revoked:     map[string]int64{},
+		revokedPath: filepath.Join(opts.Dir, revokedSessionFile),
+	}
+	if data, err := os.ReadFile(m.revokedPath); err == nil {
+		json.Unmarshal(data, &m.revoked)
+	}
+	return m, nil
+}
+
+// newEphemeralSessionManager 创建使用随机密钥、不保存任何文件的会话管理器，重启后所有会话失效
+func newEphemeralSessionManager(opts SessionOptions) *SessionManager {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &SessionManager{opts: opts.withDefaults(), key: key, now: time.Now, revoked: map[string]int64{}}
+}
+
+// withDefaults 为没有设置的时长使用默认值
+func (opts SessionOptions) withDefaults() SessionOptions {
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = DefaultSessionMaxAge
+	}
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultSessionIdleTimeout
+	}
+	if opts.RememberMaxAge <= 0 {
+		opts.RememberMaxAge = DefaultRememberMeMaxAge
+	}
+	return opts
+}
+
+// loadOrCreateKey 读取签名密钥，文件不存在时生成32字节的随机密钥并保存，只有当前用户可以读取
+func loadOrCreateKey(path string) ([]byte, error) {
+	if data, err := os.ReadFile(path); err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err == nil && len(key) >= 32 {
+			return key, nil
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(hex.EncodeToString(key)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Issue 为登录成功的用户签发新会话
+//
+// 参数:
+//   - user: 用户名
+//   - cred: 用户当前凭据的指纹，见 Fingerprint
+//   - remember: 是否勾选了"记住我"
+//
+// 返回值:
+//   - string: 签名的会话令牌，保存在Cookie中
+//   - *Session: 新会话
+func (m *SessionManager) Issue(user, cred string, remember bool) (string, *Session) {
+	id := make([]byte, 16)
+	rand.Read(id)
+
+	now := m.now()
+	maxAge := m.opts.MaxAge
+	if remember {
+		maxAge = m.opts.RememberMaxAge
+	}
+	s := &Session{
+		ID:        hex.EncodeToString(id),
+		User:      user,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(maxAge).Unix(),
+		LastSeen:  now.Unix(),
+		Remember:  remember,
+		Cred:      cred,
+	}
+	return m.sign(s), s
+}
+
+// Fingerprint 计算用户凭据的指纹，保存在会话中，验证会话时与当前凭据的指纹比较
+// 签名密钥由同一台机器上的所有实例共用，指纹把会话绑定到签发它的用户名和密码，
+// 其他用户名或密码的实例、修改密码后的实例都不接受该会话；
+// 指纹使用签名密钥计算HMAC，Cookie中的指纹不能用来离线猜测密码
+//
+// 参数:
+//   - user: 用户名
+//   - secret: 密码，或者htpasswd文件中该用户的密码哈希
+func (m *SessionManager) Fingerprint(user, secret string) string {
+	sum := m.mac("cred\x00" + user + "\x00" + secret)
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// Verify 验证会话令牌的签名、有效期和空闲时间
+//
+// 返回值:
+//   - *Session: 有效的会话
+//   - error: 签名错误、已过期、空闲超时或已注销时返回错误
+func (m *SessionManager) Verify(token string) (*Session, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrSessionInvalid
+	}
+	expected := m.mac(payload)
+	actual, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(actual, expected) {
+		return nil, ErrSessionInvalid
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrSessionInvalid
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil || s.ID == "" {
+		return nil, ErrSessionInvalid
+	}
+
+	now := m.now()
+	if now.Unix() >= s.ExpiresAt {
+		return nil, ErrSessionExpired
+	}
+	if !s.Remember && now.Sub(time.Unix(s.LastSeen, 0)) > m.opts.IdleTimeout {
+		return nil, ErrSessionExpired
+	}
+
+	m.mu.Lock()
+	_, revoked := m.revoked[s.ID]
+	m.mu.Unlock()
+	if revoked {
+		return nil, ErrSessionRevoked
+	}
+	return &s, nil
+}
+
+// Refresh 更新会话的最后访问时间，距离上次更新不到 sessionRefreshInterval 时不需要更新
+//
+// 返回值:
+//   - string: 重新签名的令牌
+//   - bool: 需要更新Cookie时返回true
+func (m *SessionManager) Refresh(s *Session) (string, bool) {
+	now := m.now()
+	if now.Sub(time.Unix(s.LastSeen, 0)) < sessionRefreshInterval {
+		return "", false
+	}
+	s.LastSeen = now.Unix()
+	return m.sign(s), true
+}
+
+// Revoke 注销会话，注销记录保存到文件中，重启后仍然有效，会话过期后自动清理
+//
+// 返回值:
+//   - error: 保存注销记录失败时返回错误，会话在本次运行期间仍然已经失效
+func (m *SessionManager) Revoke(s *Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now().Unix()
+	for id, exp := range m.revoked {
+		if exp <= now {
+			delete(m.revoked, id)
+		}
+	}
+	m.revoked[s.ID] = s.ExpiresAt
+
+	if m.revokedPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(m.revoked)
+	if err != nil {
+		return err
+	}
+	tmp := m.revokedPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.revokedPath)
+}
+
+// Cookie 创建保存会话令牌的Cookie
+// 勾选"记住我"时Cookie在会话过期时失效，否则浏览器关闭后失效；总是设置 HttpOnly 和 SameSite=Lax
+//
+// 参数:
+//   - token: 会话令牌
+//   - s: 会话
+//   - secure: 是否设置 Secure 属性，启用HTTPS时应为true
+func (m *SessionManager) Cookie(token string, s *Session, secure bool) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	if s.Remember {
+		cookie.Expires = time.Unix(s.ExpiresAt, 0)
+		cookie.MaxAge = int(s.ExpiresAt - m.now().Unix())
+	}
+	return cookie
+}
+
+// sign 序列化会话并签名，格式为 "base64(JSON).base64(HMAC-SHA256)"
+func (m *SessionManager) sign(s *Session) string {
+	data, _ := json.Marshal(s)
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(m.mac(payload))
+}
+
+// mac 计算签名
+func (m *SessionManager) mac(payload string) []byte {
+	h := hmac.New(sha256.New, m.key)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
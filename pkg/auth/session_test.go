@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestSessionManager 创建使用临时目录和可控时间的会话管理器
+func newTestSessionManager(t *testing.T, dir string, now *time.Time) *SessionManager {
+	m, err := NewSessionManager(SessionOptions{
+		MaxAge:         time.Hour,
+		IdleTimeout:    10 * time.Minute,
+		RememberMaxAge: 24 * time.Hour,
+		Dir:            dir,
+	})
+	if err != nil {
+		t.Fatalf("创建会话管理器失败: %v", err)
+	}
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+// TestSessionVerify 测试会话令牌的签名、有效期和空闲超时
+func TestSessionVerify(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		remember  bool
+		elapsed   time.Duration
+		tamper    func(token string) string
+		expectErr error
+	}{
+		{name: "有效的会话", elapsed: time.Minute},
+		{name: "空闲超时", elapsed: 11 * time.Minute, expectErr: ErrSessionExpired},
+		{name: "记住我不受空闲超时限制", remember: true, elapsed: 2 * time.Hour},
+		{name: "超过最长有效期", remember: true, elapsed: 25 * time.Hour, expectErr: ErrSessionExpired},
+		{
+			name: "篡改用户名",
+			tamper: func(token string) string {
+				return "eyJzaWQiOiJ4Iiwic3ViIjoicm9vdCJ9" + token[strings.Index(token, "."):]
+			},
+			expectErr: ErrSessionInvalid,
+		},
+		{name: "伪造的旧Cookie", tamper: func(string) string { return "true" }, expectErr: ErrSessionInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start
+			m := newTestSessionManager(t, t.TempDir(), &now)
+
+			token, _ := m.Issue("admin", "", tt.remember)
+			if tt.tamper != nil {
+				token = tt.tamper(token)
+			}
+			now = start.Add(tt.elapsed)
+
+			s, err := m.Verify(token)
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Fatalf("Verify() 错误 = %v, 期望 %v", err, tt.expectErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() 错误: %v", err)
+			}
+			if s.User != "admin" {
+				t.Errorf("用户名 = %q, 期望 %q", s.User, "admin")
+			}
+		})
+	}
+}
+
+// TestSessionRefresh 测试访问后空闲超时从最后一次访问开始计算
+func TestSessionRefresh(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := newTestSessionManager(t, t.TempDir(), &now)
+
+	token, s := m.Issue("admin", "", false)
+	if _, refreshed := m.Refresh(s); refreshed {
+		t.Errorf("刚登录时不需要刷新")
+	}
+
+	now = now.Add(8 * time.Minute)
+	s, err := m.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify() 错误: %v", err)
+	}
+	token, refreshed := m.Refresh(s)
+	if !refreshed {
+		t.Fatalf("超过刷新间隔后应该刷新")
+	}
+
+	// 距离登录已经16分钟，但距离上次访问只有8分钟
+	now = now.Add(8 * time.Minute)
+	if _, err := m.Verify(token); err != nil {
+		t.Errorf("刷新后的会话应该有效: %v", err)
+	}
+}
+
+// TestSessionPersistence 测试重启后签名密钥和注销记录仍然有效
+func TestSessionPersistence(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	first := newTestSessionManager(t, dir, &now)
+	kept, _ := first.Issue("admin", "", false)
+	revoked, s := first.Issue("admin", "", false)
+	if err := first.Revoke(s); err != nil {
+		t.Fatalf("Revoke() 错误: %v", err)
+	}
+	if _, err := first.Verify(revoked); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("注销后 Verify() 错误 = %v, 期望 %v", err, ErrSessionRevoked)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, sessionKeyFile))
+	if err != nil {
+		t.Fatalf("签名密钥没有保存: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("签名密钥的权限 = %o, 期望 600", perm)
+	}
+
+	// 模拟重启
+	second := newTestSessionManager(t, dir, &now)
+	if _, err := second.Verify(kept); err != nil {
+		t.Errorf("重启后已登录的会话应该有效: %v", err)
+	}
+	if _, err := second.Verify(revoked); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("重启后注销的会话 Verify() 错误 = %v, 期望 %v", err, ErrSessionRevoked)
+	}
+
+	// 使用其他密钥签名的令牌无效
+	other := newTestSessionManager(t, t.TempDir(), &now)
+	if _, err := other.Verify(kept); !errors.Is(err, ErrSessionInvalid) {
+		t.Errorf("其他密钥 Verify() 错误 = %v, 期望 %v", err, ErrSessionInvalid)
+	}
+}
+
+// TestSessionCookie 测试会话Cookie的属性
+func TestSessionCookie(t *testing.T) {
+	now := time.Now()
+	m := newTestSessionManager(t, t.TempDir(), &now)
+
+	token, s := m.Issue("admin", "", false)
+	cookie := m.Cookie(token, s, true)
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("Cookie属性 = %+v", cookie)
+	}
+	if cookie.MaxAge != 0 {
+		t.Errorf("没有勾选记住我时应该是会话Cookie, MaxAge = %d", cookie.MaxAge)
+	}
+
+	token, s = m.Issue("admin", "", true)
+	if cookie := m.Cookie(token, s, false); cookie.MaxAge != int((24*time.Hour).Seconds()) || cookie.Secure {
+		t.Errorf("记住我的Cookie = %+v", cookie)
+	}
+}
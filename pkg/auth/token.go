@@ -1,43 +1,78 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 // TokenAuthenticator 实现了基于令牌的认证
+// 除了启动时指定的令牌，还接受令牌文件中未过期的托管令牌
 type TokenAuthenticator struct {
-	token string
+	token  string
+	tokens *TokenStore
 }
 
 // NewTokenAuth 创建一个TokenAuth认证器
 func NewTokenAuth(config Config) *TokenAuthenticator {
 	return &TokenAuthenticator{
-		token: config.Token,
+		token:  config.Token,
+		tokens: config.Tokens,
 	}
 }
 
 // Middleware 返回一个检查令牌的中间件
 func (a *TokenAuthenticator) Middleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// 从查询参数中检查token
-		token := c.Query("token")
-		if token == "" {
-			// 如果查询参数中没有token，从Header中检查
-			token = c.GetHeader("Authorization")
-		}
+	return authMiddleware(a)
+}
 
-		// 验证token
-		if token != a.token {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "未授权访问，请提供有效的token",
-			})
-			return
+// Authenticate 检查查询参数或 Authorization 请求头中的令牌
+// 使用托管令牌时返回令牌对应的用户名，并把令牌保存到上下文中，之后按令牌的权限范围和路径前缀授权
+func (a *TokenAuthenticator) Authenticate(c *gin.Context) (string, bool) {
+	// 从查询参数中检查token
+	token := c.Query("token")
+	if token == "" {
+		// 如果查询参数中没有token，从Header中检查
+		token = bearerToken(c.GetHeader("Authorization"))
+	}
+	if token == "" {
+		return "", false
+	}
+
+	// 验证托管令牌
+	if a.tokens != nil {
+		if managed, ok := a.tokens.Lookup(token); ok {
+			c.Set(tokenContextKey, managed)
+			return managed.User(), true
 		}
+	}
 
-		c.Next()
+	// 验证token
+	return "", subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1
+}
+
+// bearerToken 从 Authorization 请求头中取出令牌
+// 请求头可以是 "Bearer 令牌"（认证方案不区分大小写），也可以直接是令牌；
+// 其他认证方案（例如与Basic认证同时使用时的 "Basic ..."）返回空
+func bearerToken(header string) string {
+	header = strings.TrimSpace(header)
+	scheme, rest, found := strings.Cut(header, " ")
+	if !found {
+		return header
+	}
+	if strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(rest)
 	}
+	return ""
+}
+
+// Challenge 返回401和JSON格式的错误信息
+func (a *TokenAuthenticator) Challenge(c *gin.Context) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"error": "未授权访问，请提供有效的token",
+	})
 }
 
 // AuthType 返回认证类型
@@ -45,6 +80,11 @@ func (a *TokenAuthenticator) AuthType() AuthType {
 	return TokenAuth
 }
 
+// Tokens 返回托管令牌文件，没有使用时为nil
+func (a *TokenAuthenticator) Tokens() *TokenStore {
+	return a.tokens
+}
+
 // LoginPageEnabled 返回是否启用了登录页
 func (a *TokenAuthenticator) LoginPageEnabled() bool {
 	return false
@@ -0,0 +1,338 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/config"
+	"github.com/CC11001100/servergo/pkg/i18n"
+)
+
+// TokenScope 托管令牌的权限范围
+type TokenScope string
+
+const (
+	// ScopeRead 读取文件、查看目录列表、搜索和打包下载
+	ScopeRead TokenScope = "read"
+	// ScopeWrite 在 ScopeRead 的基础上可以上传、覆盖文件和创建目录
+	ScopeWrite TokenScope = "write"
+	// ScopeAdmin 所有权限，包括删除和移动
+	ScopeAdmin TokenScope = "admin"
+)
+
+// scopePermissions 每种权限范围对应的权限
+var scopePermissions = map[TokenScope]Permission{
+	ScopeRead:  PermRead | PermList,
+	ScopeWrite: PermRead | PermList | PermWrite,
+	ScopeAdmin: PermAll,
+}
+
+// ParseTokenScope 解析权限范围的名称，例如 "read"
+func ParseTokenScope(name string) (TokenScope, error) {
+	scope := TokenScope(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := scopePermissions[scope]; !ok {
+		return "", fmt.Errorf(i18n.Tf("error.token_scope_invalid", name))
+	}
+	return scope, nil
+}
+
+// Permissions 返回权限范围对应的权限，未知的范围没有任何权限
+func (s TokenScope) Permissions() Permission {
+	return scopePermissions[s]
+}
+
+const (
+	// DefaultTokenFile 托管令牌文件在配置目录中的文件名，即 ~/.servergo/tokens
+	DefaultTokenFile = "tokens"
+	// tokenPrefix 托管令牌的前缀，便于在日志、脚本和代码仓库中识别泄露的令牌
+	tokenPrefix = "sgt_"
+	// tokenContextKey Gin上下文中保存通过认证的托管令牌的键
+	tokenContextKey = "servergo/token"
+	// tokenReloadInterval 检查令牌文件是否被修改的最小间隔，吊销令牌后无需重启即可生效
+	tokenReloadInterval = time.Second
+)
+
+// ManagedToken 托管令牌，文件中只保存令牌的SHA-256哈希，令牌本身只在创建时显示一次
+type ManagedToken struct {
+	Name      string     `json:"name"`                 // 名称，例如每个CI任务一个: "ci-deploy"
+	Hash      string     `json:"hash"`                 // 令牌的SHA-256哈希，十六进制
+	Scope     TokenScope `json:"scope"`                // 权限范围
+	Path      string     `json:"path"`                 // 可以访问的URL路径前缀，"/" 表示所有路径
+	CreatedAt int64      `json:"created_at"`           // 创建时间，Unix秒
+	ExpiresAt int64      `json:"expires_at,omitempty"` // 过期时间，Unix秒，为0表示永不过期
+}
+
+// User 返回令牌在访问控制策略中使用的用户名，例如 "token:ci-deploy"
+func (t *ManagedToken) User() string {
+	return "token:" + t.Name
+}
+
+// Expired 返回令牌在指定时间是否已经过期
+func (t *ManagedToken) Expired(now time.Time) bool {
+	return t.ExpiresAt != 0 && now.Unix() >= t.ExpiresAt
+}
+
+// Allows 判断令牌的路径前缀和权限范围是否允许对URL路径的操作
+// 与访问控制策略同时使用时，两者都允许才能访问
+func (t *ManagedToken) Allows(urlPath string, perm Permission) bool {
+	prefix := cleanURLPath(t.Path)
+	urlPath = cleanURLPath(urlPath)
+	if prefix != "/" && urlPath != prefix && !strings.HasPrefix(urlPath, prefix+"/") {
+		return false
+	}
+	return t.Scope.Permissions()&perm == perm
+}
+
+// RequestToken 返回请求通过认证时使用的托管令牌，没有使用托管令牌时返回nil
+func RequestToken(c *gin.Context) *ManagedToken {
+	if v, ok := c.Get(tokenContextKey); ok {
+		token, _ := v.(*ManagedToken)
+		return token
+	}
+	return nil
+}
+
+// TokenStore 保存托管令牌的JSON文件，默认为 ~/.servergo/tokens
+// 每个令牌有自己的名称、权限范围、路径前缀和过期时间，可以单独吊销；
+// 文件被修改后（例如执行 servergo token revoke）在下次验证时自动重新读取
+type TokenStore struct {
+	path string
+
+	mu        sync.RWMutex
+	tokens    []ManagedToken
+	modTime   time.Time
+	size      int64
+	checkedAt time.Time
+}
+
+// DefaultTokenStorePath 返回默认的令牌文件路径 ~/.servergo/tokens
+func DefaultTokenStorePath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DefaultTokenFile), nil
+}
+
+// OpenTokenStore 打开令牌文件，文件不存在时返回空的令牌文件，保存时创建
+//
+// 参数:
+//   - path: 文件路径，例如: "/home/user/.servergo/tokens"
+//
+// 返回值:
+//   - *TokenStore: 令牌文件
+//   - error: 文件无法读取或格式错误时返回错误
+func OpenTokenStore(path string) (*TokenStore, error) {
+	s := &TokenStore{path: path}
+	if err := s.reload(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Path 返回文件路径
+func (s *TokenStore) Path() string {
+	return s.path
+}
+
+// reload 重新读取文件
+func (s *TokenStore) reload() error {
+	s.mu.Lock()
+	s.checkedAt = time.Now()
+	s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var tokens []ManagedToken
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &tokens); err != nil {
+			return fmt.Errorf("%s: %w", s.path, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = tokens
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return nil
+}
+
+// reloadIfChanged 文件的修改时间或大小变化时重新读取，读取失败时继续使用之前的内容
+// 文件被删除时所有令牌都失效
+func (s *TokenStore) reloadIfChanged() {
+	s.mu.RLock()
+	recent := time.Since(s.checkedAt) < tokenReloadInterval
+	s.mu.RUnlock()
+	if recent {
+		return
+	}
+
+	s.mu.Lock()
+	s.checkedAt = time.Now()
+	modTime, size := s.modTime, s.size
+	s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.mu.Lock()
+		s.tokens, s.modTime, s.size = nil, time.Time{}, 0
+		s.mu.Unlock()
+		return
+	}
+	if err != nil || info.ModTime().Equal(modTime) && info.Size() == size {
+		return
+	}
+	s.reload()
+}
+
+// find 返回指定名称的令牌的下标，不存在时返回-1
+func (s *TokenStore) find(name string) int {
+	for i := range s.tokens {
+		if s.tokens[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// hashToken 返回令牌的SHA-256哈希；令牌是足够长的随机字符串，不需要bcrypt这样的慢哈希
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create 创建一个托管令牌
+//
+// 参数:
+//   - name: 令牌名称，不能为空或包含空白字符，不能与已有的令牌重名
+//   - scope: 权限范围
+//   - urlPath: 可以访问的URL路径前缀，为空时可以访问所有路径
+//   - ttl: 有效期，为0时永不过期
+//
+// 返回值:
+//   - string: 令牌，例如: "sgt_..."，文件中只保存哈希，之后无法再次获取
+//   - error: 名称无效或已存在、权限范围未知时返回错误
+func (s *TokenStore) Create(name string, scope TokenScope, urlPath string, ttl time.Duration) (string, error) {
+	if name == "" || strings.ContainsAny(name, " \t\r\n") {
+		return "", fmt.Errorf(i18n.Tf("error.token_name_invalid", name))
+	}
+	if _, ok := scopePermissions[scope]; !ok {
+		return "", fmt.Errorf(i18n.Tf("error.token_scope_invalid", scope))
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	secret := tokenPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now()
+	token := ManagedToken{
+		Name:      name,
+		Hash:      hashToken(secret),
+		Scope:     scope,
+		Path:      cleanURLPath(urlPath),
+		CreatedAt: now.Unix(),
+	}
+	if ttl > 0 {
+		token.ExpiresAt = now.Add(ttl).Unix()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.find(name) >= 0 {
+		return "", fmt.Errorf(i18n.Tf("error.token_exists", name))
+	}
+	s.tokens = append(s.tokens, token)
+	return secret, nil
+}
+
+// Revoke 吊销令牌
+//
+// 返回值:
+//   - bool: 令牌存在并被吊销时返回true
+func (s *TokenStore) Revoke(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.find(name)
+	if i < 0 {
+		return false
+	}
+	s.tokens = append(s.tokens[:i], s.tokens[i+1:]...)
+	return true
+}
+
+// Tokens 按创建顺序返回所有令牌，包括已经过期的令牌
+func (s *TokenStore) Tokens() []ManagedToken {
+	s.reloadIfChanged()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]ManagedToken(nil), s.tokens...)
+}
+
+// Lookup 查找令牌，令牌不存在或已经过期时返回false
+func (s *TokenStore) Lookup(secret string) (*ManagedToken, bool) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return nil, false
+	}
+	s.reloadIfChanged()
+	hash := []byte(hashToken(secret))
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := range s.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(s.tokens[i].Hash)) == 1 {
+			if s.tokens[i].Expired(time.Now()) {
+				return nil, false
+			}
+			token := s.tokens[i]
+			return &token, true
+		}
+	}
+	return nil, false
+}
+
+// Save 保存文件；先写入临时文件再重命名，运行中的服务器不会读到写了一半的文件
+func (s *TokenStore) Save() error {
+	s.mu.RLock()
+	tokens := s.tokens
+	if tokens == nil {
+		tokens = []ManagedToken{}
+	}
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,180 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTokenStore 测试创建、查找、吊销和保存托管令牌
+func TestTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf", "tokens")
+	tokens, err := OpenTokenStore(path)
+	if err != nil {
+		t.Fatalf("OpenTokenStore() 错误: %v", err)
+	}
+
+	for _, name := range []string{"", "ci deploy"} {
+		if _, err := tokens.Create(name, ScopeRead, "/", 0); err == nil {
+			t.Errorf("Create(%q) 期望返回错误", name)
+		}
+	}
+	if _, err := tokens.Create("ci", "owner", "/", 0); err == nil {
+		t.Errorf("未知的权限范围期望返回错误")
+	}
+
+	secret, err := tokens.Create("ci-deploy", ScopeWrite, "builds/", 0)
+	if err != nil {
+		t.Fatalf("Create() 错误: %v", err)
+	}
+	if _, err := tokens.Create("ci-deploy", ScopeRead, "/", 0); err == nil {
+		t.Errorf("重名的令牌期望返回错误")
+	}
+	expired, _ := tokens.Create("old", ScopeRead, "/", time.Nanosecond)
+	if err := tokens.Save(); err != nil {
+		t.Fatalf("Save() 错误: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), secret) {
+		t.Errorf("令牌文件中不应该保存令牌本身")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Errorf("文件权限 = %o, 期望 600", info.Mode().Perm())
+	}
+
+	tokens, err = OpenTokenStore(path)
+	if err != nil {
+		t.Fatalf("OpenTokenStore() 错误: %v", err)
+	}
+	token, ok := tokens.Lookup(secret)
+	if !ok || token.Name != "ci-deploy" || token.Path != "/builds" || token.User() != "token:ci-deploy" {
+		t.Fatalf("Lookup() = %+v, %v", token, ok)
+	}
+	if _, ok := tokens.Lookup(expired); ok {
+		t.Errorf("过期的令牌不应该通过")
+	}
+	if _, ok := tokens.Lookup(secret + "x"); ok {
+		t.Errorf("错误的令牌不应该通过")
+	}
+	if !tokens.Revoke("ci-deploy") || tokens.Revoke("ci-deploy") {
+		t.Errorf("Revoke() 应该只在令牌存在时返回true")
+	}
+	if _, ok := tokens.Lookup(secret); ok {
+		t.Errorf("吊销的令牌不应该通过")
+	}
+}
+
+// TestTokenStoreReload 测试在其他进程中吊销令牌后自动重新读取文件
+func TestTokenStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens")
+	editor, _ := OpenTokenStore(path)
+	secret, _ := editor.Create("ci", ScopeRead, "/", 0)
+	editor.Save()
+
+	tokens, err := OpenTokenStore(path)
+	if err != nil {
+		t.Fatalf("OpenTokenStore() 错误: %v", err)
+	}
+	if _, ok := tokens.Lookup(secret); !ok {
+		t.Fatalf("Lookup() 期望通过")
+	}
+
+	editor.Revoke("ci")
+	editor.Save()
+
+	// 模拟距离上次检查已经超过间隔
+	tokens.mu.Lock()
+	tokens.checkedAt = time.Time{}
+	tokens.mu.Unlock()
+
+	if _, ok := tokens.Lookup(secret); ok {
+		t.Errorf("文件修改后吊销的令牌不应该通过")
+	}
+}
+
+// TestManagedTokenAllows 测试令牌的权限范围和路径前缀
+func TestManagedTokenAllows(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    TokenScope
+		path     string
+		urlPath  string
+		perm     Permission
+		expected bool
+	}{
+		{name: "只读令牌读取", scope: ScopeRead, path: "/", urlPath: "/a.txt", perm: PermRead, expected: true},
+		{name: "只读令牌写入", scope: ScopeRead, path: "/", urlPath: "/a.txt", perm: PermWrite},
+		{name: "写入令牌写入", scope: ScopeWrite, path: "/", urlPath: "/a.txt", perm: PermWrite, expected: true},
+		{name: "写入令牌删除", scope: ScopeWrite, path: "/", urlPath: "/a.txt", perm: PermDelete},
+		{name: "管理令牌删除", scope: ScopeAdmin, path: "/", urlPath: "/a.txt", perm: PermDelete, expected: true},
+		{name: "路径前缀本身", scope: ScopeRead, path: "/builds", urlPath: "/builds", perm: PermList, expected: true},
+		{name: "路径前缀下的文件", scope: ScopeRead, path: "/builds", urlPath: "/builds/1/app.zip", perm: PermRead, expected: true},
+		{name: "名称相同前缀的其他目录", scope: ScopeRead, path: "/builds", urlPath: "/builds-old/app.zip", perm: PermRead},
+		{name: "路径前缀外", scope: ScopeAdmin, path: "/builds", urlPath: "/", perm: PermRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := &ManagedToken{Scope: tt.scope, Path: tt.path}
+			if got := token.Allows(tt.urlPath, tt.perm); got != tt.expected {
+				t.Errorf("Allows(%q) = %v, 期望 %v", tt.urlPath, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestTokenAuthHeader 测试从查询参数和 Authorization 请求头中读取令牌
+func TestTokenAuthHeader(t *testing.T) {
+	tokens, _ := OpenTokenStore(filepath.Join(t.TempDir(), "tokens"))
+	managed, _ := tokens.Create("ci", ScopeRead, "/", 0)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewTokenAuth(Config{Token: "tok", Tokens: tokens}).Middleware())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(gin.AuthUserKey))
+	})
+
+	tests := []struct {
+		name         string
+		query        string
+		header       string
+		expectedCode int
+		expectedUser string
+	}{
+		{name: "查询参数", query: "token=tok", expectedCode: http.StatusOK},
+		{name: "直接放令牌的请求头", header: "tok", expectedCode: http.StatusOK},
+		{name: "Bearer", header: "Bearer tok", expectedCode: http.StatusOK},
+		{name: "认证方案不区分大小写", header: "bearer  tok ", expectedCode: http.StatusOK},
+		{name: "其他认证方案", header: "Basic tok", expectedCode: http.StatusUnauthorized},
+		{name: "空的Bearer", header: "Bearer ", expectedCode: http.StatusUnauthorized},
+		{name: "错误的令牌", header: "Bearer wrong", expectedCode: http.StatusUnauthorized},
+		{name: "托管令牌", header: "Bearer " + managed, expectedCode: http.StatusOK, expectedUser: "token:ci"},
+		{name: "查询参数中的托管令牌", query: "token=" + managed, expectedCode: http.StatusOK, expectedUser: "token:ci"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			if w.Code == http.StatusOK && w.Body.String() != tt.expectedUser {
+				t.Errorf("认证的用户 = %q, 期望 %q", w.Body.String(), tt.expectedUser)
+			}
+		})
+	}
+}
@@ -32,14 +32,18 @@ type Config struct {
 	EnableLogPersistence bool `mapstructure:"enable-log-persistence"`
 	// 从哪个端口开始递增寻找空闲端口(0表示随机选择)
 	StartPort int `mapstructure:"start-port"`
+	// 是否启用单页应用模式，未知路径回退到根目录的index.html
+	SPA bool `mapstructure:"spa"`
+	// 是否在目录列表中显示以 "." 开头的隐藏文件
+	ShowHidden bool `mapstructure:"show-hidden"`
 	// 认证相关配置
 	Username string `json:"username" yaml:"username"` // 默认用户名
 	Password string `json:"password" yaml:"password"` // 默认密码
 	// 其他配置项可以在这里添加
 }
 
-// 获取配置目录路径
-func getConfigDir() (string, error) {
+// GetConfigDir 获取配置目录路径（~/.servergo），目录不存在时会自动创建
+func GetConfigDir() (string, error) {
 	// 获取用户HOME目录
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -59,7 +63,7 @@ func getConfigDir() (string, error) {
 
 // 获取配置文件路径
 func GetConfigFilePath() (string, error) {
-	configDir, err := getConfigDir()
+	configDir, err := GetConfigDir()
 	if err != nil {
 		return "", err
 	}
@@ -69,7 +73,7 @@ func GetConfigFilePath() (string, error) {
 
 // InitConfig 初始化配置
 func InitConfig() error {
-	configDir, err := getConfigDir()
+	configDir, err := GetConfigDir()
 	if err != nil {
 		return err
 	}
@@ -104,12 +108,14 @@ func SaveConfig(cfg Config) error {
 	viper.Set("language", cfg.Language)
 	viper.Set("enable-log-persistence", cfg.EnableLogPersistence)
 	viper.Set("start-port", cfg.StartPort)
+	viper.Set("spa", cfg.SPA)
+	viper.Set("show-hidden", cfg.ShowHidden)
 	viper.Set("username", cfg.Username)
 	viper.Set("password", cfg.Password)
 	// 其他配置项设置...
 
 	// 获取配置目录
-	configDir, err := getConfigDir()
+	configDir, err := GetConfigDir()
 	if err != nil {
 		return fmt.Errorf("failed to get config directory: %v", err)
 	}
@@ -171,6 +177,8 @@ func SetDefaults() {
 	viper.SetDefault("theme", "default")             // 默认使用默认主题
 	viper.SetDefault("enable-log-persistence", true) // 默认启用日志持久化
 	viper.SetDefault("start-port", 0)                // 默认从0开始递增寻找空闲端口
+	viper.SetDefault("spa", false)                   // 默认不启用单页应用模式
+	viper.SetDefault("show-hidden", false)           // 默认隐藏以 "." 开头的文件
 	viper.SetDefault("username", "admin")            // 默认用户名
 	viper.SetDefault("password", "")                 // 默认密码为空，将自动生成
 
@@ -188,6 +196,8 @@ func DefaultConfig() Config {
 		Language:             i18n.DetectOSLanguage(),
 		EnableLogPersistence: true,
 		StartPort:            0,
+		SPA:                  false,
+		ShowHidden:           false,
 		Username:             "admin", // 默认用户名
 		Password:             "",      // 默认密码为空，将自动生成
 	}
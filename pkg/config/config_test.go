@@ -42,7 +42,7 @@ func setupTestConfig(t *testing.T) {
 func TestGetConfigFilePath(t *testing.T) {
 	setupTestConfig(t)
 
-	// 因为我们不能直接修改getConfigDir，只能测试整体功能
+	// 因为我们不能直接修改GetConfigDir，只能测试整体功能
 	// 或者使用临时的环境变量来影响其行为
 
 	// 检查是否能获取用户主目录
@@ -106,7 +106,7 @@ func TestGetConfig(t *testing.T) {
 // 测试保存配置
 func TestSaveConfig(t *testing.T) {
 	// 这个测试无法在不修改原始函数的情况下进行完整测试
-	// 因为SaveConfig内部使用了getConfigDir，我们无法直接修改它
+	// 因为SaveConfig内部使用了GetConfigDir，我们无法直接修改它
 	// 这里我们只能测试一些基本功能，或者使用环境变量来影响它
 
 	// 获取临时目录用于测试
@@ -117,7 +117,7 @@ func TestSaveConfig(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	// 使用临时环境变量来影响HOME目录
-	// 注意：这只在getConfigDir使用os.UserHomeDir时有效
+	// 注意：这只在GetConfigDir使用os.UserHomeDir时有效
 	originalHome := os.Getenv("HOME")
 	os.Setenv("HOME", tempDir)
 	defer os.Setenv("HOME", originalHome)
@@ -130,7 +130,7 @@ func TestSaveConfig(t *testing.T) {
 	}
 
 	// 在真实环境中测试保存配置
-	// 注意：这可能会修改真实的配置文件，具体取决于getConfigDir的实现
+	// 注意：这可能会修改真实的配置文件，具体取决于GetConfigDir的实现
 	t.Skip("跳过实际写入文件的测试，以防意外修改实际配置文件")
 
 	// 以下代码在Skip之后不会执行，但保留以供参考
@@ -8,7 +8,23 @@ import (
 // renderTableTheme 渲染表格主题格式的目录列表
 // 该函数生成一个简洁的文本表格，适合在终端或简单文本界面中显示
 func renderTableTheme(data TemplateData) (string, error) {
+	if data.Error != nil {
+		return fmt.Sprintf("%d %s\n%s\n", data.Error.Code, data.Error.Title, data.Error.Message), nil
+	}
+
+	// 全文搜索的结果按 grep 的格式输出，每行是 "文件:行号: 内容"
+	if data.ContentSearch() {
+		var sb strings.Builder
+		for _, m := range data.Search.Matches {
+			fmt.Fprintf(&sb, "%s:%d: %s\n", m.Name, m.Line, m.Snippet)
+		}
+		return sb.String(), nil
+	}
+
 	if len(data.Items) == 0 {
+		if data.Listing != nil && data.Listing.Filter != "" {
+			return fmt.Sprintf("没有匹配 %q 的文件", data.Listing.Filter), nil
+		}
 		return "目录为空", nil
 	}
 
@@ -69,6 +85,17 @@ func renderTableTheme(data TemplateData) (string, error) {
 			nameField, item.Size, item.LastModified, itemType))
 	}
 
+	// 有多页时在表格下方显示页码和翻页地址
+	if l := data.Listing; l != nil && l.TotalPages > 1 {
+		result.WriteString(fmt.Sprintf("\n第 %d/%d 页，共 %d 项\n", l.Page, l.TotalPages, l.Total))
+		if l.PrevURL != "" {
+			result.WriteString("上一页: " + l.PrevURL + "\n")
+		}
+		if l.NextURL != "" {
+			result.WriteString("下一页: " + l.NextURL + "\n")
+		}
+	}
+
 	return result.String(), nil
 }
 
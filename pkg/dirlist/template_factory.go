@@ -7,6 +7,9 @@ import (
 	"github.com/CC11001100/servergo/pkg/i18n"
 )
 
+// partialsPattern 所有主题共享的局部模板文件
+const partialsPattern = "templates/partials/*.html"
+
 // 检查模板文件是否存在
 func templateFileExists(path string) bool {
 	// 尝试使用嵌入文件系统检查文件是否存在
@@ -83,6 +86,12 @@ func NewDirListTemplate(theme string) (*DirListTemplate, error) {
 		return nil, fmt.Errorf(i18n.Tf("dirlist.theme_load_error", errDetail))
 	}
 
+	// 解析所有主题共享的局部模板，例如上传表单等工具栏
+	tmpl, err = tmpl.ParseFS(templatesFS, partialsPattern)
+	if err != nil {
+		return nil, fmt.Errorf(i18n.Tf("dirlist.theme_load_error", err))
+	}
+
 	// 额外检查是否真的成功解析了模板
 	if tmpl == nil || tmpl.Templates() == nil || len(tmpl.Templates()) == 0 {
 		return nil, fmt.Errorf("成功解析模板，但模板集合为空 (%s)", templatePath)
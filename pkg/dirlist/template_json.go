@@ -9,6 +9,13 @@ import (
 
 // renderJSON 直接渲染JSON数据，避免HTML模板的限制
 func (t *DirListTemplate) renderJSON(data TemplateData) (string, error) {
+	if data.Error != nil {
+		return RenderErrorJSON(data)
+	}
+	if data.Search != nil {
+		return RenderSearchJSON(data)
+	}
+
 	// 创建特殊的JSON结构
 	type jsonItem struct {
 		Name          string `json:"name"`
@@ -18,6 +25,7 @@ func (t *DirListTemplate) renderJSON(data TemplateData) (string, error) {
 		LastModified  string `json:"last_modified"`
 		Path          string `json:"path"`
 		URL           string `json:"url"`
+		ThumbnailURL  string `json:"thumbnail_url,omitempty"`
 	}
 
 	type jsonData struct {
@@ -25,6 +33,17 @@ func (t *DirListTemplate) renderJSON(data TemplateData) (string, error) {
 		Timestamp       string     `json:"timestamp"`
 		ParentDirectory string     `json:"parent_directory"`
 		Contents        []jsonItem `json:"contents"`
+
+		// 排序、筛选和分页信息，contents 只包含当前页
+		Total      int    `json:"total"`
+		Page       int    `json:"page"`
+		PerPage    int    `json:"per_page"`
+		TotalPages int    `json:"total_pages"`
+		Sort       string `json:"sort,omitempty"`
+		Order      string `json:"order,omitempty"`
+		Filter     string `json:"filter,omitempty"`
+		Next       string `json:"next,omitempty"`
+		Prev       string `json:"prev,omitempty"`
 	}
 
 	// 转换数据
@@ -43,6 +62,7 @@ func (t *DirListTemplate) renderJSON(data TemplateData) (string, error) {
 			LastModified:  item.LastModified,
 			Path:          item.Path,
 			URL:           url,
+			ThumbnailURL:  item.ThumbURL,
 		}
 	}
 
@@ -51,6 +71,21 @@ func (t *DirListTemplate) renderJSON(data TemplateData) (string, error) {
 		Timestamp:       data.CurrentTime,
 		ParentDirectory: data.ParentDir,
 		Contents:        contents,
+		Total:           len(contents),
+		Page:            1,
+		PerPage:         len(contents),
+		TotalPages:      1,
+	}
+	if l := data.Listing; l != nil {
+		jsonResult.Total = l.Total
+		jsonResult.Page = l.Page
+		jsonResult.PerPage = l.PerPage
+		jsonResult.TotalPages = l.TotalPages
+		jsonResult.Sort = l.Sort
+		jsonResult.Order = l.Order
+		jsonResult.Filter = l.Filter
+		jsonResult.Next = l.NextURL
+		jsonResult.Prev = l.PrevURL
 	}
 
 	// 序列化为JSON字符串
@@ -61,3 +96,138 @@ func (t *DirListTemplate) renderJSON(data TemplateData) (string, error) {
 
 	return string(jsonBytes), nil
 }
+
+// RenderErrorJSON 将错误信息渲染为JSON对象
+// JSON主题以及请求偏好 application/json 的客户端都使用这个格式
+//
+// 返回值示例:
+// ```
+// {"error": {"code": 404, "title": "Not Found", "message": "404 Not Found: /a.txt", "path": "/a.txt"}}
+// ```
+func RenderErrorJSON(data TemplateData) (string, error) {
+	type jsonError struct {
+		Code    int    `json:"code"`
+		Title   string `json:"title"`
+		Message string `json:"message"`
+		Path    string `json:"path"`
+	}
+
+	jsonBytes, err := json.MarshalIndent(map[string]jsonError{
+		"error": {
+			Code:    data.Error.Code,
+			Title:   data.Error.Title,
+			Message: data.Error.Message,
+			Path:    data.DirPath,
+		},
+	}, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf(i18n.Tf("dirlist.json_marshal_error", err))
+	}
+
+	return string(jsonBytes), nil
+}
+
+// RenderSearchJSON 将搜索结果渲染为JSON对象
+// JSON主题以及请求偏好 application/json 的客户端都使用这个格式
+//
+// 返回值示例:
+// ```
+// {"query": "report", "path": "/builds", "count": 1, "truncated": false, "results": [{"name": "2024/report.pdf", "path": "/builds/2024/report.pdf", ...}]}
+// ```
+func RenderSearchJSON(data TemplateData) (string, error) {
+	type jsonResult struct {
+		Name         string `json:"name"`
+		IsDirectory  bool   `json:"is_directory"`
+		Size         int64  `json:"size"`
+		LastModified string `json:"last_modified"`
+		Path         string `json:"path"`
+		URL          string `json:"url"`
+	}
+
+	type jsonSearch struct {
+		Query     string       `json:"query"`
+		Path      string       `json:"path"`
+		Count     int          `json:"count"`
+		Truncated bool         `json:"truncated"`
+		Results   []jsonResult `json:"results"`
+	}
+
+	if data.Search.Content {
+		return renderContentSearchJSON(data)
+	}
+
+	results := make([]jsonResult, len(data.Items))
+	for i, item := range data.Items {
+		url := item.Path
+		if item.IsDir {
+			url += "/"
+		}
+		results[i] = jsonResult{
+			Name:         item.Name,
+			IsDirectory:  item.IsDir,
+			Size:         item.SizeBytes,
+			LastModified: item.LastModified,
+			Path:         item.Path,
+			URL:          url,
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(jsonSearch{
+		Query:     data.Search.Query,
+		Path:      data.DirPath,
+		Count:     data.Search.Count,
+		Truncated: data.Search.Truncated,
+		Results:   results,
+	}, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf(i18n.Tf("dirlist.json_marshal_error", err))
+	}
+
+	return string(jsonBytes), nil
+}
+
+// renderContentSearchJSON 将全文搜索结果渲染为JSON对象
+//
+// 返回值示例:
+// ```
+// {"query": "refused", "path": "/", "type": "content", "count": 1, "truncated": false, "indexing": false, "results": [{"file": "logs/app.log", "path": "/logs/app.log", "line": 12, "snippet": "dial tcp: connection refused", ...}]}
+// ```
+func renderContentSearchJSON(data TemplateData) (string, error) {
+	type jsonMatch struct {
+		File    string `json:"file"`
+		Path    string `json:"path"`
+		URL     string `json:"url"`
+		Line    int    `json:"line"`
+		Snippet string `json:"snippet"`
+	}
+
+	type jsonSearch struct {
+		Query     string      `json:"query"`
+		Path      string      `json:"path"`
+		Type      string      `json:"type"`
+		Count     int         `json:"count"`
+		Truncated bool        `json:"truncated"`
+		Indexing  bool        `json:"indexing"`
+		Results   []jsonMatch `json:"results"`
+	}
+
+	results := make([]jsonMatch, len(data.Search.Matches))
+	for i, m := range data.Search.Matches {
+		results[i] = jsonMatch{File: m.Name, Path: m.Path, URL: m.URL, Line: m.Line, Snippet: m.Snippet}
+	}
+
+	jsonBytes, err := json.MarshalIndent(jsonSearch{
+		Query:     data.Search.Query,
+		Path:      data.DirPath,
+		Type:      "content",
+		Count:     data.Search.Count,
+		Truncated: data.Search.Truncated,
+		Indexing:  data.Search.Indexing,
+		Results:   results,
+	}, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf(i18n.Tf("dirlist.json_marshal_error", err))
+	}
+
+	return string(jsonBytes), nil
+}
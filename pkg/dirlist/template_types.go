@@ -25,6 +25,104 @@ type TemplateData struct {
 	CurrentTime string     // 当前时间
 	RepoURL     string     // GitHub仓库URL
 	Stars       int        // GitHub Star数量
+
+	UploadEnabled bool // 是否启用了文件上传，启用时页面显示上传表单
+	Gallery       bool // 是否以画廊方式显示，图片显示为缩略图网格而不是文件列表
+	HasImages     bool // 目录中是否有图片，有图片时页面显示切换到画廊的链接
+
+	FullTextEnabled bool // 是否启用了全文索引，启用时搜索框可以选择搜索文件内容
+
+	Listing *ListingInfo // 目录列表的排序、筛选和分页信息，Items 只包含当前页的文件项
+
+	Error *ErrorInfo // 错误信息，不为nil时渲染错误页面而不是文件列表
+
+	Document *DocumentInfo // 渲染后的Markdown文档，不为nil时显示文档而不是文件列表
+	Readme   *DocumentInfo // 目录中的README文档，显示在文件列表下方
+
+	Source *SourceInfo // 高亮后的源码，不为nil时显示源码而不是文件列表
+
+	Search *SearchInfo // 搜索信息，不为nil时 Items 是搜索结果，文件名是相对于 DirPath 的路径
+}
+
+// ContentSearch 是否是全文搜索的结果页面，全文搜索的结果是 Search.Matches 而不是文件列表
+func (d TemplateData) ContentSearch() bool {
+	return d.Search != nil && d.Search.Content
+}
+
+// ErrorInfo 错误页面显示的信息
+type ErrorInfo struct {
+	Code    int    // HTTP状态码，例如: 404
+	Title   string // 状态码对应的标题，例如: "Not Found"
+	Message string // 详细的错误信息
+}
+
+// DocumentInfo 渲染后的Markdown文档
+type DocumentInfo struct {
+	Name string        // 文档的文件名，例如: "README.md"
+	HTML template.HTML // 渲染后的HTML片段
+	TOC  []TOCEntry    // 目录（文档中的标题）
+	CSS  template.CSS  // 代码高亮的样式表
+}
+
+// TOCEntry 文档目录中的一项
+type TOCEntry struct {
+	Level int    // 标题级别，1-6
+	Text  string // 标题文本
+	ID    string // 标题的锚点ID
+}
+
+// SourceInfo 源码查看页面显示的文件
+type SourceInfo struct {
+	Name     string        // 文件名，例如: "main.go"
+	Language string        // 识别出的语言，例如: "Go"
+	Lines    int           // 行数
+	Size     string        // 格式化后的大小
+	HTML     template.HTML // 高亮后带行号的HTML
+	CSS      template.CSS  // 代码高亮的样式表
+}
+
+// ListingInfo 目录列表的排序、筛选和分页信息
+type ListingInfo struct {
+	Sort   string // 排序字段: "name"、"size" 或 "mtime"
+	Order  string // 排序方向: "asc" 或 "desc"
+	Filter string // 文件名筛选条件，例如: "*.log"
+
+	Page       int    // 当前页码，从1开始
+	PerPage    int    // 每页的数量
+	Total      int    // 筛选后的文件项总数
+	TotalPages int    // 总页数，没有文件项时为1
+	PrevURL    string // 上一页的地址，没有上一页时为空
+	NextURL    string // 下一页的地址，没有下一页时为空
+
+	SortLinks []SortLink // 切换排序方式的链接
+}
+
+// SortLink 切换排序方式的链接
+type SortLink struct {
+	Label  string // 显示的文字，例如: "大小"
+	URL    string // 链接地址，例如: "?sort=size&order=desc"
+	Active bool   // 是否是当前的排序字段
+	Desc   bool   // 当前是否是降序
+}
+
+// SearchInfo 文件名搜索或全文搜索的信息
+type SearchInfo struct {
+	Query     string // 搜索词
+	Count     int    // 结果数量
+	Truncated bool   // 结果数量是否达到上限，达到上限时只返回了前面的结果
+
+	Content  bool          // 是否是全文搜索，全文搜索的结果在 Matches 中
+	Indexing bool          // 全文索引是否还在建立中，建立完成前结果可能不完整
+	Matches  []SearchMatch // 全文搜索匹配的行
+}
+
+// SearchMatch 全文搜索匹配的一行
+type SearchMatch struct {
+	Name    string // 相对于 DirPath 的文件路径，例如: "logs/app.log"
+	Path    string // 文件的访问路径，例如: "/shared/logs/app.log"
+	URL     string // 打开文件并定位到该行的地址，可以高亮显示的文件指向源码页面，例如: "/shared/main.go?view=1#L12"
+	Line    int    // 行号，从1开始
+	Snippet string // 匹配的行
 }
 
 // 文件或目录项
@@ -35,6 +133,8 @@ type FileItem struct {
 	SizeBytes    int64  // 原始大小（字节）
 	LastModified string // 修改时间
 	Path         string // 文件相对路径
+	ViewURL      string // 在页面中查看源码的地址，不能查看的文件为空
+	ThumbURL     string // 缩略图地址，不是图片的文件为空
 }
 
 // 返回所有支持的主题列表
@@ -0,0 +1,351 @@
+package fulltext
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultMaxFileSize 未配置 Options.MaxFileSize 时，被索引文件大小的上限（字节）
+const DefaultMaxFileSize = 4 << 20
+
+// DefaultExtensions 未配置 Options.Extensions 时被索引的文件扩展名
+var DefaultExtensions = []string{
+	".txt", ".log", ".md", ".markdown", ".rst", ".csv", ".tsv",
+	".json", ".yaml", ".yml", ".toml", ".ini", ".conf", ".cfg", ".env", ".xml",
+	".html", ".htm", ".css", ".js", ".ts", ".go", ".py", ".java", ".c", ".h", ".cpp", ".rs", ".rb", ".php", ".sh", ".sql",
+}
+
+// MinQueryLength 搜索词的最少字符数，更短的搜索词无法用三字符组缩小候选文件范围，不进行搜索
+const MinQueryLength = 3
+
+// snippetMaxRunes 片段的最大长度（字符），更长的行只截取匹配位置附近的内容
+const snippetMaxRunes = 200
+
+// binarySniffLen 判断是否是二进制文件时检查的字节数
+const binarySniffLen = 8000
+
+// Options 索引的配置
+type Options struct {
+	MaxFileSize int64    // 大于该字节数的文件不被索引，为0时使用 DefaultMaxFileSize
+	Extensions  []string // 被索引的文件扩展名，例如: []string{".log", ".md"}，"*" 表示所有文本文件，为空时使用 DefaultExtensions
+}
+
+// Match 一个搜索结果
+type Match struct {
+	Path    string // 文件的完整路径
+	Line    int    // 行号，从1开始
+	Snippet string // 匹配的行，过长时只包含匹配位置附近的内容
+}
+
+// document 被索引的文件
+type document struct {
+	id       uint32
+	modTime  time.Time
+	size     int64
+	trigrams []string // 文件包含的三字符组，删除文件时用于清理倒排表
+}
+
+// Index 文本文件的倒排索引
+// 以小写的三字符组（trigram）为键记录包含它的文件，搜索时先用三字符组缩小候选文件范围，
+// 再逐行扫描候选文件，结果与不区分大小写的子串匹配（grep -i -F）一致
+type Index struct {
+	maxFileSize int64
+	allExts     bool
+	exts        map[string]bool
+
+	mu       sync.RWMutex
+	nextID   uint32
+	docs     map[string]*document           // 文件路径 -> 文件
+	paths    map[uint32]string              // 文件ID -> 文件路径
+	postings map[string]map[uint32]struct{} // 三字符组 -> 包含它的文件ID
+}
+
+// New 创建空的索引
+func New(opts Options) *Index {
+	ix := &Index{
+		maxFileSize: opts.MaxFileSize,
+		exts:        map[string]bool{},
+		docs:        map[string]*document{},
+		paths:       map[uint32]string{},
+		postings:    map[string]map[uint32]struct{}{},
+	}
+	if ix.maxFileSize <= 0 {
+		ix.maxFileSize = DefaultMaxFileSize
+	}
+
+	exts := opts.Extensions
+	if len(exts) == 0 {
+		exts = DefaultExtensions
+	}
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		switch {
+		case ext == "*":
+			ix.allExts = true
+		case ext == "":
+		case !strings.HasPrefix(ext, "."):
+			ix.exts["."+ext] = true
+		default:
+			ix.exts[ext] = true
+		}
+	}
+	return ix
+}
+
+// Accepts 根据文件名和大小判断文件是否应该被索引
+func (ix *Index) Accepts(name string, size int64) bool {
+	if size > ix.maxFileSize {
+		return false
+	}
+	return ix.allExts || ix.exts[strings.ToLower(filepath.Ext(name))]
+}
+
+// Add 索引或重新索引一个文件
+// 文件没有修改时直接返回；文件不应该被索引、已被删除或是二进制文件时从索引中移除
+//
+// 参数:
+//   - path: 文件的完整路径
+func (ix *Index) Add(path string) error {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || !ix.Accepts(info.Name(), info.Size()) {
+		ix.Remove(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	ix.mu.RLock()
+	doc := ix.docs[path]
+	unchanged := doc != nil && doc.size == info.Size() && doc.modTime.Equal(info.ModTime())
+	ix.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		ix.Remove(path)
+		return err
+	}
+	if isBinary(content) {
+		ix.Remove(path)
+		return nil
+	}
+
+	trigrams := extractTrigrams(content)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.removeLocked(path)
+	ix.nextID++
+	doc = &document{id: ix.nextID, modTime: info.ModTime(), size: info.Size(), trigrams: trigrams}
+	ix.docs[path] = doc
+	ix.paths[doc.id] = path
+	for _, t := range trigrams {
+		posting := ix.postings[t]
+		if posting == nil {
+			posting = map[uint32]struct{}{}
+			ix.postings[t] = posting
+		}
+		posting[doc.id] = struct{}{}
+	}
+	return nil
+}
+
+// Remove 从索引中移除一个文件，或者一个目录下的所有文件
+func (ix *Index) Remove(path string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.removeLocked(path)
+	prefix := strings.TrimSuffix(path, string(filepath.Separator)) + string(filepath.Separator)
+	for p := range ix.docs {
+		if strings.HasPrefix(p, prefix) {
+			ix.removeLocked(p)
+		}
+	}
+}
+
+// removeLocked 移除一个文件，调用者需要持有写锁
+func (ix *Index) removeLocked(path string) {
+	doc := ix.docs[path]
+	if doc == nil {
+		return
+	}
+	for _, t := range doc.trigrams {
+		posting := ix.postings[t]
+		delete(posting, doc.id)
+		if len(posting) == 0 {
+			delete(ix.postings, t)
+		}
+	}
+	delete(ix.paths, doc.id)
+	delete(ix.docs, path)
+}
+
+// Len 返回已索引的文件数量
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Search 不区分大小写地搜索包含 query 的行
+// 结果按文件路径和行号排序，ctx 取消时停止搜索并返回已找到的结果；搜索词少于 MinQueryLength 个字符时没有结果
+//
+// 参数:
+//   - ctx: 控制搜索时长的上下文，例如请求的上下文
+//   - query: 搜索词，例如: "connection refused"
+//   - dir: 只搜索该目录下的文件，为空时搜索所有文件
+//   - visible: 判断文件是否可以出现在结果中，在计算结果数量之前过滤，为nil时不过滤
+//   - limit: 结果数量的上限
+//
+// 返回值:
+//   - []Match: 匹配的行
+//   - bool: 结果数量达到上限时返回true
+func (ix *Index) Search(ctx context.Context, query, dir string, visible func(path string) bool, limit int) ([]Match, bool) {
+	needle := strings.ToLower(query)
+	if utf8.RuneCountInString(needle) < MinQueryLength || limit <= 0 {
+		return nil, false
+	}
+
+	var matches []Match
+	for _, path := range ix.candidates(needle, dir) {
+		if ctx.Err() != nil {
+			break
+		}
+		if visible != nil && !visible(path) {
+			continue
+		}
+		var full bool
+		matches, full = scanFile(path, needle, matches, limit)
+		if full {
+			return matches, true
+		}
+	}
+	return matches, false
+}
+
+// candidates 返回包含搜索词所有三字符组的文件，按路径排序
+// 搜索词至少有 MinQueryLength 个字符，由调用者保证
+func (ix *Index) candidates(needle, dir string) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// 搜索词跨行时不会匹配任何一行
+	trigrams := extractTrigrams([]byte(needle))
+	if len(trigrams) == 0 {
+		return nil
+	}
+	// 从最短的倒排表开始求交集
+	sort.Slice(trigrams, func(i, j int) bool {
+		return len(ix.postings[trigrams[i]]) < len(ix.postings[trigrams[j]])
+	})
+	ids := ix.postings[trigrams[0]]
+	for _, t := range trigrams[1:] {
+		if len(ids) == 0 {
+			break
+		}
+		next := map[uint32]struct{}{}
+		for id := range ids {
+			if _, ok := ix.postings[t][id]; ok {
+				next[id] = struct{}{}
+			}
+		}
+		ids = next
+	}
+
+	prefix := ""
+	if dir != "" {
+		prefix = strings.TrimSuffix(dir, string(filepath.Separator)) + string(filepath.Separator)
+	}
+	paths := make([]string, 0, len(ids))
+	for id := range ids {
+		if p := ix.paths[id]; strings.HasPrefix(p, prefix) {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// scanFile 逐行扫描文件，把包含搜索词的行追加到 matches 中
+// 候选文件可能在索引之后被修改，所以总是以文件的当前内容为准
+func scanFile(path, needle string, matches []Match, limit int) ([]Match, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return matches, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		at := strings.Index(strings.ToLower(text), needle)
+		if at < 0 {
+			continue
+		}
+		if len(matches) >= limit {
+			return matches, true
+		}
+		matches = append(matches, Match{Path: path, Line: line, Snippet: snippet(text, at)})
+	}
+	return matches, false
+}
+
+// snippet 截取匹配位置附近的内容，去掉首尾的空白
+// at 是匹配在小写行中的字节位置，小写转换可能改变个别字符的字节长度，所以只作为大致位置
+func snippet(line string, at int) string {
+	line = strings.TrimRightFunc(line, unicode.IsSpace)
+	if utf8.RuneCountInString(line) <= snippetMaxRunes {
+		return strings.TrimSpace(line)
+	}
+
+	runes := []rune(line)
+	// 匹配位置前面保留三分之一的长度作为上下文
+	start := max(utf8.RuneCountInString(line[:min(at, len(line))])-snippetMaxRunes/3, 0)
+	end := min(start+snippetMaxRunes, len(runes))
+	start = max(end-snippetMaxRunes, 0)
+
+	result := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+	return result
+}
+
+// extractTrigrams 返回小写内容中所有不重复的三字符组，不跨行
+func extractTrigrams(content []byte) []string {
+	seen := map[string]struct{}{}
+	for _, line := range bytes.Split(bytes.ToLower(content), []byte("\n")) {
+		runes := []rune(string(line))
+		for i := 0; i+3 <= len(runes); i++ {
+			seen[string(runes[i:i+3])] = struct{}{}
+		}
+	}
+
+	trigrams := make([]string, 0, len(seen))
+	for t := range seen {
+		trigrams = append(trigrams, t)
+	}
+	return trigrams
+}
+
+// isBinary 和 git 一样，开头部分包含NUL字节的文件视为二进制文件
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) >= 0
+}
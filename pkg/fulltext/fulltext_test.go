@@ -0,0 +1,172 @@
+package fulltext
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestIndex 创建测试文件并建立索引
+func newTestIndex(t *testing.T, opts Options, files map[string]string) (*Index, string) {
+	dir := t.TempDir()
+	ix := New(opts)
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+		if err := ix.Add(p); err != nil {
+			t.Fatalf("索引 %s 失败: %v", name, err)
+		}
+	}
+	return ix, dir
+}
+
+// TestSearch 测试搜索结果的文件、行号和片段
+func TestSearch(t *testing.T) {
+	ix, dir := newTestIndex(t, Options{}, map[string]string{
+		"app.log":       "started\nERROR: connection refused\nok\nerror again\n",
+		"logs/db.log":   "db up\nConnection Refused by peer\n",
+		"docs/guide.md": "# 安装指南\n\n运行 servergo start 即可\n",
+		"image.png":     "connection refused",
+		"binary.txt":    "connection refused\x00\x01",
+	})
+
+	if n := ix.Len(); n != 3 {
+		t.Errorf("已索引文件数量 = %d, 期望 3", n)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		dir      string
+		expected []string // 文件相对路径:行号
+	}{
+		{name: "不区分大小写", query: "connection refused", expected: []string{"app.log:2", "logs/db.log:2"}},
+		{name: "子串匹配", query: "rror", expected: []string{"app.log:2", "app.log:4"}},
+		{name: "中文", query: "安装指南", expected: []string{"docs/guide.md:1"}},
+		{name: "短搜索词没有结果", query: "ok", expected: nil},
+		{name: "跨行的搜索词没有结果", query: "ok\nerror", expected: nil},
+		{name: "限定目录", query: "refused", dir: "logs", expected: []string{"logs/db.log:2"}},
+		{name: "没有结果", query: "timeout", expected: nil},
+	}
+	for _, tt := range tests {
+		searchDir := ""
+		if tt.dir != "" {
+			searchDir = filepath.Join(dir, tt.dir)
+		}
+		matches, truncated := ix.Search(context.Background(), tt.query, searchDir, nil, 10)
+		var got []string
+		for _, m := range matches {
+			rel, _ := filepath.Rel(dir, m.Path)
+			got = append(got, filepath.ToSlash(rel)+":"+strconv.Itoa(m.Line))
+		}
+		if strings.Join(got, ",") != strings.Join(tt.expected, ",") || truncated {
+			t.Errorf("%s: 搜索 %q = %v (truncated=%v), 期望 %v", tt.name, tt.query, got, truncated, tt.expected)
+		}
+	}
+
+	matches, _ := ix.Search(context.Background(), "refused", filepath.Join(dir, "logs"), nil, 10)
+	if len(matches) != 1 || matches[0].Snippet != "Connection Refused by peer" {
+		t.Errorf("片段 = %+v, 期望原始大小写的整行", matches)
+	}
+}
+
+// TestSearchLimit 测试结果数量的上限
+func TestSearchLimit(t *testing.T) {
+	ix, _ := newTestIndex(t, Options{}, map[string]string{
+		"a.log": strings.Repeat("timeout\n", 5),
+	})
+	matches, truncated := ix.Search(context.Background(), "timeout", "", nil, 3)
+	if len(matches) != 3 || !truncated {
+		t.Errorf("结果数量 = %d (truncated=%v), 期望 3 (truncated=true)", len(matches), truncated)
+	}
+}
+
+// TestSearchVisible 测试不可见的文件在计算结果数量之前被过滤
+func TestSearchVisible(t *testing.T) {
+	ix, dir := newTestIndex(t, Options{}, map[string]string{
+		"a.log": strings.Repeat("timeout\n", 5),
+		"b.log": "timeout\n",
+	})
+	hidden := filepath.Join(dir, "a.log")
+	matches, truncated := ix.Search(context.Background(), "timeout", "", func(path string) bool {
+		return path != hidden
+	}, 3)
+	if len(matches) != 1 || truncated || matches[0].Path != filepath.Join(dir, "b.log") {
+		t.Errorf("结果 = %+v (truncated=%v), 期望只有 b.log 的1个结果", matches, truncated)
+	}
+}
+
+// TestAddUpdateRemove 测试文件修改和删除后更新索引
+func TestAddUpdateRemove(t *testing.T) {
+	ix, dir := newTestIndex(t, Options{}, map[string]string{
+		"notes.txt":     "alpha",
+		"sub/other.txt": "alpha",
+	})
+	notes := filepath.Join(dir, "notes.txt")
+
+	os.WriteFile(notes, []byte("beta gamma"), 0644)
+	later := time.Now().Add(time.Second)
+	os.Chtimes(notes, later, later)
+	ix.Add(notes)
+	if matches, _ := ix.Search(context.Background(), "gamma", "", nil, 10); len(matches) != 1 {
+		t.Errorf("修改后搜索新内容 = %v, 期望 1 个结果", matches)
+	}
+	if matches, _ := ix.Search(context.Background(), "alpha", "", nil, 10); len(matches) != 1 {
+		t.Errorf("修改后搜索旧内容 = %v, 期望只剩 sub/other.txt", matches)
+	}
+
+	ix.Remove(filepath.Join(dir, "sub"))
+	if n := ix.Len(); n != 1 {
+		t.Errorf("删除目录后已索引文件数量 = %d, 期望 1", n)
+	}
+
+	os.Remove(notes)
+	if err := ix.Add(notes); err != nil {
+		t.Errorf("索引已删除的文件应该只是移除: %v", err)
+	}
+	if n := ix.Len(); n != 0 {
+		t.Errorf("删除文件后已索引文件数量 = %d, 期望 0", n)
+	}
+}
+
+// TestAccepts 测试文件大小和类型的限制
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		opts     Options
+		name     string
+		size     int64
+		expected bool
+	}{
+		{opts: Options{}, name: "app.LOG", size: 10, expected: true},
+		{opts: Options{}, name: "photo.jpg", size: 10, expected: false},
+		{opts: Options{}, name: "big.log", size: DefaultMaxFileSize + 1, expected: false},
+		{opts: Options{MaxFileSize: 100}, name: "a.txt", size: 101, expected: false},
+		{opts: Options{Extensions: []string{"log", ".out"}}, name: "run.out", size: 10, expected: true},
+		{opts: Options{Extensions: []string{"log"}}, name: "a.txt", size: 10, expected: false},
+		{opts: Options{Extensions: []string{"*"}}, name: "Makefile", size: 10, expected: true},
+	}
+	for _, tt := range tests {
+		if got := New(tt.opts).Accepts(tt.name, tt.size); got != tt.expected {
+			t.Errorf("Accepts(%q, %d) with %+v = %v, 期望 %v", tt.name, tt.size, tt.opts, got, tt.expected)
+		}
+	}
+}
+
+// TestSnippet 测试长行的截取
+func TestSnippet(t *testing.T) {
+	line := strings.Repeat("a", 500) + "NEEDLE" + strings.Repeat("b", 500)
+	got := snippet(line, 500)
+	if !strings.Contains(got, "NEEDLE") || !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("长行片段应该包含匹配位置并在两端加省略号: %q", got)
+	}
+	if got := snippet("  short line  ", 2); got != "short line" {
+		t.Errorf("短行片段 = %q, 期望 %q", got, "short line")
+	}
+}
@@ -0,0 +1,102 @@
+package highlight
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// styleName 代码高亮使用的配色，代码块自带背景色，在深色和浅色主题中都能正常显示
+const styleName = "github"
+
+// LineIDPrefix 源码查看页面中行号锚点的前缀，例如第10行的锚点是 "L10"
+const LineIDPrefix = "L"
+
+// codeFormatter 用于Markdown中的代码块，使用CSS类名输出高亮结果，样式由 CSS 统一提供
+var codeFormatter = chromahtml.New(chromahtml.WithClasses(true))
+
+// sourceFormatter 用于源码查看页面，每行带有可以链接的行号
+var sourceFormatter = chromahtml.New(
+	chromahtml.WithClasses(true),
+	chromahtml.WithLineNumbers(true),
+	chromahtml.WithLinkableLineNumbers(true, LineIDPrefix),
+)
+
+// sourceExts 缓存扩展名是否有对应的语法高亮，目录列表中每个文件都要判断一次
+var sourceExts sync.Map
+
+// CSS 返回代码高亮需要的样式表
+var CSS = sync.OnceValue(func() string {
+	var buf bytes.Buffer
+	if err := codeFormatter.WriteCSS(&buf, styles.Get(styleName)); err != nil {
+		return ""
+	}
+	return buf.String()
+})
+
+// Code 按语言高亮一段代码，语言不支持时按普通文本输出
+//
+// 参数:
+//   - w: 输出
+//   - code: 代码文本
+//   - lang: 语言名称或别名，例如: "go"、"sh"
+func Code(w io.Writer, code, lang string) error {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	return format(w, codeFormatter, lexer, code)
+}
+
+// Source 按文件名高亮整个源文件，输出带行号的HTML
+// 文件名无法确定语言时根据内容猜测，仍然无法确定时按普通文本输出
+//
+// 参数:
+//   - w: 输出
+//   - code: 文件内容
+//   - filename: 文件名，例如: "main.go"
+//
+// 返回值:
+//   - string: 识别出的语言名称，例如: "Go"
+//   - error: 输出失败时返回错误
+func Source(w io.Writer, code, filename string) (string, error) {
+	lexer := lexers.Match(filename)
+	if lexer == nil {
+		lexer = lexers.Analyse(code)
+	}
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	return lexer.Config().Name, format(w, sourceFormatter, lexer, code)
+}
+
+// IsSource 判断文件名是否对应可以高亮显示的文本或源码文件，例如 .go、.yaml、Makefile
+func IsSource(filename string) bool {
+	key := strings.ToLower(filepath.Ext(filename))
+	if key == "" {
+		key = filename
+	}
+	if cached, ok := sourceExts.Load(key); ok {
+		return cached.(bool)
+	}
+
+	isSource := lexers.Match(filename) != nil
+	sourceExts.Store(key, isSource)
+	return isSource
+}
+
+// format 使用指定的格式化器输出高亮结果
+func format(w io.Writer, formatter *chromahtml.Formatter, lexer chroma.Lexer, code string) error {
+	iterator, err := chroma.Coalesce(lexer).Tokenise(nil, code)
+	if err != nil {
+		return err
+	}
+	return formatter.Format(w, styles.Get(styleName), iterator)
+}
@@ -0,0 +1,42 @@
+package highlight
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestIsSource 测试根据文件名判断是否可以高亮显示
+func TestIsSource(t *testing.T) {
+	tests := map[string]bool{
+		"main.go":     true,
+		"config.yaml": true,
+		"Makefile":    true,
+		"notes.txt":   true,
+		"photo.jpg":   false,
+		"archive.zip": false,
+	}
+	for name, expected := range tests {
+		if got := IsSource(name); got != expected {
+			t.Errorf("IsSource(%q) = %v, 期望 %v", name, got, expected)
+		}
+	}
+}
+
+// TestSource 测试源码高亮输出带锚点的行号
+func TestSource(t *testing.T) {
+	var buf bytes.Buffer
+	language, err := Source(&buf, "package main\n\nfunc main() {}\n", "main.go")
+	if err != nil {
+		t.Fatalf("高亮失败: %v", err)
+	}
+	if language != "Go" {
+		t.Errorf("语言 = %q, 期望 Go", language)
+	}
+
+	for _, s := range []string{`id="L1"`, `href="#L3"`, `class="kd"`} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("输出中应该包含 %q", s)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package ignore 实现gitignore语法的路径匹配，用于 .servergoignore 文件和 --exclude 参数
+package ignore
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"strings"
+)
+
+// rule 表示一条忽略规则
+type rule struct {
+	segments []string // 按 "/" 分割后的模式，例如 "docs/*.md" 为 ["docs", "*.md"]
+	negate   bool     // 以 "!" 开头，重新包含之前被忽略的路径
+	dirOnly  bool     // 以 "/" 结尾，只匹配目录
+	anchored bool     // 模式中包含 "/"，相对于根目录匹配；否则匹配任意层级的名称
+}
+
+// Matcher 按gitignore语法判断路径是否被忽略
+// 零值或nil的Matcher不忽略任何路径
+type Matcher struct {
+	rules []rule
+}
+
+// New 根据gitignore语法的模式列表创建Matcher
+// 空行和以 "#" 开头的注释行会被跳过
+//
+// 参数:
+//   - patterns: 模式列表，例如: []string{"node_modules/", "*.log", "!keep.log"}
+func New(patterns []string) *Matcher {
+	m := &Matcher{}
+	for _, pattern := range patterns {
+		if r, ok := parseRule(pattern); ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	return m
+}
+
+// Load 读取gitignore语法的忽略文件，并追加额外的模式
+// 忽略文件不存在时只使用额外的模式，不返回错误
+//
+// 参数:
+//   - file: 忽略文件路径，例如: "/home/user/files/.servergoignore"
+//   - extra: 额外的模式，排在文件内容之后，例如命令行的 --exclude
+func Load(file string, extra []string) (*Matcher, error) {
+	var patterns []string
+
+	f, err := os.Open(file)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			patterns = append(patterns, scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return New(append(patterns, extra...)), nil
+}
+
+// Empty 判断Matcher是否没有任何规则
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Match 判断相对路径是否被忽略
+// 与git相同，父目录被忽略时其中的所有文件也被忽略，不能再用 "!" 重新包含
+//
+// 参数:
+//   - relPath: 相对于根目录、以 "/" 分隔的路径，例如: "docs/node_modules/a.js"
+//   - isDir: 路径是否是目录，用于以 "/" 结尾的规则
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	if m.Empty() {
+		return false
+	}
+
+	relPath = strings.Trim(relPath, "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	for i := 1; i <= len(parts); i++ {
+		// 除了最后一段，路径中的其他部分都是目录
+		if m.matchPath(parts[:i], i < len(parts) || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 按顺序应用所有规则，后面的规则覆盖前面的规则
+func (m *Matcher) matchPath(parts []string, isDir bool) bool {
+	ignored := false
+	for _, r := range m.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.match(parts) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// match 判断规则是否匹配路径
+func (r rule) match(parts []string) bool {
+	if !r.anchored {
+		ok, err := path.Match(r.segments[0], parts[len(parts)-1])
+		return err == nil && ok
+	}
+	return matchSegments(r.segments, parts)
+}
+
+// parseRule 解析一行gitignore语法的模式，空行和注释返回false
+func parseRule(line string) (rule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		// 转义开头的 "!" 和 "#"
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimLeft(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+
+	r.segments = strings.Split(line, "/")
+	return r, true
+}
+
+// matchSegments 逐段匹配模式和路径，"**" 匹配零个或多个路径段
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], parts[0]); err != nil || !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
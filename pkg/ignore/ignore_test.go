@@ -0,0 +1,103 @@
+package ignore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestMatch 测试gitignore语法的匹配规则
+func TestMatch(t *testing.T) {
+	m := New([]string{
+		"# 注释",
+		"",
+		"node_modules/",
+		"*.log",
+		"!keep.log",
+		"/build",
+		"docs/**/*.tmp",
+		".env",
+		`\#notes`,
+	})
+
+	tests := []struct {
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"web/node_modules/react/index.js", false, true},
+		{"node_modules", false, false}, // 以 "/" 结尾的规则只匹配目录
+		{"app.log", false, true},
+		{"logs/app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build/output.bin", false, true},
+		{"src/build", true, false}, // 以 "/" 开头的规则只匹配根目录
+		{"docs/a.tmp", false, true},
+		{"docs/x/y/a.tmp", false, true},
+		{"other/a.tmp", false, false},
+		{".env", false, true},
+		{"config/.env", false, true},
+		{"#notes", false, true},
+		{"README.md", false, false},
+		{"", true, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.expected {
+			t.Errorf("Match(%q, %v) = %v, 期望 %v", tt.path, tt.isDir, got, tt.expected)
+		}
+	}
+}
+
+// TestMatchNil 测试空Matcher不忽略任何路径
+func TestMatchNil(t *testing.T) {
+	var m *Matcher
+	if m.Match("a.txt", false) {
+		t.Errorf("nil Matcher 不应该忽略任何路径")
+	}
+	if !New(nil).Empty() {
+		t.Errorf("没有规则的Matcher应该为空")
+	}
+}
+
+// TestLoad 测试读取忽略文件并追加额外的模式
+func TestLoad(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "servergo-ignore-")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	file := filepath.Join(tempDir, ".servergoignore")
+
+	// 文件不存在时只使用额外的模式
+	m, err := Load(file, []string{"*.bak"})
+	if err != nil {
+		t.Fatalf("读取不存在的忽略文件不应该出错: %v", err)
+	}
+	if !m.Match("a.bak", false) {
+		t.Errorf("额外的模式没有生效")
+	}
+
+	if err := ioutil.WriteFile(file, []byte("secret/\n*.bak\n"), 0644); err != nil {
+		t.Fatalf("创建忽略文件失败: %v", err)
+	}
+	m, err = Load(file, []string{"!important.bak"})
+	if err != nil {
+		t.Fatalf("读取忽略文件失败: %v", err)
+	}
+
+	if !m.Match("secret/a.txt", false) {
+		t.Errorf("文件中的规则没有生效")
+	}
+	if !m.Match("a.bak", false) {
+		t.Errorf("文件中的规则没有生效")
+	}
+	if m.Match("important.bak", false) {
+		t.Errorf("额外的模式应该排在文件内容之后，可以重新包含路径")
+	}
+}
@@ -0,0 +1,43 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+
+	"github.com/CC11001100/servergo/pkg/highlight"
+)
+
+// codeBlockRenderer 使用chroma高亮带语言标记的代码块，例如 ```go
+type codeBlockRenderer struct{}
+
+// RegisterFuncs 实现 renderer.NodeRenderer 接口，替换默认的代码块渲染
+func (r *codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.renderFencedCodeBlock)
+}
+
+// renderFencedCodeBlock 渲染代码块，没有指定语言或语言不支持时按普通文本输出
+func (r *codeBlockRenderer) renderFencedCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+
+	block := node.(*ast.FencedCodeBlock)
+	var code strings.Builder
+	lines := block.Lines()
+	for i := 0; i < lines.Len(); i++ {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	lang := string(block.Language(source))
+	if err := highlight.Code(w, code.String(), lang); err != nil {
+		w.WriteString("<pre><code>")
+		w.WriteString(html.EscapeString(code.String()))
+		w.WriteString("</code></pre>\n")
+	}
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,126 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Heading 文档中的一个标题，用于生成目录
+type Heading struct {
+	Level int    // 标题级别，1-6
+	Text  string // 标题文本，例如: "安装"
+	ID    string // 标题的锚点ID，例如: "安装"
+}
+
+// Document 渲染后的Markdown文档
+type Document struct {
+	HTML     string    // 渲染后的HTML片段
+	Headings []Heading // 按出现顺序排列的所有标题
+}
+
+// converter 支持GitHub风格的表格、任务列表、删除线和自动链接，标题自动生成锚点ID
+// 原始HTML不会输出，防止文档中的脚本在页面中执行
+var converter = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	goldmark.WithRendererOptions(
+		renderer.WithNodeRenderers(util.Prioritized(&codeBlockRenderer{}, 100)),
+	),
+)
+
+// Render 把Markdown文本渲染为HTML，并提取所有标题用于生成目录
+//
+// 参数:
+//   - source: Markdown文本
+//
+// 返回值:
+//   - *Document: 渲染后的文档
+//   - error: 渲染失败时返回错误
+func Render(source []byte) (*Document, error) {
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	root := converter.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+
+	doc := &Document{}
+	err := ast.Walk(root, func(node ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := node.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		doc.Headings = append(doc.Headings, Heading{
+			Level: heading.Level,
+			Text:  string(heading.Text(source)),
+			ID:    string(idBytes),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := converter.Renderer().Render(&buf, source, root); err != nil {
+		return nil, err
+	}
+	doc.HTML = buf.String()
+	return doc, nil
+}
+
+// headingIDs 生成标题的锚点ID
+// goldmark 默认会去掉非ASCII字符，中文标题都会变成 "heading"，这里保留所有语言的字母和数字
+type headingIDs struct {
+	used map[string]bool
+}
+
+// newHeadingIDs 创建标题锚点ID生成器，每个文档使用一个新的生成器
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: map[string]bool{}}
+}
+
+// Generate 实现 parser.IDs 接口，字母转为小写，空白转为 "-"，去掉其他符号，重复的ID依次加上 "-1"、"-2" 后缀
+func (ids *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(string(value)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r) || r == '-' || r == '_':
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsSpace(r):
+			b.WriteRune('-')
+		}
+	}
+
+	base := b.String()
+	if base == "" {
+		base = "heading"
+	}
+	id := base
+	for i := 1; ids.used[id]; i++ {
+		id = fmt.Sprintf("%s-%d", base, i)
+	}
+	ids.used[id] = true
+	return []byte(id)
+}
+
+// Put 实现 parser.IDs 接口，记录文档中手动指定的ID
+func (ids *headingIDs) Put(value []byte) {
+	ids.used[string(value)] = true
+}
+
+// IsMarkdownFile 判断文件名是否是Markdown文档
+func IsMarkdownFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".md" || ext == ".markdown"
+}
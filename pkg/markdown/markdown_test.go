@@ -0,0 +1,64 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRender 测试Markdown渲染、标题提取和代码高亮
+func TestRender(t *testing.T) {
+	source := "# 介绍\n\n正文 **加粗**\n\n## Install Guide\n\n## Install Guide\n\n```go\nfunc main() {}\n```\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\n<script>alert(1)</script>\n"
+
+	doc, err := Render([]byte(source))
+	if err != nil {
+		t.Fatalf("渲染失败: %v", err)
+	}
+
+	expectedHeadings := []Heading{
+		{Level: 1, Text: "介绍", ID: "介绍"},
+		{Level: 2, Text: "Install Guide", ID: "install-guide"},
+		{Level: 2, Text: "Install Guide", ID: "install-guide-1"},
+	}
+	if len(doc.Headings) != len(expectedHeadings) {
+		t.Fatalf("标题数量 = %d, 期望 %d", len(doc.Headings), len(expectedHeadings))
+	}
+	for i, h := range doc.Headings {
+		if h != expectedHeadings[i] {
+			t.Errorf("第%d个标题 = %+v, 期望 %+v", i, h, expectedHeadings[i])
+		}
+	}
+
+	tests := []struct {
+		name     string
+		contains string
+		expected bool
+	}{
+		{name: "标题带锚点", contains: `<h2 id="install-guide">`, expected: true},
+		{name: "加粗", contains: "<strong>加粗</strong>", expected: true},
+		{name: "代码高亮", contains: `class="chroma"`, expected: true},
+		{name: "表格", contains: "<table>", expected: true},
+		{name: "不输出原始HTML", contains: "<script>", expected: false},
+	}
+	for _, tt := range tests {
+		if got := strings.Contains(doc.HTML, tt.contains); got != tt.expected {
+			t.Errorf("%s: 包含 %q = %v, 期望 %v", tt.name, tt.contains, got, tt.expected)
+		}
+	}
+}
+
+// TestIsMarkdownFile 测试Markdown文件的判断
+func TestIsMarkdownFile(t *testing.T) {
+	tests := map[string]bool{
+		"README.md":    true,
+		"guide.MD":     true,
+		"a.markdown":   true,
+		"index.html":   false,
+		"md":           false,
+		"notes.md.txt": false,
+	}
+	for name, expected := range tests {
+		if got := IsMarkdownFile(name); got != expected {
+			t.Errorf("IsMarkdownFile(%q) = %v, 期望 %v", name, got, expected)
+		}
+	}
+}
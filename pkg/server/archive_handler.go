@@ -0,0 +1,262 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// 支持的目录打包格式，对应 ?download= 参数的值
+const (
+	archiveFormatZip   = "zip"
+	archiveFormatTarGz = "tar.gz"
+)
+
+// archiveWriter 抽象了ZIP和tar.gz两种打包格式的写入操作
+type archiveWriter interface {
+	// addDir 写入一个目录项，name 以 "/" 分隔且不带结尾的 "/"
+	addDir(name string, info os.FileInfo) error
+	// addFile 写入一个普通文件
+	addFile(name string, info os.FileInfo, r io.Reader) error
+	// Close 写入归档的结尾数据
+	Close() error
+}
+
+// handleArchiveDownload 将目录打包为ZIP或tar.gz，以流的方式直接写入响应
+// 不会在磁盘上创建临时文件，打包过程中遵循与 handleFileRequest 相同的符号链接和根目录限制
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - fullPath: 目录在文件系统中的完整路径，例如: "/home/user/files/docs"
+//   - format: 打包格式，"zip" 或 "tar.gz"
+func (fs *FileServer) handleArchiveDownload(c *gin.Context, fullPath, format string) {
+	// 打包会暴露目录中的所有文件名，未启用目录列表时不允许
+	if !fs.config.EnableDirListing {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return
+	}
+
+	var contentType string
+	switch format {
+	case archiveFormatZip:
+		contentType = "application/zip"
+	case archiveFormatTarGz:
+		contentType = "application/gzip"
+	default:
+		fs.respondError(c, http.StatusBadRequest, i18n.Tf("http.archive_bad_format", format))
+		return
+	}
+
+	// 归档内的顶层目录名和下载文件名都使用目录名，例如: "docs/..." 和 "docs.zip"
+	baseName := filepath.Base(fullPath)
+	if baseName == string(filepath.Separator) {
+		// 服务目录就是文件系统根目录的情况
+		baseName = "root"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": baseName + "." + format,
+	}))
+	// Gin的NoRoute流程会预先把状态码设为404，这里显式设置为200
+	c.Status(http.StatusOK)
+
+	if c.Request.Method == http.MethodHead {
+		c.Writer.WriteHeaderNow()
+		return
+	}
+
+	var aw archiveWriter
+	if format == archiveFormatZip {
+		aw = newZipArchive(c.Writer)
+	} else {
+		aw = newTarGzArchive(c.Writer)
+	}
+
+	// 响应头已经发出，出错时只能记录日志并中断连接
+	visited := map[string]bool{}
+	if err := fs.writeArchiveDir(c, aw, fullPath, baseName, visited); err != nil {
+		logger.Error(i18n.Tf("server.archive_failed", fullPath, err))
+		c.Abort()
+		return
+	}
+	if err := aw.Close(); err != nil {
+		logger.Error(i18n.Tf("server.archive_failed", fullPath, err))
+	}
+}
+
+// writeArchiveDir 递归地把目录内容写入归档
+// 符号链接只有在真实路径位于服务目录内时才会被跟随，visited 记录已写入的真实目录，避免符号链接循环
+// 当前用户没有读取权限的文件和没有列表权限的子目录不会写入归档
+func (fs *FileServer) writeArchiveDir(c *gin.Context, aw archiveWriter, dirPath, name string, visited map[string]bool) error {
+	realPath, err := filepath.EvalSymlinks(dirPath)
+	if err != nil {
+		return err
+	}
+	if visited[realPath] {
+		return nil
+	}
+	visited[realPath] = true
+
+	info, err := os.Stat(dirPath)
+	if err != nil {
+		return err
+	}
+	if err := aw.addDir(name, info); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		entryPath := filepath.Join(dirPath, entry.Name())
+		entryName := path.Join(name, entry.Name())
+
+		// 与 handleFileRequest 相同：真实路径越出根目录的符号链接直接跳过，失效的链接也跳过
+		if err := fs.checkRealPath(entryPath); err != nil {
+			continue
+		}
+
+		// 获取链接目标的信息
+		info, err := os.Stat(entryPath)
+		if err != nil {
+			continue
+		}
+
+		// 隐藏文件、被忽略的文件和当前用户没有读取权限的文件不打包
+		if fs.isExcluded(entryPath, info.IsDir()) || !fs.allowed(c, fs.urlPathOf(entryPath), auth.PermRead) {
+			continue
+		}
+
+		switch {
+		case info.IsDir():
+			// 没有列表权限的子目录不打包，与目录列表页面一样不暴露其中的文件
+			if !fs.allowed(c, fs.urlPathOf(entryPath), auth.PermList) {
+				continue
+			}
+			if err := fs.writeArchiveDir(c, aw, entryPath, entryName, visited); err != nil {
+				return err
+			}
+		case info.Mode().IsRegular():
+			if err := writeArchiveFile(aw, entryPath, entryName, info); err != nil {
+				return err
+			}
+		}
+		// 设备文件、管道、套接字等特殊文件不打包
+	}
+
+	return nil
+}
+
+// writeArchiveFile 把单个文件写入归档
+func writeArchiveFile(aw archiveWriter, filePath, name string, info os.FileInfo) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		// 没有读取权限的文件跳过，不影响其他文件
+		if os.IsPermission(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	return aw.addFile(name, info, file)
+}
+
+// zipArchive 以ZIP格式写入归档
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+// newZipArchive 创建写入 w 的ZIP归档
+func newZipArchive(w io.Writer) *zipArchive {
+	return &zipArchive{zw: zip.NewWriter(w)}
+}
+
+func (a *zipArchive) addDir(name string, info os.FileInfo) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name + "/"
+	_, err = a.zw.CreateHeader(header)
+	return err
+}
+
+func (a *zipArchive) addFile(name string, info os.FileInfo, r io.Reader) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	header.Method = zip.Deflate
+
+	w, err := a.zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (a *zipArchive) Close() error {
+	return a.zw.Close()
+}
+
+// tarGzArchive 以tar.gz格式写入归档
+type tarGzArchive struct {
+	gw *gzip.Writer
+	tw *tar.Writer
+}
+
+// newTarGzArchive 创建写入 w 的tar.gz归档
+func newTarGzArchive(w io.Writer) *tarGzArchive {
+	gw := gzip.NewWriter(w)
+	return &tarGzArchive{gw: gw, tw: tar.NewWriter(gw)}
+}
+
+func (a *tarGzArchive) addDir(name string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name + "/"
+	return a.tw.WriteHeader(header)
+}
+
+func (a *tarGzArchive) addFile(name string, info os.FileInfo, r io.Reader) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	// 只写入头部声明的大小，防止打包过程中文件被追加内容导致tar格式错误
+	_, err = io.CopyN(a.tw, r, header.Size)
+	return err
+}
+
+func (a *tarGzArchive) Close() error {
+	if err := a.tw.Close(); err != nil {
+		return err
+	}
+	return a.gw.Close()
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newArchiveRouter 创建用于测试打包下载的路由，服务目录中包含一个指向外部目录的符号链接
+func newArchiveRouter(t *testing.T) (*gin.Engine, string, func()) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	srv.config.EnableDirListing = true
+
+	outsideDir, err := ioutil.TempDir("", "servergo-outside-")
+	if err != nil {
+		cleanup()
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(outsideDir, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(tempDir, "subdir", "escape")); err != nil {
+		t.Fatalf("创建符号链接失败: %v", err)
+	}
+	// 根目录内的符号链接应该被跟随
+	if err := os.Symlink(filepath.Join(tempDir, "test.txt"), filepath.Join(tempDir, "subdir", "link.txt")); err != nil {
+		t.Fatalf("创建符号链接失败: %v", err)
+	}
+	// 指向上级目录的符号链接不能导致死循环
+	if err := os.Symlink(tempDir, filepath.Join(tempDir, "subdir", "loop")); err != nil {
+		t.Fatalf("创建符号链接失败: %v", err)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+	return router, tempDir, func() {
+		os.RemoveAll(outsideDir)
+		cleanup()
+	}
+}
+
+// TestArchiveDownload 测试ZIP和tar.gz打包下载
+func TestArchiveDownload(t *testing.T) {
+	router, _, cleanup := newArchiveRouter(t)
+	defer cleanup()
+
+	readers := map[string]func(t *testing.T, body []byte) map[string]string{
+		"zip":    readZipEntries,
+		"tar.gz": readTarGzEntries,
+	}
+
+	for format, read := range readers {
+		t.Run(format, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/subdir/?download="+format, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", w.Code, http.StatusOK, w.Body.String())
+			}
+			if disposition := w.Header().Get("Content-Disposition"); !strings.Contains(disposition, "subdir."+format) {
+				t.Errorf("Content-Disposition = %q", disposition)
+			}
+
+			entries := read(t, w.Body.Bytes())
+			if entries["subdir/subfile.txt"] != "Sub File Content" {
+				t.Errorf("归档中缺少 subdir/subfile.txt，实际: %v", entryNames(entries))
+			}
+			if entries["subdir/link.txt"] != "Test File Content" {
+				t.Errorf("根目录内的符号链接应该被打包，实际: %v", entryNames(entries))
+			}
+			for name := range entries {
+				if strings.Contains(name, "escape") || strings.Contains(name, "secret") {
+					t.Errorf("归档中不应包含根目录之外的文件: %s", name)
+				}
+			}
+		})
+	}
+}
+
+// TestArchiveDownloadErrors 测试打包下载的错误情况
+func TestArchiveDownloadErrors(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	tests := []struct {
+		name         string
+		dirListing   bool
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "不支持的格式",
+			dirListing:   true,
+			path:         "/subdir/?download=rar",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "未启用目录列表",
+			dirListing:   false,
+			path:         "/subdir/?download=zip",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "文件忽略download参数",
+			dirListing:   true,
+			path:         "/test.txt?download=zip",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.config.EnableDirListing = tt.dirListing
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+		})
+	}
+}
+
+// readZipEntries 读取ZIP归档中所有文件的内容
+func readZipEntries(t *testing.T, body []byte) map[string]string {
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("解析ZIP失败: %v", err)
+	}
+
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("打开ZIP条目失败: %v", err)
+		}
+		content, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		entries[f.Name] = string(content)
+	}
+	return entries
+}
+
+// readTarGzEntries 读取tar.gz归档中所有文件的内容
+func readTarGzEntries(t *testing.T, body []byte) map[string]string {
+	gr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("解析gzip失败: %v", err)
+	}
+	tr := tar.NewReader(gr)
+
+	entries := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("解析tar失败: %v", err)
+		}
+		content, _ := ioutil.ReadAll(tr)
+		entries[header.Name] = string(content)
+	}
+	return entries
+}
+
+// entryNames 返回排序后的归档条目名称，便于输出错误信息
+func entryNames(entries map[string]string) []string {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
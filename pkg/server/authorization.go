@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/i18n"
+)
+
+// userContextKey WebDAV请求的context中保存认证用户名的键，confinedFileSystem 据此检查权限
+type userContextKey struct{}
+
+// tokenContextKey WebDAV请求的context中保存托管令牌的键
+type tokenContextKey struct{}
+
+// requestUser 返回认证中间件保存的用户名，未认证时为空
+func requestUser(c *gin.Context) string {
+	return c.GetString(gin.AuthUserKey)
+}
+
+// allowed 判断当前请求的用户对URL路径是否有指定的权限
+//
+// 参数:
+//   - c: Gin的上下文，包含认证的用户名
+//   - urlPath: URL路径，包含挂载点前缀，例如: "/docs/private/a.txt"
+//   - perm: 需要的权限，例如: auth.PermRead
+func (fs *FileServer) allowed(c *gin.Context, urlPath string, perm auth.Permission) bool {
+	return fs.userAllowed(requestUser(c), auth.RequestToken(c), urlPath, perm)
+}
+
+// userAllowed 判断用户对URL路径是否有指定的权限，没有配置授权器时不限制
+// 使用托管令牌认证时，还需要在令牌的权限范围和路径前缀之内
+func (fs *FileServer) userAllowed(user string, token *auth.ManagedToken, urlPath string, perm auth.Permission) bool {
+	if token != nil && !token.Allows(urlPath, perm) {
+		return false
+	}
+	if fs.authorizer == nil {
+		return true
+	}
+	return fs.authorizer.Allowed(user, urlPath, perm)
+}
+
+// authorize 检查权限，没有权限时写入错误响应并返回false
+// 不能读取的路径返回404，与隐藏文件一样不暴露路径是否存在；其他权限返回403
+func (fs *FileServer) authorize(c *gin.Context, urlPath string, perm auth.Permission) bool {
+	if fs.allowed(c, urlPath, perm) {
+		return true
+	}
+	if perm == auth.PermRead {
+		fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", urlPath))
+	} else {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+	}
+	return false
+}
+
+// readableItems 去掉当前用户不能读取的文件项，目录列表和搜索结果都不显示这些路径
+func (fs *FileServer) readableItems(c *gin.Context, items []dirlist.FileItem) []dirlist.FileItem {
+	visible := items[:0]
+	for _, item := range items {
+		if fs.allowed(c, item.Path, auth.PermRead) {
+			visible = append(visible, item)
+		}
+	}
+	return visible
+}
+
+// urlPathOf 返回服务目录中的文件对应的URL路径，例如 "/home/user/files/a/b.txt" 对应 "/a/b.txt"
+func (fs *FileServer) urlPathOf(fullPath string) string {
+	rel, err := filepath.Rel(fs.absDir, fullPath)
+	if err != nil {
+		return fs.prefix + "/"
+	}
+	return path.Join("/", fs.prefix, filepath.ToSlash(rel))
+}
+
+// withRequestUser 把认证的用户名和托管令牌保存到请求的context中
+func withRequestUser(c *gin.Context) *http.Request {
+	ctx := context.WithValue(c.Request.Context(), userContextKey{}, requestUser(c))
+	if token := auth.RequestToken(c); token != nil {
+		ctx = context.WithValue(ctx, tokenContextKey{}, token)
+	}
+	return c.Request.WithContext(ctx)
+}
+
+// contextUser 返回 withRequestUser 保存的用户名
+func contextUser(ctx context.Context) string {
+	user, _ := ctx.Value(userContextKey{}).(string)
+	return user
+}
+
+// contextToken 返回 withRequestUser 保存的托管令牌，没有使用托管令牌时返回nil
+func contextToken(ctx context.Context) *auth.ManagedToken {
+	token, _ := ctx.Value(tokenContextKey{}).(*auth.ManagedToken)
+	return token
+}
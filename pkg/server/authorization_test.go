@@ -0,0 +1,325 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+)
+
+// testPolicy 测试使用的访问控制策略：/private 只有 alice 和 bob 可以看到，只有 alice 可以修改
+const testPolicy = `
+default: [read, list]
+paths:
+  /private:
+    alice: [read, list, write, delete]
+    bob: [read, list]
+    "*": []
+  /subdir:
+    "*": [read, list, write]
+  /nolist:
+    "*": [read]
+  /docs/README.md:
+    "*": []
+`
+
+// newACLRouter 创建使用访问控制策略的测试路由，Basic认证头中的用户名作为已认证的用户
+func newACLRouter(t *testing.T) (*gin.Engine, string) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	t.Cleanup(cleanup)
+
+	os.Remove(filepath.Join(tempDir, "index.html"))
+	os.Mkdir(filepath.Join(tempDir, "private"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "private", "secret.txt"), []byte("secret"), 0644)
+	os.Mkdir(filepath.Join(tempDir, "nolist"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "nolist", "unlisted.txt"), []byte("unlisted"), 0644)
+	os.Mkdir(filepath.Join(tempDir, "docs"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "docs", "README.md"), []byte("# Readme secret"), 0644)
+
+	authorizer, err := auth.ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy() 错误: %v", err)
+	}
+	srv.authorizer = authorizer
+	srv.config.EnableUpload = true
+	srv.config.EnableWebDAV = true
+	srv.davHandler = srv.newWebDAVHandler()
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user, _, ok := c.Request.BasicAuth(); ok {
+			c.Set(gin.AuthUserKey, user)
+		}
+	})
+	router.GET(searchPath, srv.handleSearch)
+	router.NoRoute(srv.handleRequest)
+	return router, tempDir
+}
+
+// TestAuthorization 测试按用户和路径限制读取、列表、写入和删除
+func TestAuthorization(t *testing.T) {
+	router, tempDir := newACLRouter(t)
+
+	tests := []struct {
+		name         string
+		user         string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+		contains     string
+		notContains  string
+	}{
+		{name: "其他用户看不到私有目录", user: "carol", method: http.MethodGet, path: "/", expectedCode: http.StatusOK, contains: "test.txt", notContains: "private"},
+		{name: "未认证的用户看不到私有目录", method: http.MethodGet, path: "/", expectedCode: http.StatusOK, notContains: "private"},
+		{name: "授权的用户可以看到私有目录", user: "bob", method: http.MethodGet, path: "/", expectedCode: http.StatusOK, contains: "private"},
+		{name: "其他用户读取私有文件", user: "carol", method: http.MethodGet, path: "/private/secret.txt", expectedCode: http.StatusNotFound},
+		{name: "其他用户打包下载私有目录", user: "carol", method: http.MethodGet, path: "/private?download=zip", expectedCode: http.StatusNotFound},
+		{name: "授权的用户读取私有文件", user: "bob", method: http.MethodGet, path: "/private/secret.txt", expectedCode: http.StatusOK, contains: "secret"},
+		{name: "只读用户不能上传", user: "bob", method: http.MethodPut, path: "/private/new.txt", body: "x", expectedCode: http.StatusForbidden},
+		{name: "只读用户不能删除", user: "bob", method: http.MethodDelete, path: "/private/secret.txt", expectedCode: http.StatusForbidden},
+		{name: "默认规则不允许写入", user: "carol", method: http.MethodPut, path: "/new.txt", body: "x", expectedCode: http.StatusForbidden},
+		{name: "允许所有用户写入的目录", user: "carol", method: http.MethodPut, path: "/subdir/new.txt", body: "x", expectedCode: http.StatusCreated},
+		{name: "搜索结果不包含私有文件", user: "carol", method: http.MethodGet, path: searchPath + "?q=secret", expectedCode: http.StatusOK, notContains: "secret.txt"},
+		{name: "授权的用户可以搜索到私有文件", user: "alice", method: http.MethodGet, path: searchPath + "?q=secret", expectedCode: http.StatusOK, contains: "secret.txt"},
+		{name: "搜索不进入没有列表权限的目录", user: "carol", method: http.MethodGet, path: searchPath + "?q=unlisted", expectedCode: http.StatusOK, notContains: "unlisted.txt"},
+		{name: "目录列表不显示不能读取的README", user: "carol", method: http.MethodGet, path: "/docs/", expectedCode: http.StatusOK, notContains: "Readme secret"},
+		{name: "WebDAV列表不包含私有目录", user: "carol", method: "PROPFIND", path: "/", expectedCode: http.StatusMultiStatus, contains: "test.txt", notContains: "private"},
+		{name: "有权限的用户可以写入", user: "alice", method: http.MethodPut, path: "/private/new.txt", body: "x", expectedCode: http.StatusCreated},
+		{name: "有权限的用户可以删除", user: "alice", method: http.MethodDelete, path: "/private/new.txt", expectedCode: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, "")
+			}
+			if tt.method == "PROPFIND" {
+				req.Header.Set("Depth", "1")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", w.Code, tt.expectedCode, w.Body.String())
+			}
+			if tt.contains != "" && !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("响应中没有 %q", tt.contains)
+			}
+			if tt.notContains != "" && strings.Contains(w.Body.String(), tt.notContains) {
+				t.Errorf("响应中不应该有 %q", tt.notContains)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "new.txt")); err == nil {
+		t.Errorf("没有写入权限时不应该创建文件")
+	}
+}
+
+// TestTokenAuthorization 测试托管令牌只能在自己的权限范围和路径前缀内访问
+func TestTokenAuthorization(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	t.Cleanup(cleanup)
+	srv.config.EnableUpload = true
+	srv.config.EnableWebDAV = true
+	srv.davHandler = srv.newWebDAVHandler()
+
+	tokens, err := auth.OpenTokenStore(filepath.Join(t.TempDir(), "tokens"))
+	if err != nil {
+		t.Fatalf("OpenTokenStore() 错误: %v", err)
+	}
+	readToken, _ := tokens.Create("ci-read", auth.ScopeRead, "/subdir", 0)
+	writeToken, _ := tokens.Create("ci-write", auth.ScopeWrite, "/subdir", 0)
+
+	router := gin.New()
+	router.Use(auth.NewTokenAuth(auth.Config{Token: "static", Tokens: tokens}).Middleware())
+	router.NoRoute(srv.handleRequest)
+
+	tests := []struct {
+		name         string
+		token        string
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{name: "读取路径前缀内的文件", token: readToken, method: http.MethodGet, path: "/subdir/subfile.txt", expectedCode: http.StatusOK},
+		{name: "读取路径前缀外的文件", token: readToken, method: http.MethodGet, path: "/test.txt", expectedCode: http.StatusNotFound},
+		{name: "只读令牌不能上传", token: readToken, method: http.MethodPut, path: "/subdir/new.txt", body: "x", expectedCode: http.StatusForbidden},
+		{name: "写入令牌可以上传", token: writeToken, method: http.MethodPut, path: "/subdir/new.txt", body: "x", expectedCode: http.StatusCreated},
+		{name: "写入令牌不能在路径前缀外上传", token: writeToken, method: http.MethodPut, path: "/new.txt", body: "x", expectedCode: http.StatusForbidden},
+		{name: "写入令牌不能删除", token: writeToken, method: http.MethodDelete, path: "/subdir/new.txt", expectedCode: http.StatusForbidden},
+		{name: "启动时指定的令牌不受限制", token: "static", method: http.MethodGet, path: "/test.txt", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", w.Code, tt.expectedCode, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestLiveReloadAuthorization 测试实时刷新不推送当前用户不能读取的路径
+func TestLiveReloadAuthorization(t *testing.T) {
+	srv, router, _ := newLiveReloadRouter(t)
+	authorizer, err := auth.ParsePolicy([]byte(testPolicy))
+	if err != nil {
+		t.Fatalf("ParsePolicy() 错误: %v", err)
+	}
+	srv.authorizer = authorizer
+	defer srv.liveReload.close()
+
+	httpServer := httptest.NewServer(router)
+	defer httpServer.Close()
+	resp, err := http.Get(httpServer.URL + liveReloadPath)
+	if err != nil {
+		t.Fatalf("连接SSE失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); !strings.HasPrefix(line, ":") {
+		t.Fatalf("第一行 = %q, 期望是注释", line)
+	}
+	srv.liveReload.broadcast("/private/secret.txt")
+	srv.liveReload.broadcast("/test.txt")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("读取事件失败: %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			if line != "data: /test.txt\n" {
+				t.Errorf("第一个事件 = %q, 不能读取的路径不应该推送", line)
+			}
+			return
+		}
+	}
+}
+
+// TestProxyAuthorization 测试转发的请求与静态文件一样检查权限
+func TestProxyAuthorization(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	authorizer, err := auth.ParsePolicy([]byte("default: [read, list]\npaths:\n  /api/admin:\n    alice: [read, write]\n    \"*\": []\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() 错误: %v", err)
+	}
+	srv.authorizer = authorizer
+	srv.config.Proxies = []ProxyRule{{Prefix: "/api", Target: upstream.URL}}
+	if err := srv.setupProxies(); err != nil {
+		t.Fatalf("解析转发规则失败: %v", err)
+	}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if user, _, ok := c.Request.BasicAuth(); ok {
+			c.Set(gin.AuthUserKey, user)
+		}
+	})
+	router.Use(srv.proxyMiddleware())
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	tests := []struct {
+		name         string
+		user         string
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{name: "可以读取时转发GET", user: "carol", method: http.MethodGet, path: "/api/users", expectedCode: http.StatusOK},
+		{name: "没有写入权限时不转发POST", user: "carol", method: http.MethodPost, path: "/api/users", expectedCode: http.StatusForbidden},
+		{name: "不能读取时返回404", user: "carol", method: http.MethodGet, path: "/api/admin/stats", expectedCode: http.StatusNotFound},
+		{name: "有写入权限时转发POST", user: "alice", method: http.MethodPost, path: "/api/admin/stats", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, proxyServer.URL+tt.path, nil)
+			req.SetBasicAuth(tt.user, "")
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedCode {
+				t.Errorf("状态码 = %d, 期望 %d", resp.StatusCode, tt.expectedCode)
+			}
+		})
+	}
+}
+
+// TestTokenProxyAuthorization 测试托管令牌的权限范围和路径前缀同样限制转发的请求
+func TestTokenProxyAuthorization(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.config.Proxies = []ProxyRule{{Prefix: "/api", Target: upstream.URL}}
+	if err := srv.setupProxies(); err != nil {
+		t.Fatalf("解析转发规则失败: %v", err)
+	}
+
+	tokens, err := auth.OpenTokenStore(filepath.Join(t.TempDir(), "tokens"))
+	if err != nil {
+		t.Fatalf("OpenTokenStore() 错误: %v", err)
+	}
+	apiToken, _ := tokens.Create("ci-api", auth.ScopeRead, "/api", 0)
+	buildsToken, _ := tokens.Create("ci-builds", auth.ScopeRead, "/builds", 0)
+
+	router := gin.New()
+	router.Use(auth.NewTokenAuth(auth.Config{Token: "static", Tokens: tokens}).Middleware())
+	router.Use(srv.proxyMiddleware())
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	tests := []struct {
+		name         string
+		token        string
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{name: "只读令牌可以GET", token: apiToken, method: http.MethodGet, path: "/api/users", expectedCode: http.StatusOK},
+		{name: "只读令牌不能POST", token: apiToken, method: http.MethodPost, path: "/api/users", expectedCode: http.StatusForbidden},
+		{name: "路径前缀外不能GET", token: buildsToken, method: http.MethodGet, path: "/api/users", expectedCode: http.StatusNotFound},
+		{name: "路径前缀外不能POST", token: buildsToken, method: http.MethodPost, path: "/api/users", expectedCode: http.StatusForbidden},
+		{name: "启动时指定的令牌不受限制", token: "static", method: http.MethodPost, path: "/api/users", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, proxyServer.URL+tt.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedCode {
+				t.Errorf("状态码 = %d, 期望 %d", resp.StatusCode, tt.expectedCode)
+			}
+		})
+	}
+}
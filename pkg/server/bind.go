@@ -0,0 +1,148 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CC11001100/servergo/pkg/i18n"
+)
+
+// unixPrefix Unix套接字监听地址的前缀，例如: "unix:/run/servergo.sock"
+const unixPrefix = "unix:"
+
+// bindAddr 解析后的监听地址
+type bindAddr struct {
+	host     string // 主机或IP，为空表示所有接口，例如: "127.0.0.1"、"::1"
+	port     int    // 端口，为0表示使用 Config.Port
+	unixPath string // Unix套接字的路径，不为空时忽略 host 和 port
+}
+
+// UnixSocketPath 判断监听地址是否是Unix套接字
+//
+// 参数:
+//   - addr: 监听地址，例如: "unix:/run/servergo.sock"
+//
+// 返回值:
+//   - string: 套接字文件的路径，例如: "/run/servergo.sock"
+//   - bool: 是Unix套接字时返回true
+func UnixSocketPath(addr string) (string, bool) {
+	addr = strings.TrimSpace(addr)
+	if !strings.HasPrefix(addr, unixPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(addr, unixPrefix), true
+}
+
+// ParseBindAddr 解析 --bind 参数
+// 支持 "主机:端口"、"[IPv6]:端口"、":端口"，以及不带端口的 "主机"、"IPv6"、"[IPv6]"
+//
+// 参数:
+//   - addr: 监听地址，例如: "127.0.0.1:8080"、"[::1]:8080"、"10.8.0.2"
+//
+// 返回值:
+//   - string: 主机部分，为空表示所有接口
+//   - int: 端口，没有指定端口时为0
+//   - error: 格式错误或端口超出范围时返回错误
+func ParseBindAddr(addr string) (string, int, error) {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return "", 0, fmt.Errorf(i18n.Tf("error.bind_invalid", addr))
+	}
+
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		// 没有端口: "localhost"、"::1" 或 "[::1]"
+		host = addr
+		if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+			host = host[1 : len(host)-1]
+		}
+		if strings.ContainsAny(host, "[]") || strings.Count(host, ":") == 1 {
+			return "", 0, fmt.Errorf(i18n.Tf("error.bind_invalid", addr))
+		}
+		return host, 0, nil
+	}
+
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 65535 {
+		return "", 0, fmt.Errorf(i18n.Tf("error.bind_invalid", addr))
+	}
+	return host, port, nil
+}
+
+// bindAddrs 返回需要监听的地址，没有配置 Config.Bind 时监听所有接口
+func (fs *FileServer) bindAddrs() ([]bindAddr, error) {
+	if len(fs.config.Bind) == 0 {
+		return []bindAddr{{port: fs.config.Port}}, nil
+	}
+
+	addrs := make([]bindAddr, 0, len(fs.config.Bind))
+	for _, spec := range fs.config.Bind {
+		if socketPath, ok := UnixSocketPath(spec); ok {
+			if socketPath == "" {
+				return nil, fmt.Errorf(i18n.Tf("error.bind_invalid", spec))
+			}
+			addrs = append(addrs, bindAddr{unixPath: socketPath})
+			continue
+		}
+		host, port, err := ParseBindAddr(spec)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, bindAddr{host: host, port: port})
+	}
+	return addrs, nil
+}
+
+// urlHost 返回访问地址中的主机部分
+// 第一个TCP监听地址是具体的地址时使用该地址，例如只监听VPN接口时使用VPN的IP，否则使用 localhost
+func (fs *FileServer) urlHost() string {
+	addrs, err := fs.bindAddrs()
+	if err != nil {
+		return "localhost"
+	}
+	for _, addr := range addrs {
+		if addr.unixPath != "" {
+			continue
+		}
+		if ip := net.ParseIP(addr.host); addr.host == "" || ip != nil && ip.IsUnspecified() {
+			return "localhost"
+		}
+		if strings.Contains(addr.host, ":") {
+			return "[" + addr.host + "]"
+		}
+		return addr.host
+	}
+	return "localhost"
+}
+
+// unixOnly 判断是否只监听了Unix套接字，是时返回第一个套接字的路径
+func (fs *FileServer) unixOnly() (string, bool) {
+	addrs, err := fs.bindAddrs()
+	if err != nil {
+		return "", false
+	}
+	for _, addr := range addrs {
+		if addr.unixPath == "" {
+			return "", false
+		}
+	}
+	return addrs[0].unixPath, true
+}
+
+// listenUnix 监听Unix套接字
+// 套接字文件已经存在时，没有进程在使用（例如上次没有正常退出）才删除并重新创建，避免抢占正在运行的实例
+func listenUnix(socketPath string) (net.Listener, error) {
+	if info, err := os.Lstat(socketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.DialTimeout("unix", socketPath, time.Second)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf(i18n.Tf("error.unix_socket_in_use", socketPath))
+		}
+		os.Remove(socketPath)
+	}
+	return net.Listen("unix", socketPath)
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+)
+
+// TestParseBindAddr 测试解析监听地址
+func TestParseBindAddr(t *testing.T) {
+	tests := []struct {
+		name         string
+		addr         string
+		expectedHost string
+		expectedPort int
+		expectErr    bool
+	}{
+		{name: "IPv4和端口", addr: "127.0.0.1:8080", expectedHost: "127.0.0.1", expectedPort: 8080},
+		{name: "IPv6和端口", addr: "[::1]:8080", expectedHost: "::1", expectedPort: 8080},
+		{name: "只有端口", addr: ":9000", expectedHost: "", expectedPort: 9000},
+		{name: "只有主机名", addr: "localhost", expectedHost: "localhost"},
+		{name: "只有IPv6", addr: "::1", expectedHost: "::1"},
+		{name: "带括号的IPv6", addr: "[fe80::1]", expectedHost: "fe80::1"},
+		{name: "空地址", addr: "", expectErr: true},
+		{name: "端口不是数字", addr: "127.0.0.1:http", expectErr: true},
+		{name: "端口超出范围", addr: "127.0.0.1:70000", expectErr: true},
+		{name: "缺少端口", addr: "127.0.0.1:", expectErr: true},
+		{name: "括号不完整", addr: "[::1", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, err := ParseBindAddr(tt.addr)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("ParseBindAddr(%q) 期望返回错误", tt.addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseBindAddr(%q) 错误: %v", tt.addr, err)
+			}
+			if host != tt.expectedHost || port != tt.expectedPort {
+				t.Errorf("ParseBindAddr(%q) = %q, %d, 期望 %q, %d", tt.addr, host, port, tt.expectedHost, tt.expectedPort)
+			}
+		})
+	}
+}
+
+// TestBindURL 测试访问地址使用第一个监听地址
+func TestBindURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		bind     []string
+		expected string
+	}{
+		{name: "没有指定监听地址", expected: "http://localhost:9000"},
+		{name: "IPv4地址", bind: []string{"10.8.0.2:9000"}, expected: "http://10.8.0.2:9000"},
+		{name: "IPv6地址", bind: []string{"[::1]", "127.0.0.1"}, expected: "http://[::1]:9000"},
+		{name: "所有接口", bind: []string{"0.0.0.0"}, expected: "http://localhost:9000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &FileServer{config: Config{Port: 9000, Bind: tt.bind}}
+			if got := fs.URL(); got != tt.expected {
+				t.Errorf("URL() = %q, 期望 %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestListenMultipleAddrs 测试同时监听多个地址，没有指定端口的地址使用同一个端口
+func TestListenMultipleAddrs(t *testing.T) {
+	// 检查环境是否支持IPv6回环地址
+	if l, err := net.Listen("tcp", "[::1]:0"); err != nil {
+		t.Skipf("不支持IPv6回环地址: %v", err)
+	} else {
+		l.Close()
+	}
+
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "test.txt"), []byte("Hello"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	srv, err := New(Config{Dir: tempDir, AuthType: auth.NoAuth, Bind: []string{"127.0.0.1", "[::1]"}})
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("监听端口失败: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start(context.Background())
+	}()
+
+	addrs := srv.Addrs()
+	if len(addrs) != 2 {
+		t.Fatalf("监听地址 = %v, 期望2个", addrs)
+	}
+	first, second := addrs[0].(*net.TCPAddr), addrs[1].(*net.TCPAddr)
+	if !first.IP.Equal(net.IPv4(127, 0, 0, 1)) || !second.IP.Equal(net.IPv6loopback) || first.Port != second.Port {
+		t.Errorf("监听地址 = %v, 期望 127.0.0.1 和 ::1 使用同一个端口", addrs)
+	}
+
+	for _, addr := range addrs {
+		resp, err := http.Get(fmt.Sprintf("http://%s/test.txt", addr))
+		if err != nil {
+			t.Fatalf("请求 %s 失败: %v", addr, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(body) != "Hello" {
+			t.Errorf("%s 返回 %q, 期望 %q", addr, body, "Hello")
+		}
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown() 错误: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Start() 返回错误: %v", err)
+	}
+}
+
+// TestListenInvalidBind 测试监听地址无效时不会留下已经打开的监听器
+func TestListenInvalidBind(t *testing.T) {
+	srv, err := New(Config{Dir: t.TempDir(), AuthType: auth.NoAuth, Bind: []string{"127.0.0.1", "127.0.0.1:http"}})
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	if err := srv.Listen(); err == nil {
+		t.Fatalf("期望返回错误")
+	}
+	if addrs := srv.Addrs(); addrs != nil {
+		t.Errorf("监听地址 = %v, 期望没有监听", addrs)
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pires/go-proxyproto"
+
+	"github.com/CC11001100/servergo/pkg/i18n"
+)
+
+// unixConnKey 请求上下文中标记连接来自Unix套接字的键
+type unixConnKey struct{}
+
+// trustedProxies 可信代理的网段
+type trustedProxies []*net.IPNet
+
+// parseTrustedProxies 解析可信代理的IP或网段
+//
+// 参数:
+//   - specs: IP或CIDR网段，例如: []string{"127.0.0.1", "10.0.0.0/8", "::1"}
+//
+// 返回值:
+//   - trustedProxies: 解析后的网段，单个IP转换为 /32 或 /128 的网段
+//   - error: 格式错误时返回错误
+func parseTrustedProxies(specs []string) (trustedProxies, error) {
+	var nets trustedProxies
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if strings.Contains(spec, "/") {
+			_, ipNet, err := net.ParseCIDR(spec)
+			if err != nil {
+				return nil, fmt.Errorf(i18n.Tf("error.trusted_proxy_invalid", spec))
+			}
+			nets = append(nets, ipNet)
+			continue
+		}
+		ip := net.ParseIP(spec)
+		if ip == nil {
+			return nil, fmt.Errorf(i18n.Tf("error.trusted_proxy_invalid", spec))
+		}
+		bits := 8 * net.IPv6len
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 8*net.IPv4len
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
+}
+
+// contains 判断IP是否属于可信代理
+func (tp trustedProxies) contains(ip net.IP) bool {
+	for _, ipNet := range tp {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedClientIP 从 X-Forwarded-For 和 X-Real-IP 请求头中取得客户端的IP
+// X-Forwarded-For 从右向左跳过可信代理，返回第一个不可信的地址，客户端自己添加的地址不会被使用
+//
+// 返回值:
+//   - string: 客户端的IP，请求头中没有有效的地址时返回空字符串
+func (tp trustedProxies) forwardedClientIP(r *http.Request) string {
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		items := strings.Split(xff, ",")
+		for i := len(items) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(items[i]))
+			if ip == nil {
+				break
+			}
+			if i == 0 || !tp.contains(ip) {
+				return ip.String()
+			}
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// markUnixConn 标记来自Unix套接字的连接，用作 http.Server 的 ConnContext
+// 只判断连接的类型，不调用 LocalAddr，PROXY协议的连接调用 LocalAddr 会阻塞到读取完头部
+func markUnixConn(ctx context.Context, conn net.Conn) context.Context {
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+	if _, ok := conn.(*net.UnixConn); ok {
+		return context.WithValue(ctx, unixConnKey{}, true)
+	}
+	return ctx
+}
+
+// unixClientIPMiddleware 为来自Unix套接字的请求设置客户端地址
+// Unix套接字只有本机的反向代理（例如nginx、caddy）能够连接，总是信任它转发的请求头；
+// 没有请求头时说明是本机进程直接连接，使用 127.0.0.1。设置后 c.ClientIP() 返回真实的客户端地址
+func unixClientIPMiddleware(trusted trustedProxies) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Context().Value(unixConnKey{}) == nil {
+			c.Next()
+			return
+		}
+		ip := trusted.forwardedClientIP(c.Request)
+		if ip == "" {
+			ip = "127.0.0.1"
+		}
+		c.Request.RemoteAddr = net.JoinHostPort(ip, "0")
+		c.Next()
+	}
+}
+
+// setupTrustedProxies 设置可信代理，只有来自可信代理的请求才使用转发请求头中的客户端地址
+// 没有配置可信代理时不信任任何转发请求头，避免客户端伪造 X-Forwarded-For
+func setupTrustedProxies(engine *gin.Engine, specs []string) (trustedProxies, error) {
+	trusted, err := parseTrustedProxies(specs)
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.SetTrustedProxies(specs); err != nil {
+		return nil, fmt.Errorf(i18n.Tf("error.trusted_proxy_invalid", err))
+	}
+	return trusted, nil
+}
+
+// proxyProtocolListener 让TCP监听器接受PROXY协议（v1/v2）头部，请求的来源地址使用头部中的客户端地址
+// 只接受可信代理发送的头部，其他地址发送的头部会导致连接被拒绝；没有配置可信代理时拒绝所有头部。
+// 没有发送头部的连接按普通连接处理
+func (fs *FileServer) proxyProtocolListener(listener net.Listener) net.Listener {
+	trusted := fs.trustedProxies
+	return &proxyproto.Listener{
+		Listener: listener,
+		Policy: func(upstream net.Addr) (proxyproto.Policy, error) {
+			if tcpAddr, ok := upstream.(*net.TCPAddr); ok && trusted.contains(tcpAddr.IP) {
+				return proxyproto.USE, nil
+			}
+			return proxyproto.REJECT, nil
+		},
+	}
+}
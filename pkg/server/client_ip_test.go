@@ -0,0 +1,296 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pires/go-proxyproto"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+)
+
+// TestParseTrustedProxies 测试解析可信代理
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name      string
+		specs     []string
+		trusted   []string
+		untrusted []string
+		expectErr bool
+	}{
+		{name: "单个IPv4", specs: []string{"127.0.0.1"}, trusted: []string{"127.0.0.1"}, untrusted: []string{"127.0.0.2"}},
+		{name: "网段", specs: []string{"10.0.0.0/8"}, trusted: []string{"10.1.2.3"}, untrusted: []string{"11.0.0.1"}},
+		{name: "IPv6", specs: []string{"::1", "fd00::/8"}, trusted: []string{"::1", "fd12::1"}, untrusted: []string{"::2"}},
+		{name: "没有配置", untrusted: []string{"127.0.0.1"}},
+		{name: "无效的IP", specs: []string{"localhost"}, expectErr: true},
+		{name: "无效的网段", specs: []string{"10.0.0.0/33"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp, err := parseTrustedProxies(tt.specs)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTrustedProxies() 错误: %v", err)
+			}
+			for _, ip := range tt.trusted {
+				if !tp.contains(net.ParseIP(ip)) {
+					t.Errorf("%s 应该是可信代理", ip)
+				}
+			}
+			for _, ip := range tt.untrusted {
+				if tp.contains(net.ParseIP(ip)) {
+					t.Errorf("%s 不应该是可信代理", ip)
+				}
+			}
+		})
+	}
+}
+
+// newClientIPServer 创建返回 c.ClientIP() 的测试服务器
+func newClientIPServer(t *testing.T, config Config) *FileServer {
+	config.Dir = t.TempDir()
+	config.AuthType = auth.NoAuth
+	srv, err := New(config)
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+	srv.engine.GET("/ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+	return srv
+}
+
+// TestClientIPTrustedProxies 测试只使用可信代理转发的客户端地址
+func TestClientIPTrustedProxies(t *testing.T) {
+	tests := []struct {
+		name       string
+		trusted    []string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "没有配置可信代理时忽略转发请求头",
+			remoteAddr: "127.0.0.1:40000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expected:   "127.0.0.1",
+		},
+		{
+			name:       "可信代理转发的X-Forwarded-For",
+			trusted:    []string{"127.0.0.1"},
+			remoteAddr: "127.0.0.1:40000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "跳过多级可信代理",
+			trusted:    []string{"127.0.0.1", "10.0.0.0/8"},
+			remoteAddr: "127.0.0.1:40000",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.0.0.5"},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "可信代理转发的X-Real-IP",
+			trusted:    []string{"127.0.0.1"},
+			remoteAddr: "127.0.0.1:40000",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.7"},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "不可信的地址伪造请求头",
+			trusted:    []string{"127.0.0.1"},
+			remoteAddr: "198.51.100.9:40000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expected:   "198.51.100.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newClientIPServer(t, Config{TrustedProxies: tt.trusted})
+
+			req, _ := http.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			srv.engine.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.expected {
+				t.Errorf("ClientIP() = %q, 期望 %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestInvalidTrustedProxies 测试可信代理格式错误时无法创建服务器
+func TestInvalidTrustedProxies(t *testing.T) {
+	_, err := New(Config{Dir: t.TempDir(), AuthType: auth.NoAuth, TrustedProxies: []string{"proxy.local"}})
+	if err == nil {
+		t.Fatalf("期望返回错误")
+	}
+}
+
+// startTestServer 在后台启动服务器，测试结束时关闭
+func startTestServer(t *testing.T, srv *FileServer) {
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("监听失败: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start(context.Background())
+	}()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		<-done
+	})
+}
+
+// TestUnixSocketListener 测试监听Unix套接字，套接字的对端转发的客户端地址总是被信任
+func TestUnixSocketListener(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "servergo.sock")
+
+	// 模拟上次没有正常退出时留下的套接字文件
+	stale, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("不支持Unix套接字: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	srv := newClientIPServer(t, Config{Bind: []string{"unix:" + socketPath}})
+	startTestServer(t, srv)
+
+	if got := srv.URL(); got != "unix:"+socketPath {
+		t.Errorf("URL() = %q, 期望 %q", got, "unix:"+socketPath)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		xff      string
+		expected string
+	}{
+		{name: "反向代理转发的客户端地址", xff: "203.0.113.7", expected: "203.0.113.7"},
+		{name: "本机进程直接连接", expected: "127.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://servergo/ip", nil)
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != tt.expected {
+				t.Errorf("ClientIP() = %q, 期望 %q", body, tt.expected)
+			}
+		})
+	}
+
+	// 正在使用的套接字不能被另一个实例抢占
+	other := newClientIPServer(t, Config{Bind: []string{"unix:" + socketPath}})
+	if err := other.Listen(); err == nil {
+		other.Shutdown(context.Background())
+		t.Errorf("套接字正在使用时期望返回错误")
+	}
+}
+
+// requestWithProxyHeader 发送带有PROXY协议头部的请求，返回响应内容
+func requestWithProxyHeader(t *testing.T, addr net.Addr, version byte) (string, error) {
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	header := proxyproto.HeaderProxyFromAddrs(version,
+		&net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 50000},
+		&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 80})
+	if _, err := header.WriteTo(conn); err != nil {
+		t.Fatalf("发送PROXY头部失败: %v", err)
+	}
+	io.WriteString(conn, "GET /ip HTTP/1.1\r\nHost: servergo\r\nConnection: close\r\n\r\n")
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return string(body), nil
+}
+
+// TestProxyProtocol 测试TCP监听器接受PROXY协议头部
+func TestProxyProtocol(t *testing.T) {
+	srv := newClientIPServer(t, Config{Bind: []string{"127.0.0.1"}, ProxyProtocol: true, TrustedProxies: []string{"127.0.0.1"}})
+	startTestServer(t, srv)
+
+	for _, version := range []byte{1, 2} {
+		got, err := requestWithProxyHeader(t, srv.Addr(), version)
+		if err != nil {
+			t.Fatalf("v%d 请求失败: %v", version, err)
+		}
+		if got != "203.0.113.7" {
+			t.Errorf("v%d ClientIP() = %q, 期望 %q", version, got, "203.0.113.7")
+		}
+	}
+
+	// 没有发送头部的连接按普通连接处理
+	resp, err := http.Get("http://" + srv.Addr().String() + "/ip")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "127.0.0.1" {
+		t.Errorf("ClientIP() = %q, 期望 %q", body, "127.0.0.1")
+	}
+}
+
+// TestProxyProtocolUntrusted 测试不可信的地址发送的PROXY协议头部会被拒绝
+func TestProxyProtocolUntrusted(t *testing.T) {
+	srv := newClientIPServer(t, Config{Bind: []string{"127.0.0.1"}, ProxyProtocol: true, TrustedProxies: []string{"10.0.0.0/8"}})
+	startTestServer(t, srv)
+
+	got, err := requestWithProxyHeader(t, srv.Addr(), 1)
+	if err == nil && strings.Contains(got, "203.0.113.7") {
+		t.Errorf("不可信的地址不应该能够伪造客户端地址")
+	}
+}
+
+// TestProxyProtocolRequiresTrustedProxies 测试启用PROXY协议时必须配置可信代理
+func TestProxyProtocolRequiresTrustedProxies(t *testing.T) {
+	if _, err := New(Config{Dir: t.TempDir(), AuthType: auth.NoAuth, ProxyProtocol: true}); err == nil {
+		t.Errorf("没有配置可信代理时期望返回错误")
+	}
+}
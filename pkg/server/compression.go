@@ -0,0 +1,375 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// DefaultCompressionMinSize 未配置 Config.CompressionMinSize 时，小于该字节数的响应不压缩
+const DefaultCompressionMinSize = 1024
+
+// DefaultCompressionTypes 未配置 Config.CompressionTypes 时允许压缩的MIME类型
+// 以 "/" 结尾的项按前缀匹配，例如 "text/" 匹配所有文本类型
+var DefaultCompressionTypes = []string{
+	"text/",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// 支持的内容编码，按服务端偏好从高到低排列
+const (
+	encodingBrotli = "br"
+	encodingZstd   = "zstd"
+	encodingGzip   = "gzip"
+)
+
+var supportedEncodings = []string{encodingBrotli, encodingZstd, encodingGzip}
+
+// precompressedExtensions 预压缩文件的扩展名，例如 app.js.br、app.js.gz
+var precompressedExtensions = map[string]string{
+	encodingBrotli: ".br",
+	encodingZstd:   ".zst",
+	encodingGzip:   ".gz",
+}
+
+// compressionMiddleware 返回根据 Accept-Encoding 协商压缩响应的中间件
+// 文件下载、目录列表、JSON等所有响应都经过该中间件，是否压缩取决于响应的大小、状态码和Content-Type
+func (fs *FileServer) compressionMiddleware() gin.HandlerFunc {
+	minSize := fs.config.CompressionMinSize
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+	types := fs.config.CompressionTypes
+	if len(types) == 0 {
+		types = DefaultCompressionTypes
+	}
+
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), supportedEncodings)
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		// 无论最终是否压缩，响应内容都取决于 Accept-Encoding
+		c.Header("Vary", "Accept-Encoding")
+
+		cw := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        minSize,
+			types:          types,
+		}
+		c.Writer = cw
+		defer cw.finish()
+
+		c.Next()
+	}
+}
+
+// compressWriter 包装Gin的ResponseWriter
+// 在写出的数据达到最小压缩大小之前先缓存在内存中，再根据响应头决定是否压缩
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	types    []string
+
+	buf       []byte         // 决定是否压缩之前缓存的数据
+	decided   bool           // 是否已经决定了压缩方式并写出了响应头
+	headerNow bool           // 决定之前是否调用过 WriteHeaderNow
+	encoder   io.WriteCloser // 压缩编码器，为nil表示不压缩
+}
+
+// Write 写入响应数据
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// WriteString 写入字符串响应数据
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow 延迟到决定是否压缩后再写出响应头，因为压缩时需要修改响应头
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.headerNow = true
+}
+
+// Written 返回是否已经写出了响应（包括仍在缓存中的数据）
+func (w *compressWriter) Written() bool {
+	if w.decided {
+		return w.ResponseWriter.Written()
+	}
+	return w.headerNow || len(w.buf) > 0
+}
+
+// Size 返回已写出的响应体大小
+func (w *compressWriter) Size() int {
+	if !w.decided && len(w.buf) > 0 {
+		return len(w.buf)
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush 立即写出缓存的数据，用于流式响应
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide 根据响应状态码和响应头决定是否压缩，并写出缓存的数据
+func (w *compressWriter) decide() error {
+	w.decided = true
+
+	if w.shouldCompress() {
+		w.encoder = newEncoder(w.encoding, w.ResponseWriter)
+	}
+	if w.encoder != nil {
+		header := w.Header()
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// 压缩后的内容与原始内容的字节范围不同
+		header.Del("Accept-Ranges")
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+	}
+
+	if len(w.buf) == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return nil
+	}
+
+	buf := w.buf
+	w.buf = nil
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// shouldCompress 判断当前响应是否应该压缩
+func (w *compressWriter) shouldCompress() bool {
+	if len(w.buf) < w.minSize {
+		return false
+	}
+
+	// 只压缩完整的成功响应，206等部分响应需要保持原始字节范围
+	status := w.Status()
+	if status < 200 || status >= 300 || status == http.StatusNoContent || status == http.StatusPartialContent {
+		return false
+	}
+
+	header := w.Header()
+	// 已经编码过的响应（例如预压缩文件）不再压缩
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	return isCompressibleType(header.Get("Content-Type"), w.types)
+}
+
+// finish 在请求处理完成后写出剩余的缓存数据并关闭编码器
+func (w *compressWriter) finish() {
+	if !w.decided {
+		// 处理函数没有写出任何内容，交给Gin按原来的方式处理
+		if !w.headerNow && len(w.buf) == 0 {
+			return
+		}
+		if err := w.decide(); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
+
+// newEncoder 创建指定编码的压缩器，创建失败时返回nil，响应将不压缩
+func newEncoder(encoding string, w io.Writer) io.WriteCloser {
+	switch encoding {
+	case encodingBrotli:
+		// 实时压缩使用较低的压缩级别，兼顾速度和压缩率
+		return brotli.NewWriterLevel(w, 4)
+	case encodingZstd:
+		encoder, err := zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil
+		}
+		return encoder
+	default:
+		return gzip.NewWriter(w)
+	}
+}
+
+// isCompressibleType 判断Content-Type是否在允许压缩的列表中
+func isCompressibleType(contentType string, types []string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range types {
+		if strings.HasSuffix(t, "/") {
+			if strings.HasPrefix(mediaType, t) {
+				return true
+			}
+		} else if mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding 解析 Accept-Encoding 请求头，从 offered 中选出客户端最偏好的编码
+// q值相同时按 offered 的顺序（服务端偏好）选择，没有可接受的编码时返回空字符串
+//
+// 参数:
+//   - acceptEncoding: 请求头，例如: "gzip, deflate, br;q=0.9"
+//   - offered: 服务端支持的编码，按偏好排列
+func negotiateEncoding(acceptEncoding string, offered []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		qualities[name] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range offered {
+		q, ok := qualities[encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// servePrecompressed 如果文件旁边存在客户端可接受的预压缩版本（.br、.zst、.gz），直接提供该版本
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - fullPath: 原始文件的完整路径，例如: "/home/user/files/app.js"
+//
+// 返回值:
+//   - bool: 是否已经提供了预压缩文件
+func (fs *FileServer) servePrecompressed(c *gin.Context, fullPath string) bool {
+	acceptEncoding := c.GetHeader("Accept-Encoding")
+	if acceptEncoding == "" {
+		return false
+	}
+
+	// 依次尝试客户端可接受的编码
+	offered := append([]string(nil), supportedEncodings...)
+	for len(offered) > 0 {
+		encoding := negotiateEncoding(acceptEncoding, offered)
+		if encoding == "" {
+			return false
+		}
+		if fs.serveCompressedSibling(c, fullPath, encoding) {
+			return true
+		}
+		offered = removeString(offered, encoding)
+	}
+	return false
+}
+
+// serveCompressedSibling 提供指定编码的预压缩文件，文件不存在、被排除或越出根目录时返回false
+func (fs *FileServer) serveCompressedSibling(c *gin.Context, fullPath, encoding string) bool {
+	siblingPath := fullPath + precompressedExtensions[encoding]
+	if fs.isExcluded(siblingPath, false) {
+		return false
+	}
+	if err := fs.checkRealPath(siblingPath); err != nil {
+		return false
+	}
+
+	file, err := os.Open(siblingPath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+
+	// Content-Type 使用原始文件的类型，而不是 .br/.gz 的类型
+	contentType := mime.TypeByExtension(filepath.Ext(fullPath))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Encoding", encoding)
+	c.Header("Vary", "Accept-Encoding")
+	http.ServeContent(c.Writer, c.Request, filepath.Base(fullPath), info.ModTime(), file)
+	return true
+}
+
+// removeString 返回删除了指定元素的新切片
+func removeString(list []string, s string) []string {
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// newCompressionRouter 创建启用了压缩的测试路由，并准备一个较大的文本文件
+func newCompressionRouter(t *testing.T) (*gin.Engine, string, func()) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	srv.config.EnableCompression = true
+	srv.config.EnableDirListing = true
+
+	bigText := strings.Repeat("servergo compresses large text files\n", 200)
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "big.log"), []byte(bigText), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(srv.compressionMiddleware())
+	router.NoRoute(srv.handleRequest)
+	return router, tempDir, cleanup
+}
+
+// TestNegotiateEncoding 测试 Accept-Encoding 协商
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		expected       string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"gzip, br;q=0.5", "gzip"},
+		{"zstd, gzip", "zstd"},
+		{"br;q=0, gzip;q=0", ""},
+		{"identity", ""},
+		{"*", "br"},
+		{"*;q=0.1, gzip", "gzip"},
+		{"GZIP", "gzip"},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.acceptEncoding, supportedEncodings); got != tt.expected {
+			t.Errorf("negotiateEncoding(%q) = %q, 期望 %q", tt.acceptEncoding, got, tt.expected)
+		}
+	}
+}
+
+// TestCompressionMiddleware 测试不同编码和不同响应的压缩行为
+func TestCompressionMiddleware(t *testing.T) {
+	router, tempDir, cleanup := newCompressionRouter(t)
+	defer cleanup()
+
+	original, _ := ioutil.ReadFile(filepath.Join(tempDir, "big.log"))
+
+	tests := []struct {
+		name             string
+		path             string
+		acceptEncoding   string
+		headers          map[string]string
+		expectedEncoding string
+		expectedCode     int
+	}{
+		{name: "gzip压缩大文件", path: "/big.log", acceptEncoding: "gzip", expectedEncoding: "gzip", expectedCode: http.StatusOK},
+		{name: "brotli压缩大文件", path: "/big.log", acceptEncoding: "gzip, br", expectedEncoding: "br", expectedCode: http.StatusOK},
+		{name: "zstd压缩大文件", path: "/big.log", acceptEncoding: "zstd", expectedEncoding: "zstd", expectedCode: http.StatusOK},
+		{name: "客户端不支持压缩", path: "/big.log", acceptEncoding: "", expectedEncoding: "", expectedCode: http.StatusOK},
+		{name: "小文件不压缩", path: "/test.txt", acceptEncoding: "gzip", expectedEncoding: "", expectedCode: http.StatusOK},
+		{name: "范围请求不压缩", path: "/big.log", acceptEncoding: "gzip", headers: map[string]string{"Range": "bytes=0-9"}, expectedEncoding: "", expectedCode: http.StatusPartialContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			if encoding := w.Header().Get("Content-Encoding"); encoding != tt.expectedEncoding {
+				t.Fatalf("Content-Encoding = %q, 期望 %q", encoding, tt.expectedEncoding)
+			}
+			if tt.expectedEncoding == "" {
+				return
+			}
+
+			if w.Header().Get("Content-Length") != "" {
+				t.Errorf("压缩后的响应不应该包含原始的Content-Length")
+			}
+			if body := decompress(t, tt.expectedEncoding, w.Body.Bytes()); !bytes.Equal(body, original) {
+				t.Errorf("解压后的内容与原始文件不一致")
+			}
+		})
+	}
+}
+
+// TestCompressionTypes 测试只压缩允许列表中的MIME类型
+func TestCompressionTypes(t *testing.T) {
+	router, tempDir, cleanup := newCompressionRouter(t)
+	defer cleanup()
+
+	// PNG图片本身已经压缩过，不应该再压缩
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 4096)...)
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "image.png"), png, 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/image.png", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if encoding := w.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("图片不应该被压缩，Content-Encoding = %q", encoding)
+	}
+	if !bytes.Equal(w.Body.Bytes(), png) {
+		t.Errorf("未压缩的响应内容被修改")
+	}
+}
+
+// TestCompressionDirectoryListing 测试目录列表页面也会被压缩
+func TestCompressionDirectoryListing(t *testing.T) {
+	router, tempDir, cleanup := newCompressionRouter(t)
+	defer cleanup()
+
+	// 根目录中有index.html，使用子目录测试目录列表
+	bigName := strings.Repeat("long-file-name-", 10) + ".log"
+	for i := 0; i < 20; i++ {
+		name := filepath.Join(tempDir, "subdir", strconv.Itoa(i)+bigName)
+		if err := ioutil.WriteFile(name, []byte("x"), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "/subdir/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+	if encoding := w.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("Content-Encoding = %q, 期望 gzip", encoding)
+	}
+	if body := decompress(t, "gzip", w.Body.Bytes()); !bytes.Contains(body, []byte("subfile.txt")) {
+		t.Errorf("解压后的目录列表中没有 subfile.txt")
+	}
+}
+
+// TestPrecompressedSibling 测试优先提供预压缩文件
+func TestPrecompressedSibling(t *testing.T) {
+	router, tempDir, cleanup := newCompressionRouter(t)
+	defer cleanup()
+
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "app.js"), []byte("console.log('plain')"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "app.js.br"), []byte("brotli bytes"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tempDir, "app.js.gz"), []byte("gzip bytes"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	tests := []struct {
+		acceptEncoding   string
+		expectedEncoding string
+		expectedBody     string
+	}{
+		{"br, gzip", "br", "brotli bytes"},
+		{"gzip", "gzip", "gzip bytes"},
+		{"zstd, gzip;q=0.5", "gzip", "gzip bytes"},
+		{"", "", "console.log('plain')"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/app.js", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+			}
+			if encoding := w.Header().Get("Content-Encoding"); encoding != tt.expectedEncoding {
+				t.Errorf("Content-Encoding = %q, 期望 %q", encoding, tt.expectedEncoding)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.Contains(contentType, "javascript") {
+				t.Errorf("Content-Type = %q, 期望原始文件的类型", contentType)
+			}
+			if body := w.Body.String(); body != tt.expectedBody {
+				t.Errorf("响应内容 = %q, 期望 %q", body, tt.expectedBody)
+			}
+		})
+	}
+}
+
+// TestPrecompressedSiblingExcluded 测试被忽略规则排除的预压缩文件不会代替原始文件提供
+func TestPrecompressedSiblingExcluded(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.config.EnableCompression = true
+
+	files := map[string]string{
+		"app.js":       "console.log('plain')",
+		"app.js.br":    "brotli bytes",
+		ignoreFileName: "*.br\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+	if err := srv.loadIgnoreRules(); err != nil {
+		t.Fatalf("loadIgnoreRules() 错误: %v", err)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	req, _ := http.NewRequest(http.MethodGet, "/app.js", nil)
+	req.Header.Set("Accept-Encoding", "br")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+	if encoding := w.Header().Get("Content-Encoding"); encoding != "" {
+		t.Errorf("Content-Encoding = %q, 被排除的预压缩文件不应该提供", encoding)
+	}
+	if body := w.Body.String(); body != "console.log('plain')" {
+		t.Errorf("响应内容 = %q, 期望原始文件", body)
+	}
+}
+
+// decompress 按指定编码解压响应内容
+func decompress(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var reader io.Reader
+	switch encoding {
+	case "gzip":
+		gr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("创建gzip解压器失败: %v", err)
+		}
+		reader = gr
+	case "br":
+		reader = brotli.NewReader(bytes.NewReader(data))
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("创建zstd解压器失败: %v", err)
+		}
+		defer zr.Close()
+		reader = zr
+	}
+
+	body, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("解压失败: %v", err)
+	}
+	return body
+}
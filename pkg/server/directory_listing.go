@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
 
@@ -32,13 +31,18 @@ func (fs *FileServer) renderDirectoryListing(c *gin.Context, fullPath, reqPath s
 	// 读取目录内容
 	files, err := os.ReadDir(fullPath)
 	if err != nil {
-		c.String(http.StatusInternalServerError, i18n.Tf("http.500_dir_content", err))
+		fs.respondError(c, http.StatusInternalServerError, i18n.Tf("http.500_dir_content", err))
 		return
 	}
 
 	// 创建文件项列表
 	items := make([]dirlist.FileItem, 0, len(files))
 	for _, file := range files {
+		// 跳过隐藏文件和被忽略的文件
+		if fs.isExcluded(filepath.Join(fullPath, file.Name()), file.IsDir()) {
+			continue
+		}
+
 		info, err := file.Info()
 		if err != nil {
 			continue
@@ -70,17 +74,43 @@ func (fs *FileServer) renderDirectoryListing(c *gin.Context, fullPath, reqPath s
 			LastModified: info.ModTime().Format("2006-01-02 15:04:05"), // 格式化的修改时间
 			Path:         itemPath,                                     // 访问路径，例如: "/images/image.jpg"
 		})
+		if !file.IsDir() {
+			items[len(items)-1].ViewURL = fs.sourceViewURL(itemPath, file.Name(), sizeBytes)
+			items[len(items)-1].ThumbURL = thumbnailURL(itemPath, file.Name())
+		}
 	}
 
-	// 按照目录在前，文件在后的方式排序
-	sort.Slice(items, func(i, j int) bool {
-		// 如果一个是目录一个不是，目录在前
-		if items[i].IsDir != items[j].IsDir {
-			return items[i].IsDir
+	// 挂载在该目录下的其他挂载点显示为虚拟目录，与真实目录同名时只显示一次
+	if fs.parent != nil {
+		existing := make(map[string]bool, len(items))
+		for _, item := range items {
+			existing[item.Name] = true
+		}
+		for _, item := range fs.virtualMountItems(reqPath) {
+			if !existing[item.Name] {
+				items = append(items, item)
+			}
 		}
-		// 否则按名称字母顺序排序
-		return items[i].Name < items[j].Name
-	})
+	}
+
+	fs.renderListing(c, reqPath, items, fs.loadReadme(c, fullPath, files))
+}
+
+// renderListing 对文件项进行筛选、排序和分页，并使用当前主题渲染目录列表
+// 真实目录和只包含挂载点的虚拟目录共用此方法
+// 查询参数 sort、order、filter、page 和 per_page 对所有主题（包括json和table）都有效
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - reqPath: 请求的路径，例如: "/images"
+//   - items: 目录中的文件项
+//   - readme: 显示在文件列表下方的README文档，没有时为nil
+func (fs *FileServer) renderListing(c *gin.Context, reqPath string, items []dirlist.FileItem, readme *dirlist.DocumentInfo) {
+	// 不显示当前用户没有读取权限的文件和目录
+	items = fs.readableItems(c, items)
+
+	// 目录在前，文件在后，按请求的方式排序，只保留当前页
+	items, listing := parseListingOptions(c).apply(c, items)
 
 	// 计算上级目录路径
 	var parentDir string
@@ -99,20 +129,48 @@ func (fs *FileServer) renderDirectoryListing(c *gin.Context, fullPath, reqPath s
 
 	// 准备模板数据
 	data := dirlist.TemplateData{
-		DirPath:     reqPath,                                  // 当前目录路径，例如: "/images"
-		Items:       items,                                    // 文件列表
-		ParentDir:   parentDir,                                // 父目录路径，例如: "/"
-		CurrentTime: time.Now().Format("2006-01-02 15:04:05"), // 当前时间，用于显示在页面
+		DirPath:       reqPath,                                  // 当前目录路径，例如: "/images"
+		Items:         items,                                    // 文件列表
+		ParentDir:     parentDir,                                // 父目录路径，例如: "/"
+		CurrentTime:   time.Now().Format("2006-01-02 15:04:05"), // 当前时间，用于显示在页面
+		UploadEnabled: fs.config.EnableUpload,                   // 是否显示上传表单
+		Readme:        readme,                                   // 目录中的README文档
+		Gallery:       c.Query("view") == "gallery",             // 是否以画廊方式显示
+		Listing:       listing,                                  // 排序、筛选和分页信息
+	}
+	for _, item := range items {
+		if item.ThumbURL != "" {
+			data.HasImages = true
+			break
+		}
 	}
 
+	fs.renderPage(c, data)
+}
+
+// renderPage 使用当前主题渲染页面并返回200响应
+// 目录列表和Markdown文档页面共用此方法
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - data: 模板数据
+func (fs *FileServer) renderPage(c *gin.Context, data dirlist.TemplateData) {
+	data.FullTextEnabled = fs.fullTextIndexer() != nil
+
 	// 渲染模板
 	html, err := fs.dirTemplate.Render(data)
 	if err != nil {
-		c.String(http.StatusInternalServerError, i18n.Tf("http.500_template", err))
+		fs.respondError(c, http.StatusInternalServerError, i18n.Tf("http.500_template", err))
 		return
 	}
 
+	// 启用实时刷新时，HTML主题的页面也注入刷新脚本，目录中的文件变化时自动刷新
+	contentType := fs.dirTemplate.GetContentType()
+	if fs.config.LiveReload && strings.HasPrefix(contentType, "text/html") {
+		html = string(injectLiveReloadScript([]byte(html)))
+	}
+
 	// 设置正确的 Content-Type
-	c.Header("Content-Type", fs.dirTemplate.GetContentType())
+	c.Header("Content-Type", contentType)
 	c.String(http.StatusOK, html)
 }
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/dirlist"
+)
+
+// customErrorPages 可以由用户在服务根目录中自定义页面的状态码，例如根目录下的 404.html
+var customErrorPages = map[int]string{
+	http.StatusForbidden: "403.html",
+	http.StatusNotFound:  "404.html",
+}
+
+// respondError 返回错误响应
+// 错误页面和目录列表使用同一个主题渲染，使错误页面与目录列表的风格保持一致
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - code: HTTP状态码，例如: 404
+//   - message: 详细的错误信息，例如: "404 Not Found: /a.txt"
+//
+// 功能:
+//  1. 客户端偏好 application/json 或者主题为json时，返回JSON格式的错误对象
+//  2. 403/404 时，如果服务根目录中存在 403.html/404.html，返回用户自定义的页面
+//  3. 否则使用当前主题渲染错误页面，渲染失败时返回纯文本
+func (fs *FileServer) respondError(c *gin.Context, code int, message string) {
+	data := dirlist.TemplateData{
+		DirPath:     c.Request.URL.Path,
+		CurrentTime: time.Now().Format("2006-01-02 15:04:05"),
+		Error: &dirlist.ErrorInfo{
+			Code:    code,
+			Title:   http.StatusText(code),
+			Message: message,
+		},
+	}
+
+	if fs.dirTemplate == nil {
+		c.String(code, message)
+		return
+	}
+
+	if fs.dirTemplate.GetTheme() == dirlist.JsonTheme || prefersJSON(c.GetHeader("Accept")) {
+		if body, err := dirlist.RenderErrorJSON(data); err == nil {
+			c.Data(code, "application/json", []byte(body))
+			return
+		}
+	}
+
+	if fs.serveCustomErrorPage(c, code) {
+		return
+	}
+
+	body, err := fs.dirTemplate.Render(data)
+	if err != nil {
+		c.String(code, message)
+		return
+	}
+	c.Data(code, fs.dirTemplate.GetContentType(), []byte(body))
+}
+
+// serveCustomErrorPage 返回服务根目录中用户自定义的错误页面，页面不存在时返回false
+func (fs *FileServer) serveCustomErrorPage(c *gin.Context, code int) bool {
+	name, ok := customErrorPages[code]
+	// 配置了多个挂载点时，不属于任何挂载点的路径没有对应的服务目录
+	if !ok || fs.absDir == "" {
+		return false
+	}
+
+	pagePath := filepath.Join(fs.absDir, name)
+	if err := fs.checkRealPath(pagePath); err != nil {
+		return false
+	}
+	content, err := os.ReadFile(pagePath)
+	if err != nil {
+		return false
+	}
+
+	c.Data(code, "text/html; charset=utf-8", content)
+	return true
+}
+
+// prefersJSON 判断 Accept 请求头是否偏好JSON而不是HTML
+// 只有明确列出 application/json（或 application/*）且其q值高于HTML时才返回true，
+// 浏览器发送的 "*/*" 不算偏好JSON
+//
+// 参数:
+//   - accept: Accept请求头，例如: "application/json, text/plain;q=0.5"
+func prefersJSON(accept string) bool {
+	jsonQ, htmlQ := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		switch mediaType {
+		case "application/json", "application/*":
+			jsonQ = max(jsonQ, q)
+		case "text/html", "text/*":
+			htmlQ = max(htmlQ, q)
+		}
+	}
+	return jsonQ > 0 && jsonQ > htmlQ
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/dirlist"
+)
+
+// TestPrefersJSON 测试 Accept 请求头的JSON偏好判断
+func TestPrefersJSON(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", true},
+		{"application/json, text/plain, */*", true},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", false},
+		{"text/html;q=0.5, application/json", true},
+		{"application/json;q=0.5, text/html", false},
+		{"application/*", true},
+		{"application/json;q=0", false},
+	}
+
+	for _, tt := range tests {
+		if got := prefersJSON(tt.accept); got != tt.expected {
+			t.Errorf("prefersJSON(%q) = %v, 期望 %v", tt.accept, got, tt.expected)
+		}
+	}
+}
+
+// TestErrorPages 测试主题错误页面、自定义错误页面和JSON错误对象
+func TestErrorPages(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	tests := []struct {
+		name                string
+		path                string
+		accept              string
+		dirListing          bool
+		customPage          string // 在根目录创建的自定义错误页面
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "404使用主题渲染",
+			path:                "/notexist.txt",
+			dirListing:          true,
+			expectedCode:        http.StatusNotFound,
+			expectedContentType: "text/html",
+			expectedBody:        "sg-error",
+		},
+		{
+			name:                "403使用主题渲染",
+			path:                "/subdir/",
+			dirListing:          false,
+			expectedCode:        http.StatusForbidden,
+			expectedContentType: "text/html",
+			expectedBody:        "sg-error",
+		},
+		{
+			name:                "客户端偏好JSON",
+			path:                "/notexist.txt",
+			accept:              "application/json",
+			dirListing:          true,
+			expectedCode:        http.StatusNotFound,
+			expectedContentType: "application/json",
+			expectedBody:        `"code": 404`,
+		},
+		{
+			name:                "自定义404页面",
+			path:                "/notexist.txt",
+			dirListing:          true,
+			customPage:          "404.html",
+			expectedCode:        http.StatusNotFound,
+			expectedContentType: "text/html",
+			expectedBody:        "custom 404.html",
+		},
+		{
+			name:                "自定义403页面",
+			path:                "/subdir/",
+			dirListing:          false,
+			customPage:          "403.html",
+			expectedCode:        http.StatusForbidden,
+			expectedContentType: "text/html",
+			expectedBody:        "custom 403.html",
+		},
+		{
+			name:                "偏好JSON时不使用自定义页面",
+			path:                "/notexist.txt",
+			accept:              "application/json",
+			dirListing:          true,
+			customPage:          "404.html",
+			expectedCode:        http.StatusNotFound,
+			expectedContentType: "application/json",
+			expectedBody:        `"code": 404`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.config.EnableDirListing = tt.dirListing
+			if tt.customPage != "" {
+				pagePath := filepath.Join(tempDir, tt.customPage)
+				if err := ioutil.WriteFile(pagePath, []byte("custom "+tt.customPage), 0644); err != nil {
+					t.Fatalf("创建自定义错误页面失败: %v", err)
+				}
+				defer os.Remove(pagePath)
+			}
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.expectedContentType) {
+				t.Errorf("Content-Type = %q, 期望 %q", contentType, tt.expectedContentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("响应内容 = %q, 期望包含 %q", w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+}
+
+// TestErrorPagesJSONTheme 测试json主题下错误响应为JSON对象
+func TestErrorPagesJSONTheme(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	jsonTemplate, err := dirlist.NewDirListTemplate(dirlist.JsonTheme)
+	if err != nil {
+		t.Fatalf("创建json主题失败: %v", err)
+	}
+	srv.dirTemplate = jsonTemplate
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	req, _ := http.NewRequest(http.MethodGet, "/notexist.txt", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusNotFound)
+	}
+
+	var result struct {
+		Error struct {
+			Code  int    `json:"code"`
+			Title string `json:"title"`
+			Path  string `json:"path"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("解析JSON失败: %v, 响应: %s", err, w.Body.String())
+	}
+	if result.Error.Code != http.StatusNotFound || result.Error.Title != "Not Found" || result.Error.Path != "/notexist.txt" {
+		t.Errorf("错误对象 = %+v", result.Error)
+	}
+}
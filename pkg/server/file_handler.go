@@ -6,11 +6,72 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/CC11001100/servergo/pkg/auth"
 	"github.com/CC11001100/servergo/pkg/i18n"
 	"github.com/CC11001100/servergo/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// handleRequest 根据请求方法分发所有未匹配路由的请求
+// 此方法为内部方法，用作Gin的NoRoute处理函数
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//
+// 功能:
+//  1. GET/HEAD 请求交给 handleFileRequest 处理
+//  2. 启用WebDAV时，PROPFIND、MKCOL、COPY、MOVE、LOCK等方法交给WebDAV处理器
+//  3. 启用上传时，POST(multipart表单) 和 PUT 请求交给上传处理函数
+//  4. 其他请求返回405
+func (fs *FileServer) handleRequest(c *gin.Context) {
+	// 配置了多个挂载点时，先找到负责该路径的挂载点
+	if len(fs.mounts) > 0 {
+		fs.handleMountRequest(c)
+		return
+	}
+
+	// 启用WebDAV时，WebDAV方法交给WebDAV处理器
+	if fs.davHandler != nil && webdavMethods[c.Request.Method] {
+		fs.handleWebDAV(c)
+		return
+	}
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead:
+		fs.handleFileRequest(c)
+	case http.MethodPost:
+		if fs.config.EnableUpload {
+			fs.handleMultipartUpload(c)
+			return
+		}
+		fs.methodNotAllowed(c)
+	case http.MethodPut:
+		if fs.config.EnableUpload {
+			fs.handlePutUpload(c)
+			return
+		}
+		fs.methodNotAllowed(c)
+	default:
+		fs.methodNotAllowed(c)
+	}
+}
+
+// methodNotAllowed 返回405响应，并在Allow头中列出当前支持的方法
+func (fs *FileServer) methodNotAllowed(c *gin.Context) {
+	allow := "GET, HEAD"
+	if fs.config.EnableUpload {
+		allow += ", POST, PUT"
+	}
+	if fs.davHandler != nil {
+		allow += ", OPTIONS, PROPFIND, PROPPATCH, MKCOL, COPY, MOVE, LOCK, UNLOCK, DELETE"
+		if !fs.config.EnableUpload {
+			allow += ", PUT"
+		}
+	}
+	c.Header("Allow", allow)
+	fs.respondError(c, http.StatusMethodNotAllowed, i18n.T("http.405"))
+}
+
 // handleFileRequest 处理文件请求
 // 此方法为内部方法，用作Gin的处理函数
 //
@@ -20,8 +81,12 @@ import (
 // 功能:
 //  1. 处理静态文件请求
 //  2. 如果请求的是目录且启用了目录列表，则显示目录内容
-//  3. 如果请求的是文件，则直接提供文件下载
-//  4. 处理各种错误情况，如文件不存在或无权限
+//  3. 如果请求的是目录且带有 ?download=zip|tar.gz 参数，则打包下载整个目录
+//  4. 如果请求带有 ?thumb=1 参数，则提供图片的缩略图
+//  5. 如果请求带有 ?view=1 参数，则显示带语法高亮的源码
+//  6. 如果浏览器请求的是Markdown文件，则渲染为HTML页面
+//  7. 如果请求的是文件，则直接提供文件下载
+//  8. 处理各种错误情况，如文件不存在或无权限
 func (fs *FileServer) handleFileRequest(c *gin.Context) {
 	// 获取请求路径
 	reqPath := c.Request.URL.Path
@@ -42,89 +107,120 @@ func (fs *FileServer) handleFileRequest(c *gin.Context) {
 
 	logger.Info("[DEBUG] After URL decode reqPath: %s", reqPath)
 
-	// 确保路径不会超出根目录
-	// 使用 filepath.Clean 清理路径，移除多余的 . 和 .. 元素
-	cleanPath := filepath.Clean(reqPath)
-	logger.Info("[DEBUG] Clean path: %s", cleanPath)
-	if !strings.HasPrefix(cleanPath, "/") {
-		cleanPath = "/" + cleanPath
+	// 没有读取权限的路径当作不存在
+	if !fs.authorize(c, reqPath, auth.PermRead) {
+		return
 	}
 
-	// 构建完整的文件路径
-	fullPath := filepath.Join(fs.absDir, cleanPath)
-	logger.Info("[DEBUG] Full path: %s", fullPath)
-
-	// 安全检查：确保解析后的路径仍在根目录内
-	// 使用 filepath.Rel 检查完整路径相对于根目录的位置
-	relPath, err := filepath.Rel(fs.absDir, fullPath)
-	logger.Info("[DEBUG] Relative path: %s, error: %v", relPath, err)
-	if err != nil || strings.HasPrefix(relPath, "..") || strings.Contains(relPath, "/..") {
-		logger.Info("[DEBUG] Path traversal attack detected! Relative path: %s", relPath)
-		c.String(http.StatusForbidden, i18n.T("http.403"))
+	// 确保路径不会超出根目录
+	fullPath, err := fs.resolvePath(reqPath)
+	if err != nil {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
 		return
 	}
-
-	// 主要安全检查已通过，移除有问题的第二个检查
-	logger.Info("[DEBUG] Path security check passed for: %s", relPath)
+	logger.Info("[DEBUG] Full path: %s", fullPath)
 
 	// 获取文件状态
-	fileInfo, err := os.Lstat(fullPath)
-	if err != nil {
+	if _, err := os.Lstat(fullPath); err != nil {
 		if os.IsNotExist(err) {
-			c.String(http.StatusNotFound, i18n.T("http.404"), reqPath)
+			// 单页应用模式下，不存在的路径交给前端路由处理
+			if fs.serveSPAFallback(c, reqPath) {
+				return
+			}
+			fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
 			return
 		}
-		c.String(http.StatusInternalServerError, i18n.T("http.500"))
+		fs.respondError(c, http.StatusInternalServerError, i18n.T("http.500"))
 		return
 	}
 
 	// 安全检查：防止符号链接路径遍历攻击
-	if fileInfo.Mode()&os.ModeSymlink != 0 {
-		// 解析符号链接的真实路径
-		realPath, err := filepath.EvalSymlinks(fullPath)
-		if err != nil {
-			logger.Info("[DEBUG] Failed to resolve symlink: %s, error: %v", fullPath, err)
-			c.String(http.StatusForbidden, i18n.T("http.403"))
-			return
-		}
-		logger.Info("[DEBUG] Symlink detected. Real path: %s", realPath)
+	// 解析路径上的所有符号链接，确认真实路径仍在根目录内
+	if err := fs.checkRealPath(fullPath); err != nil {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return
+	}
 
-		// 检查符号链接指向的真实路径是否在根目录内
-		realRelPath, err := filepath.Rel(fs.absDir, realPath)
-		if err != nil || strings.HasPrefix(realRelPath, "..") || strings.Contains(realRelPath, "/..") {
-			logger.Info("[DEBUG] Symlink path traversal detected! Real relative path: %s", realRelPath)
-			c.String(http.StatusForbidden, i18n.T("http.403"))
+	// 重新获取文件状态（如果是符号链接，这次会获取目标文件的状态）
+	fileInfo, err := os.Stat(fullPath)
+	if err != nil {
+		// 文件不存在（例如失效的符号链接），返回404
+		if fs.serveSPAFallback(c, reqPath) {
 			return
 		}
-		logger.Info("[DEBUG] Symlink security check passed. Real relative path: %s", realRelPath)
+		fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
+		return
 	}
 
-	// 重新获取文件状态（如果是符号链接，这次会获取目标文件的状态）
-	fileInfo, err = os.Stat(fullPath)
-	if err != nil {
-		// 文件不存在，返回404
-		c.String(http.StatusNotFound, i18n.Tf("http.404", reqPath))
+	// 隐藏文件和被忽略的文件当作不存在
+	if fs.isExcluded(fullPath, fileInfo.IsDir()) {
+		fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
 		return
 	}
 
 	// 如果是目录，检查是否启用了目录列表功能
 	if fileInfo.IsDir() {
+		// 请求打包下载整个目录，例如: ?download=zip，打包会列出目录中的文件，需要列表权限
+		if format := c.Query("download"); format != "" {
+			if !fs.authorize(c, reqPath, auth.PermList) {
+				return
+			}
+			fs.handleArchiveDownload(c, fullPath, format)
+			return
+		}
+
 		// 检查该目录下是否有index.html文件
 		indexPath := filepath.Join(fullPath, "index.html")
-		if _, err := os.Stat(indexPath); err == nil {
+		if _, err := os.Stat(indexPath); err == nil && !fs.isExcluded(indexPath, false) {
 			// 如果存在index.html，则提供该文件
+			if fs.config.LiveReload {
+				fs.serveHTMLWithLiveReload(c, indexPath)
+				return
+			}
 			c.File(indexPath)
 			return
 		}
 
 		// 如果启用了目录列表功能，则显示目录内容
 		if fs.config.EnableDirListing {
+			if !fs.authorize(c, reqPath, auth.PermList) {
+				return
+			}
 			fs.renderDirectoryListing(c, fullPath, reqPath)
 			return
 		}
 
 		// 未启用目录列表功能，返回403禁止访问
-		c.String(http.StatusForbidden, i18n.T("http.403"))
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return
+	}
+
+	// ?thumb=1 获取图片的缩略图
+	if fs.shouldServeThumbnail(c, fileInfo) {
+		fs.serveThumbnail(c, fullPath, fileInfo)
+		return
+	}
+
+	// ?view=1 在页面中查看带语法高亮的源码
+	if fs.shouldViewSource(c, fileInfo) {
+		fs.renderSourceFile(c, fullPath, reqPath)
+		return
+	}
+
+	// 浏览器请求Markdown文档时，使用当前主题渲染为HTML页面，?raw=1 返回原始文本
+	if fs.shouldRenderMarkdown(c, fileInfo) {
+		fs.renderMarkdownFile(c, fullPath, reqPath)
+		return
+	}
+
+	// 启用压缩时，如果存在预压缩的 .br/.zst/.gz 文件，直接提供该文件
+	if fs.config.EnableCompression && fs.servePrecompressed(c, fullPath) {
+		return
+	}
+
+	// 启用实时刷新时，HTML页面需要注入刷新脚本
+	if fs.config.LiveReload && isHTMLFile(fullPath) {
+		fs.serveHTMLWithLiveReload(c, fullPath)
 		return
 	}
 
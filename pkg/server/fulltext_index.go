@@ -0,0 +1,268 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/fulltext"
+	"github.com/CC11001100/servergo/pkg/highlight"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// fullTextDebounce 合并同一文件短时间内的多次变化，例如持续追加写入的日志
+const fullTextDebounce = time.Second
+
+// fullTextMaxResults 全文搜索结果数量的上限，超过时只返回前面的结果
+const fullTextMaxResults = 500
+
+// contentIndexer 在后台为服务目录中的文本文件建立全文索引，并监视文件变化保持索引更新
+type contentIndexer struct {
+	roots []watchRoot
+	index *fulltext.Index
+	ready atomic.Bool // 初次建立索引完成后为true
+
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	done    chan struct{} // 关闭后停止建立索引和处理文件变化
+	closed  bool
+}
+
+// newContentIndexer 创建全文索引器，配置了挂载点时为所有挂载点的目录建立索引
+func (fs *FileServer) newContentIndexer() *contentIndexer {
+	return &contentIndexer{
+		roots: fs.watchRoots(),
+		index: fulltext.New(fulltext.Options{
+			MaxFileSize: fs.config.FullTextMaxFileSize,
+			Extensions:  fs.config.FullTextExtensions,
+		}),
+		done: make(chan struct{}),
+	}
+}
+
+// start 开始监视文件变化，并在后台建立索引
+// 先添加监视再建立索引，建立索引期间修改的文件不会被遗漏
+func (ci *contentIndexer) start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	ci.mu.Lock()
+	if ci.closed {
+		ci.mu.Unlock()
+		watcher.Close()
+		return nil
+	}
+	ci.watcher = watcher
+	ci.mu.Unlock()
+
+	for _, root := range ci.roots {
+		watchTree(watcher, root.server, root.server.absDir)
+	}
+
+	go ci.run()
+	go ci.build()
+	return nil
+}
+
+// build 为所有服务目录建立初始索引
+func (ci *contentIndexer) build() {
+	start := time.Now()
+	for _, root := range ci.roots {
+		ci.indexTree(root.server, root.server.absDir)
+	}
+	ci.ready.Store(true)
+	logger.Info(i18n.Tf("server.fulltext_ready", ci.index.Len(), time.Since(start).Round(time.Millisecond)))
+}
+
+// indexTree 索引目录及其子目录中的文件，隐藏和被忽略的文件不索引
+func (ci *contentIndexer) indexTree(fs *FileServer, dir string) {
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if ci.isClosed() {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		if fs.isExcluded(p, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			ci.add(fs, p)
+		}
+		return nil
+	})
+}
+
+// add 索引一个文件，指向服务目录之外的符号链接不索引
+func (ci *contentIndexer) add(fs *FileServer, p string) {
+	if fs.checkRealPath(p) != nil {
+		ci.index.Remove(p)
+		return
+	}
+	if err := ci.index.Add(p); err != nil {
+		logger.Debug("fulltext: index %s: %v", p, err)
+	}
+}
+
+// run 处理文件变化事件，合并短时间内的变化后更新索引
+func (ci *contentIndexer) run() {
+	pending := map[string]bool{}
+	timer := time.NewTimer(fullTextDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-ci.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			pending[event.Name] = true
+			timer.Reset(fullTextDebounce)
+		case err, ok := <-ci.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warning(i18n.Tf("server.fulltext_error", err))
+		case <-timer.C:
+			for name := range pending {
+				ci.update(name)
+			}
+			pending = map[string]bool{}
+		case <-ci.done:
+			return
+		}
+	}
+}
+
+// update 根据文件的当前状态更新索引
+// 文件或目录已被删除（或重命名）时移除其下的所有文件，新建的目录会被加入监视并索引
+func (ci *contentIndexer) update(name string) {
+	root, _, ok := findWatchRoot(ci.roots, name)
+	if !ok {
+		return
+	}
+
+	info, err := os.Stat(name)
+	if err != nil {
+		ci.index.Remove(name)
+		return
+	}
+	if root.server.isExcluded(name, info.IsDir()) {
+		ci.index.Remove(name)
+		return
+	}
+	if info.IsDir() {
+		watchTree(ci.watcher, root.server, name)
+		ci.indexTree(root.server, name)
+		return
+	}
+	ci.add(root.server, name)
+}
+
+// fullTextIndexer 返回全文索引器，挂载点使用父服务器的索引器，没有启用全文索引时返回nil
+func (fs *FileServer) fullTextIndexer() *contentIndexer {
+	if fs.parent != nil {
+		return fs.parent.fullText
+	}
+	return fs.fullText
+}
+
+// search 在搜索目录中搜索包含搜索词的行
+//
+// 参数:
+//   - ctx: 请求的上下文，客户端断开连接时停止搜索
+//   - roots: 搜索目录
+//   - base: 搜索的URL路径加上结尾的 "/"，结果的文件名是相对于该路径的路径
+//   - query: 搜索词
+//   - visible: 判断URL路径对应的文件是否可以出现在结果中，在计算结果数量之前过滤，例如检查当前用户的权限
+//
+// 返回值:
+//   - []dirlist.SearchMatch: 匹配的行
+//   - bool: 结果数量达到上限时返回true
+func (ci *contentIndexer) search(ctx context.Context, roots []searchRoot, base, query string, visible func(itemPath string) bool) ([]dirlist.SearchMatch, bool) {
+	matches := make([]dirlist.SearchMatch, 0)
+	for _, root := range roots {
+		found, truncated := ci.index.Search(ctx, query, root.dir, func(fullPath string) bool {
+			itemPath, ok := root.itemPath(fullPath)
+			return ok && visible(itemPath)
+		}, fullTextMaxResults-len(matches))
+		for _, m := range found {
+			itemPath, ok := root.itemPath(m.Path)
+			if !ok {
+				continue
+			}
+			matches = append(matches, dirlist.SearchMatch{
+				Name:    strings.TrimPrefix(itemPath, base),
+				Path:    itemPath,
+				URL:     root.server.matchURL(itemPath, m.Path, m.Line),
+				Line:    m.Line,
+				Snippet: m.Snippet,
+			})
+		}
+		if truncated || len(matches) >= fullTextMaxResults {
+			return matches, true
+		}
+	}
+	return matches, false
+}
+
+// isClosed 判断索引器是否已经关闭
+func (ci *contentIndexer) isClosed() bool {
+	select {
+	case <-ci.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close 停止监视文件变化和建立索引
+func (ci *contentIndexer) close() {
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+
+	if ci.closed {
+		return
+	}
+	ci.closed = true
+	close(ci.done)
+	if ci.watcher != nil {
+		ci.watcher.Close()
+	}
+}
+
+// matchURL 返回打开文件并定位到某一行的地址
+// 被索引的都是文本文件，HTML主题下不太大的文件在源码页面中打开并高亮该行，否则直接打开文件
+//
+// 参数:
+//   - itemPath: 文件的访问路径，例如: "/logs/app.log"
+//   - fullPath: 文件的完整路径
+//   - line: 行号，从1开始
+func (fs *FileServer) matchURL(itemPath, fullPath string, line int) string {
+	// 文件名可能包含 #、? 或 %，需要转义路径
+	u := &url.URL{Path: itemPath}
+	info, err := os.Stat(fullPath)
+	if err == nil && fs.htmlTheme() && info.Size() <= sourceMaxSize {
+		u.RawQuery = "view=1"
+		u.Fragment = highlight.LineIDPrefix + strconv.Itoa(line)
+	}
+	return u.String()
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+)
+
+// newFullTextRouter 创建启用了全文索引的测试路由，等待初次建立索引完成后返回
+func newFullTextRouter(t *testing.T) (*FileServer, *gin.Engine, string) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	t.Cleanup(cleanup)
+
+	files := map[string]string{
+		"logs/app.log":     "started\nERROR dial tcp: connection refused\n",
+		"logs/archive.zip": "connection refused",
+		".secret/app.log":  "connection refused",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+
+	srv.config.FullTextIndex = true
+	srv.fullText = srv.newContentIndexer()
+	if err := srv.fullText.start(); err != nil {
+		t.Fatalf("启动全文索引失败: %v", err)
+	}
+	t.Cleanup(srv.fullText.close)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !srv.fullText.ready.Load() {
+		if time.Now().After(deadline) {
+			t.Fatalf("建立索引超时")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	router := gin.New()
+	router.GET(searchPath, srv.handleSearch)
+	router.NoRoute(srv.handleRequest)
+	return srv, router, tempDir
+}
+
+// TestFullTextSearch 测试按文件内容搜索
+func TestFullTextSearch(t *testing.T) {
+	_, router, _ := newFullTextRouter(t)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedBody []string
+		excludedBody []string
+	}{
+		{
+			name:         "显示文件、行号和内容",
+			query:        "?type=content&q=Connection+Refused",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"logs/app.log:2", "ERROR dial tcp: connection refused", `href="/logs/app.log?view=1#L2"`, "1 行匹配"},
+			excludedBody: []string{".secret", "archive.zip"},
+		},
+		{
+			name:         "在子目录中搜索",
+			query:        "?type=content&q=refused&path=/logs",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{">app.log:2<"},
+		},
+		{
+			name:         "其他目录中没有结果",
+			query:        "?type=content&q=refused&path=/subdir",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"0 行匹配"},
+			excludedBody: []string{"app.log"},
+		},
+		{
+			name:         "搜索框显示内容搜索选项",
+			query:        "?q=app",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{`name="type" value="content"`},
+		},
+		{name: "缺少搜索词", query: "?type=content", expectedCode: http.StatusBadRequest},
+		{name: "搜索词太短", query: "?type=content&q=ok", expectedCode: http.StatusBadRequest, expectedBody: []string{"3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, searchPath+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("响应中应该包含 %q", s)
+				}
+			}
+			for _, s := range tt.excludedBody {
+				if strings.Contains(body, s) {
+					t.Errorf("响应中不应该包含 %q", s)
+				}
+			}
+		})
+	}
+}
+
+// fullTextResult 全文搜索的JSON响应
+type fullTextResult struct {
+	Type     string `json:"type"`
+	Count    int    `json:"count"`
+	Indexing bool   `json:"indexing"`
+	Results  []struct {
+		File    string `json:"file"`
+		Path    string `json:"path"`
+		Line    int    `json:"line"`
+		Snippet string `json:"snippet"`
+	} `json:"results"`
+}
+
+// searchContentJSON 以JSON格式执行全文搜索
+func searchContentJSON(t *testing.T, router *gin.Engine, query string) fullTextResult {
+	req, _ := http.NewRequest(http.MethodGet, searchPath+"?type=content&q="+query, nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var result fullTextResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("响应不是JSON: %v, 响应: %s", err, w.Body.String())
+	}
+	return result
+}
+
+// TestFullTextSearchJSON 测试JSON格式的全文搜索结果
+func TestFullTextSearchJSON(t *testing.T) {
+	_, router, _ := newFullTextRouter(t)
+
+	result := searchContentJSON(t, router, "refused")
+	if result.Type != "content" || result.Count != 1 || result.Indexing {
+		t.Fatalf("搜索信息 = %+v", result)
+	}
+	m := result.Results[0]
+	if m.File != "logs/app.log" || m.Path != "/logs/app.log" || m.Line != 2 || m.Snippet != "ERROR dial tcp: connection refused" {
+		t.Errorf("搜索结果 = %+v", m)
+	}
+}
+
+// TestFullTextSearchAuthorization 测试没有权限的文件在计算结果数量上限之前被过滤
+func TestFullTextSearchAuthorization(t *testing.T) {
+	srv, router, tempDir := newFullTextRouter(t)
+	authorizer, err := auth.ParsePolicy([]byte("default: [read, list]\npaths:\n  /private:\n    \"*\": []\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() 错误: %v", err)
+	}
+	srv.authorizer = authorizer
+
+	// 没有权限的文件中的匹配超过结果数量的上限，并且排在可以读取的文件前面
+	files := map[string]string{
+		"private/big.log": strings.Repeat("disk timeout\n", fullTextMaxResults+100),
+		"public.log":      "disk timeout\n",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+		srv.fullText.add(srv, fullPath)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, searchPath+"?type=content&q=timeout", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var result struct {
+		fullTextResult
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("响应不是JSON: %v, 响应: %s", err, w.Body.String())
+	}
+	if result.Count != 1 || result.Truncated || result.Results[0].File != "public.log" {
+		t.Errorf("搜索结果 = %+v, 期望只有 public.log 的1个结果", result)
+	}
+}
+
+// TestFullTextSearchURLEscaping 测试文件名包含 #、? 或 % 时结果的地址会转义路径
+func TestFullTextSearchURLEscaping(t *testing.T) {
+	srv, router, tempDir := newFullTextRouter(t)
+
+	fullPath := filepath.Join(tempDir, "logs", "a#1 ?%.log")
+	if err := os.WriteFile(fullPath, []byte("disk quota exceeded\n"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	srv.fullText.add(srv, fullPath)
+
+	req, _ := http.NewRequest(http.MethodGet, searchPath+"?type=content&q=quota", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if expected := `href="/logs/a%231%20%3F%25.log?view=1#L1"`; !strings.Contains(w.Body.String(), expected) {
+		t.Errorf("响应中应该包含 %q", expected)
+	}
+}
+
+// TestFullTextIndexUpdate 测试文件变化后索引自动更新
+func TestFullTextIndexUpdate(t *testing.T) {
+	_, router, tempDir := newFullTextRouter(t)
+
+	os.MkdirAll(filepath.Join(tempDir, "new"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "new", "later.log"), []byte("disk quota exceeded\n"), 0644)
+	os.Remove(filepath.Join(tempDir, "logs", "app.log"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		added := searchContentJSON(t, router, "quota")
+		removed := searchContentJSON(t, router, "refused")
+		if added.Count == 1 && added.Results[0].File == "new/later.log" && removed.Count == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("索引没有更新: 新文件 %+v, 删除的文件 %+v", added, removed)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// TestFullTextDisabled 测试未启用全文索引时不能按内容搜索
+func TestFullTextDisabled(t *testing.T) {
+	_, router := newSearchRouter(t)
+
+	req, _ := http.NewRequest(http.MethodGet, searchPath+"?type=content&q=report", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("状态码 = %d, 期望 %d", w.Code, http.StatusBadRequest)
+	}
+	if strings.Contains(w.Body.String(), `value="content"`) {
+		t.Errorf("未启用全文索引时搜索框不应该显示内容搜索选项")
+	}
+}
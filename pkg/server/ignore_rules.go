@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/ignore"
+)
+
+// ignoreFileName 服务根目录中的忽略文件，语法与 .gitignore 相同
+const ignoreFileName = ".servergoignore"
+
+// loadIgnoreRules 读取服务根目录中的 .servergoignore，并追加 --exclude 指定的模式
+// 忽略文件只在启动时读取一次，修改后需要重启服务器
+func (fs *FileServer) loadIgnoreRules() error {
+	matcher, err := ignore.Load(filepath.Join(fs.absDir, ignoreFileName), fs.config.Exclude)
+	if err != nil {
+		return fmt.Errorf(i18n.Tf("error.ignore_load_failed", ignoreFileName, err))
+	}
+	fs.ignore = matcher
+	return nil
+}
+
+// isExcluded 判断路径是否应该对客户端隐藏
+// 被隐藏的路径不会出现在目录列表、打包下载和WebDAV中，直接请求时返回404
+//
+// 参数:
+//   - fullPath: 文件系统中的完整路径，例如: "/home/user/files/web/node_modules"
+//   - isDir: 路径是否是目录，用于只匹配目录的规则（例如 "node_modules/"）
+//
+// 返回值:
+//   - bool: 未启用 ShowHidden 时路径中任意一段以 "." 开头，或者匹配了忽略规则，返回true
+func (fs *FileServer) isExcluded(fullPath string, isDir bool) bool {
+	relPath, err := filepath.Rel(fs.absDir, fullPath)
+	if err != nil || relPath == "." {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if !fs.config.ShowHidden && hasHiddenSegment(relPath) {
+		return true
+	}
+	return fs.ignore.Match(relPath, isDir)
+}
+
+// hasHiddenSegment 判断以 "/" 分隔的相对路径中是否有以 "." 开头的隐藏文件或目录
+func hasHiddenSegment(relPath string) bool {
+	for _, segment := range strings.Split(relPath, "/") {
+		if strings.HasPrefix(segment, ".") && segment != "." && segment != ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newIgnoreRouter 创建包含隐藏文件和忽略文件的测试路由
+func newIgnoreRouter(t *testing.T, showHidden bool) (*gin.Engine, func()) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	srv.config.EnableDirListing = true
+	srv.config.ShowHidden = showHidden
+	srv.config.Exclude = []string{"*.log"}
+
+	files := map[string]string{
+		".env":                       "SECRET=1",
+		".git/config":                "[core]",
+		"subdir/node_modules/a.js":   "module",
+		"subdir/keep.js":             "keep",
+		"subdir/debug.log":           "log",
+		ignoreFileName:               "node_modules/\n",
+		"subdir/.hidden/visible.txt": "hidden",
+	}
+	for name, content := range files {
+		fullPath := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("创建目录失败: %v", err)
+		}
+		if err := ioutil.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+	if err := srv.loadIgnoreRules(); err != nil {
+		t.Fatalf("读取忽略规则失败: %v", err)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+	return router, cleanup
+}
+
+// TestIgnoredRequests 测试直接请求隐藏文件和被忽略的文件返回404
+func TestIgnoredRequests(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		showHidden   bool
+		expectedCode int
+	}{
+		{name: "隐藏文件", path: "/.env", expectedCode: http.StatusNotFound},
+		{name: "隐藏目录中的文件", path: "/.git/config", expectedCode: http.StatusNotFound},
+		{name: "隐藏目录", path: "/subdir/.hidden/", expectedCode: http.StatusNotFound},
+		{name: "忽略文件中的目录规则", path: "/subdir/node_modules/a.js", expectedCode: http.StatusNotFound},
+		{name: "exclude参数", path: "/subdir/debug.log", expectedCode: http.StatusNotFound},
+		{name: "普通文件", path: "/subdir/keep.js", expectedCode: http.StatusOK},
+		{name: "显示隐藏文件", path: "/.env", showHidden: true, expectedCode: http.StatusOK},
+		{name: "显示隐藏文件时忽略规则仍然生效", path: "/subdir/node_modules/a.js", showHidden: true, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, cleanup := newIgnoreRouter(t, tt.showHidden)
+			defer cleanup()
+
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+		})
+	}
+}
+
+// TestIgnoredListing 测试目录列表中不显示隐藏文件和被忽略的文件
+func TestIgnoredListing(t *testing.T) {
+	router, cleanup := newIgnoreRouter(t, false)
+	defer cleanup()
+
+	req, _ := http.NewRequest(http.MethodGet, "/subdir/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "keep.js") {
+		t.Errorf("目录列表中应该包含 keep.js")
+	}
+	for _, name := range []string{"node_modules", "debug.log", ".hidden"} {
+		if strings.Contains(body, name) {
+			t.Errorf("目录列表中不应该包含 %s", name)
+		}
+	}
+}
+
+// TestIgnoredArchive 测试打包下载时跳过隐藏文件和被忽略的文件
+func TestIgnoredArchive(t *testing.T) {
+	router, cleanup := newIgnoreRouter(t, false)
+	defer cleanup()
+
+	req, _ := http.NewRequest(http.MethodGet, "/subdir/?download=zip", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+
+	entries := readZipEntries(t, w.Body.Bytes())
+	if _, ok := entries["subdir/keep.js"]; !ok {
+		t.Errorf("归档中缺少 subdir/keep.js，实际: %v", entryNames(entries))
+	}
+	for name := range entries {
+		if strings.Contains(name, "node_modules") || strings.Contains(name, ".log") || strings.Contains(name, ".hidden") {
+			t.Errorf("归档中不应包含 %s", name)
+		}
+	}
+}
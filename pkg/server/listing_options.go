@@ -0,0 +1,170 @@
+package server
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/utils"
+)
+
+// DefaultPerPage 没有指定 per_page 时目录列表每页显示的数量，避免文件很多的目录一次渲染所有文件项
+const DefaultPerPage = 1000
+
+// maxPerPage per_page 参数的上限
+const maxPerPage = 10000
+
+// sortFields 支持的排序字段和显示文字的翻译键，按显示顺序排列
+var sortFields = []struct {
+	key      string
+	labelKey string
+}{
+	{key: "name", labelKey: "dirlist.sort_name"},
+	{key: "size", labelKey: "dirlist.sort_size"},
+	{key: "mtime", labelKey: "dirlist.sort_mtime"},
+}
+
+// listingOptions 目录列表的排序、筛选和分页参数
+type listingOptions struct {
+	sort    string // 排序字段: "name"、"size" 或 "mtime"
+	desc    bool   // 是否降序
+	filter  string // 文件名筛选条件，包含 *、? 或 [ 时按通配符匹配，否则按子串匹配
+	page    int    // 页码，从1开始
+	perPage int    // 每页的数量
+}
+
+// parseListingOptions 从查询参数中读取排序、筛选和分页参数，无效的参数使用默认值
+// 例如: "?sort=mtime&order=desc&filter=*.log&page=2&per_page=100"
+func parseListingOptions(c *gin.Context) listingOptions {
+	opts := listingOptions{
+		sort:    "name",
+		desc:    c.Query("order") == "desc",
+		filter:  strings.TrimSpace(c.Query("filter")),
+		page:    1,
+		perPage: DefaultPerPage,
+	}
+	switch s := c.Query("sort"); s {
+	case "size", "mtime":
+		opts.sort = s
+	}
+	if page, err := strconv.Atoi(c.Query("page")); err == nil && page > 0 {
+		opts.page = page
+	}
+	if perPage, err := strconv.Atoi(c.Query("per_page")); err == nil && perPage > 0 {
+		opts.perPage = min(perPage, maxPerPage)
+	}
+	return opts
+}
+
+// apply 对文件项进行筛选、排序和分页
+// 目录总是排在文件之前，名称按自然顺序比较（file2 在 file10 之前）
+//
+// 参数:
+//   - c: Gin的上下文，用于生成分页和排序链接
+//   - items: 目录中的所有文件项
+//
+// 返回值:
+//   - []dirlist.FileItem: 当前页的文件项
+//   - *dirlist.ListingInfo: 排序、筛选和分页信息
+func (opts listingOptions) apply(c *gin.Context, items []dirlist.FileItem) ([]dirlist.FileItem, *dirlist.ListingInfo) {
+	if opts.filter != "" {
+		match := newNameMatcher(opts.filter)
+		filtered := make([]dirlist.FileItem, 0, len(items))
+		for _, item := range items {
+			if match(item.Name) {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].IsDir != items[j].IsDir {
+			return items[i].IsDir
+		}
+		if opts.desc {
+			return opts.less(items[j], items[i])
+		}
+		return opts.less(items[i], items[j])
+	})
+
+	total := len(items)
+	totalPages := max((total+opts.perPage-1)/opts.perPage, 1)
+	page := min(opts.page, totalPages)
+	start := (page - 1) * opts.perPage
+	end := min(start+opts.perPage, total)
+
+	order := "asc"
+	if opts.desc {
+		order = "desc"
+	}
+	info := &dirlist.ListingInfo{
+		Sort:       opts.sort,
+		Order:      order,
+		Filter:     opts.filter,
+		Page:       page,
+		PerPage:    opts.perPage,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+	if page > 1 {
+		info.PrevURL = listingURL(c, map[string]string{"page": strconv.Itoa(page - 1)})
+	}
+	if page < totalPages {
+		info.NextURL = listingURL(c, map[string]string{"page": strconv.Itoa(page + 1)})
+	}
+
+	// 点击当前的排序字段切换排序方向，名称默认升序，大小和修改时间默认降序（最大、最新的在前）
+	for _, field := range sortFields {
+		active := field.key == opts.sort
+		linkOrder := "asc"
+		if active && !opts.desc || !active && field.key != "name" {
+			linkOrder = "desc"
+		}
+		info.SortLinks = append(info.SortLinks, dirlist.SortLink{
+			Label:  i18n.T(field.labelKey),
+			URL:    listingURL(c, map[string]string{"sort": field.key, "order": linkOrder, "page": ""}),
+			Active: active,
+			Desc:   active && opts.desc,
+		})
+	}
+
+	return items[start:end], info
+}
+
+// less 按排序字段比较两个文件项，字段相同时按名称比较
+func (opts listingOptions) less(a, b dirlist.FileItem) bool {
+	switch opts.sort {
+	case "size":
+		if a.SizeBytes != b.SizeBytes {
+			return a.SizeBytes < b.SizeBytes
+		}
+	case "mtime":
+		// 修改时间的格式是 "2006-01-02 15:04:05"，按字符串比较即按时间先后
+		if a.LastModified != b.LastModified {
+			return a.LastModified < b.LastModified
+		}
+	}
+	return utils.NaturalLess(a.Name, b.Name)
+}
+
+// listingURL 在当前请求地址的基础上修改查询参数，值为空的参数会被删除
+// 其他参数（例如 view=gallery、filter）保持不变
+func listingURL(c *gin.Context, changes map[string]string) string {
+	query := c.Request.URL.Query()
+	for key, value := range changes {
+		if value == "" {
+			query.Del(key)
+		} else {
+			query.Set(key, value)
+		}
+	}
+
+	u := url.URL{Path: c.Request.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/dirlist"
+)
+
+// newListingRouter 创建使用指定主题的测试路由，测试目录 /many 中包含大小和修改时间各不相同的文件
+func newListingRouter(t *testing.T, theme string) *gin.Engine {
+	srv, tempDir, cleanup := setupTestServer(t)
+	t.Cleanup(cleanup)
+
+	if theme != dirlist.DefaultTheme {
+		tmpl, err := dirlist.NewDirListTemplate(theme)
+		if err != nil {
+			t.Fatalf("创建%s主题失败: %v", theme, err)
+		}
+		srv.dirTemplate = tmpl
+	}
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	files := []struct {
+		name    string
+		size    int
+		modTime time.Time
+	}{
+		{name: "file1.log", size: 10, modTime: base.Add(time.Hour)},
+		{name: "file2.log", size: 30, modTime: base.Add(2 * time.Hour)},
+		{name: "file10.log", size: 20, modTime: base},
+		{name: "notes.txt", size: 5, modTime: base.Add(3 * time.Hour)},
+	}
+	dir := filepath.Join(tempDir, "many")
+	os.MkdirAll(filepath.Join(dir, "zdir"), 0755)
+	for _, f := range files {
+		p := filepath.Join(dir, f.name)
+		if err := os.WriteFile(p, []byte(strings.Repeat("x", f.size)), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+		os.Chtimes(p, f.modTime, f.modTime)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+	return router
+}
+
+// TestListingOptionsJSON 测试json主题下的排序、筛选和分页
+func TestListingOptionsJSON(t *testing.T) {
+	router := newListingRouter(t, dirlist.JsonTheme)
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedNames string
+		expectedTotal int
+		expectedPage  int
+		expectedNext  string
+		expectedPrev  string
+	}{
+		{name: "默认按名称自然排序", query: "", expectedNames: "zdir,file1.log,file2.log,file10.log,notes.txt", expectedTotal: 5, expectedPage: 1},
+		{name: "按大小降序", query: "?sort=size&order=desc", expectedNames: "zdir,file2.log,file10.log,file1.log,notes.txt", expectedTotal: 5, expectedPage: 1},
+		{name: "按修改时间升序", query: "?sort=mtime", expectedNames: "zdir,file10.log,file1.log,file2.log,notes.txt", expectedTotal: 5, expectedPage: 1},
+		{name: "按名称降序", query: "?order=desc", expectedNames: "zdir,notes.txt,file10.log,file2.log,file1.log", expectedTotal: 5, expectedPage: 1},
+		{name: "通配符筛选", query: "?filter=*.log", expectedNames: "file1.log,file2.log,file10.log", expectedTotal: 3, expectedPage: 1},
+		{name: "子串筛选不区分大小写", query: "?filter=FILE1", expectedNames: "file1.log,file10.log", expectedTotal: 2, expectedPage: 1},
+		{
+			name:          "第一页",
+			query:         "?per_page=2",
+			expectedNames: "zdir,file1.log",
+			expectedTotal: 5,
+			expectedPage:  1,
+			expectedNext:  "/many/?page=2&per_page=2",
+		},
+		{
+			name:          "最后一页",
+			query:         "?per_page=2&page=3",
+			expectedNames: "notes.txt",
+			expectedTotal: 5,
+			expectedPage:  3,
+			expectedPrev:  "/many/?page=2&per_page=2",
+		},
+		{
+			name:          "页码超出范围时显示最后一页",
+			query:         "?per_page=2&page=99",
+			expectedNames: "notes.txt",
+			expectedTotal: 5,
+			expectedPage:  3,
+			expectedPrev:  "/many/?page=2&per_page=2",
+		},
+		{
+			name:          "翻页链接保留排序和筛选参数",
+			query:         "?filter=*.log&sort=size&per_page=1",
+			expectedNames: "file1.log",
+			expectedTotal: 3,
+			expectedPage:  1,
+			expectedNext:  "/many/?filter=%2A.log&page=2&per_page=1&sort=size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/many/"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			var result struct {
+				Contents []struct {
+					Name string `json:"name"`
+				} `json:"contents"`
+				Total int    `json:"total"`
+				Page  int    `json:"page"`
+				Next  string `json:"next"`
+				Prev  string `json:"prev"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+				t.Fatalf("解析JSON失败: %v, 响应: %s", err, w.Body.String())
+			}
+
+			names := make([]string, len(result.Contents))
+			for i, item := range result.Contents {
+				names[i] = item.Name
+			}
+			if got := strings.Join(names, ","); got != tt.expectedNames {
+				t.Errorf("文件项 = %s, 期望 %s", got, tt.expectedNames)
+			}
+			if result.Total != tt.expectedTotal || result.Page != tt.expectedPage {
+				t.Errorf("total = %d, page = %d, 期望 %d, %d", result.Total, result.Page, tt.expectedTotal, tt.expectedPage)
+			}
+			if result.Next != tt.expectedNext || result.Prev != tt.expectedPrev {
+				t.Errorf("next = %q, prev = %q, 期望 %q, %q", result.Next, result.Prev, tt.expectedNext, tt.expectedPrev)
+			}
+		})
+	}
+}
+
+// TestListingOptionsThemes 测试HTML主题和table主题中的排序、筛选和分页
+func TestListingOptionsThemes(t *testing.T) {
+	tests := []struct {
+		name         string
+		theme        string
+		query        string
+		expectedBody []string
+		excludedBody []string
+	}{
+		{
+			name:         "HTML主题显示排序链接和页码",
+			theme:        dirlist.DefaultTheme,
+			query:        "?per_page=2",
+			expectedBody: []string{`class="sg-sort"`, `href="/many/?order=desc&amp;per_page=2&amp;sort=size"`, "第 1/3 页，共 5 项", "下一页"},
+			excludedBody: []string{"notes.txt", "上一页"},
+		},
+		{
+			name:         "HTML主题筛选",
+			theme:        dirlist.DefaultTheme,
+			query:        "?filter=notes",
+			expectedBody: []string{"notes.txt", "筛选出 1 项"},
+			excludedBody: []string{"file1.log"},
+		},
+		{
+			name:         "table主题显示页码和翻页地址",
+			theme:        dirlist.TableTheme,
+			query:        "?per_page=2&sort=size&order=desc",
+			expectedBody: []string{"zdir", "file2.log", "第 1/3 页，共 5 项", "下一页: /many/?order=desc&page=2&per_page=2&sort=size"},
+			excludedBody: []string{"notes.txt"},
+		},
+		{
+			name:         "table主题筛选没有结果",
+			theme:        dirlist.TableTheme,
+			query:        "?filter=*.pdf",
+			expectedBody: []string{`没有匹配 "*.pdf" 的文件`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newListingRouter(t, tt.theme)
+			req, _ := http.NewRequest(http.MethodGet, "/many/"+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("响应中应该包含 %q", s)
+				}
+			}
+			for _, s := range tt.excludedBody {
+				if strings.Contains(body, s) {
+					t.Errorf("响应中不应该包含 %q", s)
+				}
+			}
+		})
+	}
+}
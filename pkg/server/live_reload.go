@@ -0,0 +1,313 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// liveReloadPath 实时刷新的SSE事件地址
+const liveReloadPath = "/_servergo/live-reload"
+
+// liveReloadDebounce 合并短时间内的多次文件变化，例如编辑器保存时的写入、重命名
+const liveReloadDebounce = 100 * time.Millisecond
+
+// liveReloadKeepAlive SSE连接的保活间隔，防止中间的代理断开空闲连接
+const liveReloadKeepAlive = 30 * time.Second
+
+// liveReloadScript 注入到HTML响应中的脚本
+// 变化的是当前页面、当前目录下的文件，或者是CSS、JS、图片等非HTML文件时刷新页面
+const liveReloadScript = `<script>(function(){
+var es=new EventSource("` + liveReloadPath + `");
+es.onmessage=function(e){
+var p=e.data,here=decodeURIComponent(location.pathname),dir=here.replace(/\/?$/,"/");
+if(!/\.html?$/i.test(p)||p===here||p.indexOf(dir)===0&&p.slice(dir.length).indexOf("/")<0){location.reload();}
+};
+})();</script>`
+
+// watchRoot 被监视的服务目录，实时刷新和全文索引共用
+type watchRoot struct {
+	server *FileServer // 负责该目录的服务器（挂载点），用于判断忽略规则
+	prefix string      // 目录对应的URL前缀，例如: "/docs"
+}
+
+// watchRoots 返回需要监视的服务目录，配置了挂载点时是所有挂载点的目录
+func (fs *FileServer) watchRoots() []watchRoot {
+	if len(fs.mounts) == 0 {
+		return []watchRoot{{server: fs}}
+	}
+	roots := make([]watchRoot, 0, len(fs.mounts))
+	for _, m := range fs.mounts {
+		roots = append(roots, watchRoot{server: m, prefix: m.prefix})
+	}
+	return roots
+}
+
+// findWatchRoot 查找文件所在的服务目录，返回文件相对于该目录的路径
+func findWatchRoot(roots []watchRoot, name string) (watchRoot, string, bool) {
+	for _, root := range roots {
+		relPath, err := filepath.Rel(root.server.absDir, name)
+		if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+			continue
+		}
+		return root, relPath, true
+	}
+	return watchRoot{}, "", false
+}
+
+// watchTree 递归地为目录及其子目录添加监视
+// fsnotify 不支持递归监视，这里为每个子目录分别添加监视，隐藏和被忽略的目录（例如 node_modules）不监视
+func watchTree(watcher *fsnotify.Watcher, fs *FileServer, dir string) {
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if fs.isExcluded(p, true) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(p); err != nil {
+			logger.Debug("watch %s: %v", p, err)
+		}
+		return nil
+	})
+}
+
+// liveReloader 监视服务目录中的文件变化，并通过SSE通知打开的页面刷新
+type liveReloader struct {
+	roots []watchRoot
+
+	mu      sync.Mutex
+	clients map[chan string]struct{} // 已连接的SSE客户端
+	watcher *fsnotify.Watcher
+	done    chan struct{} // 关闭后所有SSE连接结束
+	closed  bool
+}
+
+// newLiveReloader 创建实时刷新器，配置了挂载点时监视所有挂载点的目录
+func (fs *FileServer) newLiveReloader() *liveReloader {
+	return &liveReloader{
+		roots:   fs.watchRoots(),
+		clients: map[chan string]struct{}{},
+		done:    make(chan struct{}),
+	}
+}
+
+// start 开始监视文件变化
+func (lr *liveReloader) start() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	lr.mu.Lock()
+	lr.watcher = watcher
+	lr.mu.Unlock()
+
+	for _, root := range lr.roots {
+		watchTree(watcher, root.server, root.server.absDir)
+	}
+
+	go lr.run()
+	return nil
+}
+
+// run 处理文件变化事件，合并短时间内的变化后通知客户端
+func (lr *liveReloader) run() {
+	pending := map[string]bool{}
+	timer := time.NewTimer(liveReloadDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-lr.watcher.Events:
+			if !ok {
+				return
+			}
+			urlPath, ok := lr.handleEvent(event)
+			if !ok {
+				continue
+			}
+			pending[urlPath] = true
+			timer.Reset(liveReloadDebounce)
+		case err, ok := <-lr.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warning(i18n.Tf("server.live_reload_error", err))
+		case <-timer.C:
+			for urlPath := range pending {
+				lr.broadcast(urlPath)
+			}
+			pending = map[string]bool{}
+		case <-lr.done:
+			return
+		}
+	}
+}
+
+// handleEvent 把文件系统事件转换为URL路径，新建的目录会被加入监视
+func (lr *liveReloader) handleEvent(event fsnotify.Event) (string, bool) {
+	// 只修改权限的事件不影响页面内容
+	if event.Op == fsnotify.Chmod {
+		return "", false
+	}
+
+	root, relPath, ok := findWatchRoot(lr.roots, event.Name)
+	if !ok {
+		return "", false
+	}
+
+	info, statErr := os.Stat(event.Name)
+	isDir := statErr == nil && info.IsDir()
+	if root.server.isExcluded(event.Name, isDir) {
+		return "", false
+	}
+	if isDir && event.Has(fsnotify.Create) {
+		watchTree(lr.watcher, root.server, event.Name)
+	}
+
+	return path.Join("/", root.prefix, filepath.ToSlash(relPath)), true
+}
+
+// broadcast 把变化的URL路径发送给所有客户端，发送缓冲已满的客户端会错过这次通知
+func (lr *liveReloader) broadcast(urlPath string) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	for client := range lr.clients {
+		select {
+		case client <- urlPath:
+		default:
+		}
+	}
+}
+
+// subscribe 注册一个SSE客户端
+func (lr *liveReloader) subscribe() chan string {
+	client := make(chan string, 16)
+	lr.mu.Lock()
+	lr.clients[client] = struct{}{}
+	lr.mu.Unlock()
+	return client
+}
+
+// unsubscribe 注销一个SSE客户端
+func (lr *liveReloader) unsubscribe(client chan string) {
+	lr.mu.Lock()
+	delete(lr.clients, client)
+	lr.mu.Unlock()
+}
+
+// close 停止监视并结束所有SSE连接，使优雅关闭不必等待这些长连接
+func (lr *liveReloader) close() {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	if lr.closed {
+		return
+	}
+	lr.closed = true
+	close(lr.done)
+	if lr.watcher != nil {
+		lr.watcher.Close()
+	}
+}
+
+// handleLiveReloadEvents 以SSE的方式推送文件变化，每个事件的数据是变化文件的URL路径
+// 当前用户不能读取的路径不推送，避免通过事件得知这些文件的名称
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+func (fs *FileServer) handleLiveReloadEvents(c *gin.Context) {
+	lr := fs.liveReload
+	client := lr.subscribe()
+	defer lr.unsubscribe(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.WriteString(": connected\n\n")
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(liveReloadKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case urlPath := <-client:
+			if !fs.allowed(c, urlPath, auth.PermRead) {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "data: %s\n\n", urlPath)
+			c.Writer.Flush()
+		case <-keepAlive.C:
+			c.Writer.WriteString(": keep-alive\n\n")
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		case <-lr.done:
+			return
+		}
+	}
+}
+
+// serveHTMLWithLiveReload 提供HTML文件，并在 </body> 之前注入实时刷新脚本
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - fullPath: HTML文件的完整路径，例如: "/home/user/site/index.html"
+func (fs *FileServer) serveHTMLWithLiveReload(c *gin.Context, fullPath string) {
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		fs.respondError(c, http.StatusInternalServerError, i18n.T("http.500"))
+		return
+	}
+
+	// 开发时每次都重新获取页面，避免浏览器使用缓存的旧页面
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", injectLiveReloadScript(content))
+}
+
+// injectLiveReloadScript 在最后一个 </body> 之前插入实时刷新脚本，没有 </body> 时追加到末尾
+func injectLiveReloadScript(html []byte) []byte {
+	index := lastIndexBodyClose(html)
+	if index < 0 {
+		return append(html, liveReloadScript...)
+	}
+
+	result := make([]byte, 0, len(html)+len(liveReloadScript))
+	result = append(result, html[:index]...)
+	result = append(result, liveReloadScript...)
+	return append(result, html[index:]...)
+}
+
+// lastIndexBodyClose 不区分大小写地查找最后一个 </body> 的位置
+func lastIndexBodyClose(html []byte) int {
+	tag := []byte("</body>")
+	for i := len(html) - len(tag); i >= 0; i-- {
+		if html[i] == '<' && bytes.EqualFold(html[i:i+len(tag)], tag) {
+			return i
+		}
+	}
+	return -1
+}
+
+// isHTMLFile 判断文件是否是HTML页面
+func isHTMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".html" || ext == ".htm"
+}
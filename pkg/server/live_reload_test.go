@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newLiveReloadRouter 创建启用了实时刷新的测试路由
+func newLiveReloadRouter(t *testing.T) (*FileServer, *gin.Engine, string) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	t.Cleanup(cleanup)
+
+	srv.config.LiveReload = true
+	srv.liveReload = srv.newLiveReloader()
+
+	router := gin.New()
+	router.GET(liveReloadPath, srv.handleLiveReloadEvents)
+	router.NoRoute(srv.handleRequest)
+	return srv, router, tempDir
+}
+
+// TestInjectLiveReloadScript 测试刷新脚本的注入位置
+func TestInjectLiveReloadScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{name: "插入到</body>之前", html: "<html><body>hi</body></html>", expected: "<html><body>hi" + liveReloadScript + "</body></html>"},
+		{name: "不区分大小写", html: "<BODY>hi</BODY>", expected: "<BODY>hi" + liveReloadScript + "</BODY>"},
+		{name: "使用最后一个</body>", html: "<body>a</body>b</body>", expected: "<body>a</body>b" + liveReloadScript + "</body>"},
+		{name: "没有</body>时追加到末尾", html: "<p>hi</p>", expected: "<p>hi</p>" + liveReloadScript},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := string(injectLiveReloadScript([]byte(tt.html))); result != tt.expected {
+				t.Errorf("注入结果 = %q, 期望 %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+// TestLiveReloadInjection 测试启用实时刷新时HTML响应中注入了刷新脚本，其他文件保持不变
+func TestLiveReloadInjection(t *testing.T) {
+	_, router, _ := newLiveReloadRouter(t)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedScript bool
+	}{
+		{name: "HTML文件", path: "/index.html", expectedScript: true},
+		{name: "目录中的index.html", path: "/indexdir/", expectedScript: true},
+		{name: "目录列表", path: "/subdir/", expectedScript: true},
+		{name: "非HTML文件", path: "/test.txt", expectedScript: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+			}
+			if hasScript := strings.Contains(w.Body.String(), liveReloadPath); hasScript != tt.expectedScript {
+				t.Errorf("包含刷新脚本 = %v, 期望 %v", hasScript, tt.expectedScript)
+			}
+		})
+	}
+}
+
+// TestLiveReloadEvents 测试文件变化后通过SSE推送变化的路径，关闭后SSE连接结束
+func TestLiveReloadEvents(t *testing.T) {
+	srv, router, tempDir := newLiveReloadRouter(t)
+	if err := srv.liveReload.start(); err != nil {
+		t.Fatalf("启动文件监视失败: %v", err)
+	}
+	defer srv.liveReload.close()
+
+	httpServer := httptest.NewServer(router)
+	defer httpServer.Close()
+
+	resp, err := http.Get(httpServer.URL + liveReloadPath)
+	if err != nil {
+		t.Fatalf("连接SSE失败: %v", err)
+	}
+	defer resp.Body.Close()
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, 期望 text/event-stream", contentType)
+	}
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	// 等待连接建立后再修改文件
+	if line := <-lines; !strings.HasPrefix(line, ":") {
+		t.Fatalf("第一行 = %q, 期望是注释", line)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "subdir", "subfile.txt"), []byte("changed"), 0644); err != nil {
+		t.Fatalf("修改文件失败: %v", err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	for found := false; !found; {
+		select {
+		case line := <-lines:
+			found = line == "data: /subdir/subfile.txt"
+		case <-timeout:
+			t.Fatalf("没有收到文件变化事件")
+		}
+	}
+
+	// 关闭后SSE连接应该结束，优雅关闭不必等待
+	srv.liveReload.close()
+	timeout = time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-lines:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("关闭后SSE连接没有结束")
+		}
+	}
+}
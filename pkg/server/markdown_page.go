@@ -0,0 +1,129 @@
+package server
+
+import (
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/highlight"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+	"github.com/CC11001100/servergo/pkg/markdown"
+)
+
+// markdownMaxSize 超过此大小的Markdown文件不渲染，直接提供原始文件
+const markdownMaxSize = 4 << 20
+
+// htmlTheme 判断当前主题是否输出HTML，json和table主题不渲染Markdown
+func (fs *FileServer) htmlTheme() bool {
+	return fs.dirTemplate != nil && strings.HasPrefix(fs.dirTemplate.GetContentType(), "text/html")
+}
+
+// shouldRenderMarkdown 判断是否把Markdown文件渲染为HTML页面
+// 只有浏览器（Accept中包含 text/html）请求时才渲染，curl 等工具以及 ?raw=1 仍然得到原始文本
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - fileInfo: 请求的文件信息
+func (fs *FileServer) shouldRenderMarkdown(c *gin.Context, fileInfo os.FileInfo) bool {
+	if !markdown.IsMarkdownFile(fileInfo.Name()) || fileInfo.Size() > markdownMaxSize || !fs.htmlTheme() {
+		return false
+	}
+	if raw, _ := strconv.ParseBool(c.Query("raw")); raw {
+		return false
+	}
+	return strings.Contains(strings.ToLower(c.GetHeader("Accept")), "text/html")
+}
+
+// renderMarkdownFile 使用当前主题把Markdown文件渲染为带目录的HTML页面
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - fullPath: 文件的完整路径，例如: "/home/user/docs/guide.md"
+//   - reqPath: 请求的路径，例如: "/docs/guide.md"
+func (fs *FileServer) renderMarkdownFile(c *gin.Context, fullPath, reqPath string) {
+	doc, err := loadDocument(fullPath)
+	if err != nil {
+		fs.respondError(c, http.StatusInternalServerError, i18n.Tf("http.500_markdown", err))
+		return
+	}
+
+	fs.renderPage(c, dirlist.TemplateData{
+		DirPath:     reqPath,
+		ParentDir:   path.Dir(path.Clean("/" + reqPath)),
+		CurrentTime: time.Now().Format("2006-01-02 15:04:05"),
+		Document:    doc,
+	})
+}
+
+// loadReadme 查找并渲染目录中的README文档，显示在目录列表的下方
+// 文件名不区分大小写，例如 README.md、readme.md；没有README、README指向根目录外、当前用户不能读取或渲染失败时返回nil
+//
+// 参数:
+//   - c: Gin的上下文，用于检查当前用户的读取权限
+//   - dir: 目录的完整路径
+//   - files: 目录中的文件
+func (fs *FileServer) loadReadme(c *gin.Context, dir string, files []os.DirEntry) *dirlist.DocumentInfo {
+	if !fs.htmlTheme() {
+		return nil
+	}
+
+	for _, file := range files {
+		name := strings.ToLower(file.Name())
+		if file.IsDir() || (name != "readme.md" && name != "readme.markdown") {
+			continue
+		}
+
+		fullPath := filepath.Join(dir, file.Name())
+		// README可能是指向根目录外的符号链接，与直接请求该文件一样检查真实路径
+		if fs.isExcluded(fullPath, false) || fs.checkRealPath(fullPath) != nil {
+			continue
+		}
+		info, err := os.Stat(fullPath)
+		if err != nil || info.IsDir() || info.Size() > markdownMaxSize {
+			continue
+		}
+		if !fs.allowed(c, fs.urlPathOf(fullPath), auth.PermRead) {
+			continue
+		}
+
+		doc, err := loadDocument(fullPath)
+		if err != nil {
+			logger.Debug("render readme %s: %v", fullPath, err)
+			continue
+		}
+		return doc
+	}
+	return nil
+}
+
+// loadDocument 读取并渲染Markdown文件
+func loadDocument(fullPath string) (*dirlist.DocumentInfo, error) {
+	source, err := os.ReadFile(fullPath)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := markdown.Render(source)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &dirlist.DocumentInfo{
+		Name: filepath.Base(fullPath),
+		HTML: template.HTML(rendered.HTML),
+		CSS:  template.CSS(highlight.CSS()),
+	}
+	for _, h := range rendered.Headings {
+		doc.TOC = append(doc.TOC, dirlist.TOCEntry{Level: h.Level, Text: h.Text, ID: h.ID})
+	}
+	return doc, nil
+}
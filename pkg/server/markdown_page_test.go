@@ -0,0 +1,154 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/dirlist"
+)
+
+// TestMarkdownRendering 测试浏览器请求Markdown文件时渲染为HTML页面
+func TestMarkdownRendering(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	source := "# 使用说明\n\n## 安装\n\n```go\nfmt.Println(1)\n```\n"
+	if err := os.WriteFile(filepath.Join(tempDir, "subdir", "guide.md"), []byte(source), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "subdir", "README.md"), []byte("# 子目录说明"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	const browserAccept = "text/html,application/xhtml+xml,*/*;q=0.8"
+	tests := []struct {
+		name         string
+		path         string
+		accept       string
+		expectedType string
+		expectedBody []string
+		excludedBody []string
+	}{
+		{
+			name:         "浏览器请求渲染为HTML",
+			path:         "/subdir/guide.md",
+			accept:       browserAccept,
+			expectedType: "text/html",
+			expectedBody: []string{`<h2 id="安装">`, `class="sg-toc"`, `class="chroma"`, "?raw=1"},
+			excludedBody: []string{"<table>"},
+		},
+		{
+			name:         "raw参数返回原始文本",
+			path:         "/subdir/guide.md?raw=1",
+			accept:       browserAccept,
+			expectedBody: []string{"# 使用说明"},
+			excludedBody: []string{"<h1"},
+		},
+		{
+			name:         "非浏览器请求返回原始文本",
+			path:         "/subdir/guide.md",
+			accept:       "*/*",
+			expectedBody: []string{"# 使用说明"},
+			excludedBody: []string{"<h1"},
+		},
+		{
+			name:         "目录列表下方显示README",
+			path:         "/subdir/",
+			accept:       browserAccept,
+			expectedType: "text/html",
+			expectedBody: []string{"subfile.txt", `<h1 id="子目录说明">`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.expectedType) {
+				t.Errorf("Content-Type = %q, 期望以 %q 开头", contentType, tt.expectedType)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("响应中应该包含 %q", s)
+				}
+			}
+			for _, s := range tt.excludedBody {
+				if strings.Contains(body, s) {
+					t.Errorf("响应中不应该包含 %q", s)
+				}
+			}
+		})
+	}
+}
+
+// TestMarkdownJSONTheme 测试json主题下不渲染Markdown
+func TestMarkdownJSONTheme(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "guide.md"), []byte("# 标题"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	tmpl, err := dirlist.NewDirListTemplate(dirlist.JsonTheme)
+	if err != nil {
+		t.Fatalf("创建模板失败: %v", err)
+	}
+	srv.dirTemplate = tmpl
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	req, _ := http.NewRequest(http.MethodGet, "/guide.md", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if body := w.Body.String(); body != "# 标题" {
+		t.Errorf("响应内容 = %q, 期望原始文本", body)
+	}
+}
+
+// TestReadmeSymlinkOutsideRoot 测试目录列表不显示指向根目录外的README
+func TestReadmeSymlinkOutsideRoot(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	outsideDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outsideDir, "secret.md"), []byte("# Outside secret"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := os.Symlink(filepath.Join(outsideDir, "secret.md"), filepath.Join(tempDir, "subdir", "README.md")); err != nil {
+		t.Fatalf("创建符号链接失败: %v", err)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	req, _ := http.NewRequest(http.MethodGet, "/subdir/", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+	}
+	if strings.Contains(w.Body.String(), "Outside secret") {
+		t.Error("目录列表不应该显示根目录外的README")
+	}
+}
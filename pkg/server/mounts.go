@@ -0,0 +1,195 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/i18n"
+)
+
+// Mount 表示把一个本地目录挂载到一个URL前缀下
+type Mount struct {
+	Prefix string // URL前缀，例如: "/docs"，"/" 表示挂载到根路径
+	Dir    string // 本地目录，例如: "./site"
+}
+
+// ParseMount 解析 "前缀=目录" 格式的挂载参数
+//
+// 参数:
+//   - spec: 挂载参数，例如: "/docs=./site"
+//
+// 返回值:
+//   - Mount: 解析后的挂载点，前缀已规范化为以 "/" 开头、不以 "/" 结尾的形式
+//   - error: 格式错误时返回错误
+func ParseMount(spec string) (Mount, error) {
+	prefix, dir, ok := strings.Cut(spec, "=")
+	if !ok || strings.TrimSpace(dir) == "" {
+		return Mount{}, fmt.Errorf(i18n.Tf("error.mount_invalid", spec))
+	}
+	return Mount{Prefix: normalizeMountPrefix(prefix), Dir: dir}, nil
+}
+
+// MountsFromDirs 把多个目录分别挂载到以目录名命名的前缀下
+// 例如 ["./site", "/srv/artifacts"] 挂载为 "/site" 和 "/artifacts"，目录名重复时依次加上 "-2"、"-3" 等后缀
+func MountsFromDirs(dirs []string) []Mount {
+	mounts := make([]Mount, 0, len(dirs))
+	used := map[string]bool{}
+	for _, dir := range dirs {
+		name := "root"
+		if absDir, err := filepath.Abs(dir); err == nil && filepath.Base(absDir) != string(filepath.Separator) {
+			name = filepath.Base(absDir)
+		}
+
+		prefix := "/" + name
+		for i := 2; used[prefix]; i++ {
+			prefix = fmt.Sprintf("/%s-%d", name, i)
+		}
+		used[prefix] = true
+
+		mounts = append(mounts, Mount{Prefix: prefix, Dir: dir})
+	}
+	return mounts
+}
+
+// normalizeMountPrefix 规范化挂载前缀，例如 "docs/" 规范化为 "/docs"，根路径规范化为 "/"
+func normalizeMountPrefix(prefix string) string {
+	return path.Clean("/" + strings.Trim(strings.TrimSpace(prefix), "/"))
+}
+
+// setupMounts 为每个挂载点创建一个子文件服务器
+// 子服务器共享父服务器的配置、模板和认证器，只有服务目录、忽略规则和WebDAV处理器是独立的
+func (fs *FileServer) setupMounts() error {
+	seen := map[string]bool{}
+	for _, m := range fs.config.Mounts {
+		prefix := normalizeMountPrefix(m.Prefix)
+		if seen[prefix] {
+			return fmt.Errorf(i18n.Tf("error.mount_duplicate", prefix))
+		}
+		seen[prefix] = true
+
+		absDir, realDir, err := resolveServedDir(m.Dir)
+		if err != nil {
+			return err
+		}
+
+		config := fs.config
+		config.Dir = m.Dir
+		config.Mounts = nil
+
+		child := &FileServer{
+			config:        config,
+			absDir:        absDir,
+			realDir:       realDir,
+			engine:        fs.engine,
+			authenticator: fs.authenticator,
+			authorizer:    fs.authorizer,
+			dirTemplate:   fs.dirTemplate,
+			parent:        fs,
+		}
+		// 根路径的挂载点不需要去掉前缀
+		if prefix != "/" {
+			child.prefix = prefix
+		}
+		if err := child.loadIgnoreRules(); err != nil {
+			return err
+		}
+		if config.EnableWebDAV {
+			child.davHandler = child.newWebDAVHandler()
+		}
+
+		fs.mounts = append(fs.mounts, child)
+	}
+
+	// 前缀长的挂载点优先匹配，例如 "/docs/api" 优先于 "/docs"
+	sort.SliceStable(fs.mounts, func(i, j int) bool {
+		return len(fs.mounts[i].prefix) > len(fs.mounts[j].prefix)
+	})
+	return nil
+}
+
+// findMount 查找负责处理请求路径的挂载点，没有匹配的挂载点时返回nil
+func (fs *FileServer) findMount(reqPath string) *FileServer {
+	for _, m := range fs.mounts {
+		if m.prefix == "" || reqPath == m.prefix || strings.HasPrefix(reqPath, m.prefix+"/") {
+			return m
+		}
+	}
+	return nil
+}
+
+// handleMountRequest 把请求交给对应的挂载点处理
+// 不属于任何挂载点、但是挂载点上级目录的路径（例如根路径）显示为只包含挂载点的虚拟目录
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+func (fs *FileServer) handleMountRequest(c *gin.Context) {
+	reqPath := path.Clean("/" + c.Request.URL.Path)
+	if m := fs.findMount(reqPath); m != nil {
+		m.handleRequest(c)
+		return
+	}
+
+	// 虚拟目录不对应真实的目录，不支持打包下载
+	items := fs.virtualMountItems(reqPath)
+	if len(items) == 0 || c.Query("download") != "" {
+		fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
+		return
+	}
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		fs.methodNotAllowed(c)
+		return
+	}
+	if !fs.config.EnableDirListing {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return
+	}
+	if !fs.authorize(c, reqPath, auth.PermRead) || !fs.authorize(c, reqPath, auth.PermList) {
+		return
+	}
+
+	fs.renderListing(c, reqPath, items, nil)
+}
+
+// virtualMountItems 返回位于 urlDir 之下的挂载点对应的虚拟目录项
+// 例如挂载了 "/docs" 和 "/builds/nightly" 时，根路径下有 "docs" 和 "builds" 两个虚拟目录
+//
+// 参数:
+//   - urlDir: URL中的目录路径，例如: "/"
+func (fs *FileServer) virtualMountItems(urlDir string) []dirlist.FileItem {
+	root := fs
+	if fs.parent != nil {
+		root = fs.parent
+	}
+
+	base := strings.TrimSuffix(path.Clean("/"+urlDir), "/") + "/"
+	seen := map[string]bool{}
+	items := make([]dirlist.FileItem, 0)
+	for _, m := range root.mounts {
+		rest, ok := strings.CutPrefix(m.prefix, base)
+		if !ok || rest == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, "/")
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		items = append(items, dirlist.FileItem{
+			Name:         name,
+			IsDir:        true,
+			Size:         "-",
+			LastModified: "-",
+			Path:         base + name,
+		})
+	}
+	return items
+}
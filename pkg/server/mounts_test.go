@@ -0,0 +1,163 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestParseMount 测试挂载参数的解析
+func TestParseMount(t *testing.T) {
+	tests := []struct {
+		spec      string
+		expected  Mount
+		expectErr bool
+	}{
+		{spec: "/docs=./site", expected: Mount{Prefix: "/docs", Dir: "./site"}},
+		{spec: "docs/=./site", expected: Mount{Prefix: "/docs", Dir: "./site"}},
+		{spec: "/a/b/=/srv/b", expected: Mount{Prefix: "/a/b", Dir: "/srv/b"}},
+		{spec: "/=.", expected: Mount{Prefix: "/", Dir: "."}},
+		{spec: "./site", expectErr: true},
+		{spec: "/docs=", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		m, err := ParseMount(tt.spec)
+		if (err != nil) != tt.expectErr {
+			t.Errorf("ParseMount(%q) 错误 = %v, 期望出错 = %v", tt.spec, err, tt.expectErr)
+			continue
+		}
+		if !tt.expectErr && m != tt.expected {
+			t.Errorf("ParseMount(%q) = %+v, 期望 %+v", tt.spec, m, tt.expected)
+		}
+	}
+}
+
+// TestMountsFromDirs 测试位置参数中的多个目录按目录名挂载
+func TestMountsFromDirs(t *testing.T) {
+	mounts := MountsFromDirs([]string{"./site", "/srv/artifacts", "/tmp/site"})
+	expected := []string{"/site", "/artifacts", "/site-2"}
+	for i, m := range mounts {
+		if m.Prefix != expected[i] {
+			t.Errorf("第%d个挂载点前缀 = %s, 期望 %s", i, m.Prefix, expected[i])
+		}
+	}
+}
+
+// newMountRouter 创建挂载了 /docs 和 /builds/nightly 两个目录的测试路由
+func newMountRouter(t *testing.T) (*gin.Engine, func()) {
+	gin.SetMode(gin.TestMode)
+
+	docsDir, err := ioutil.TempDir("", "servergo-docs-")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	buildsDir, err := ioutil.TempDir("", "servergo-builds-")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	cleanup := func() {
+		os.RemoveAll(docsDir)
+		os.RemoveAll(buildsDir)
+	}
+
+	files := map[string]string{
+		filepath.Join(docsDir, "index.html"):       "docs index",
+		filepath.Join(docsDir, "guide", "a.md"):    "guide",
+		filepath.Join(buildsDir, "app-1.0.tar"):    "build",
+		filepath.Join(buildsDir, "old", "app.tar"): "old build",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(name), 0755)
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			cleanup()
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+
+	srv, err := New(Config{
+		EnableDirListing: true,
+		Mounts: []Mount{
+			{Prefix: "/docs", Dir: docsDir},
+			{Prefix: "/builds/nightly", Dir: buildsDir},
+		},
+	})
+	if err != nil {
+		cleanup()
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+	return router, cleanup
+}
+
+// TestMountRequests 测试请求被分发到对应的挂载点
+func TestMountRequests(t *testing.T) {
+	router, cleanup := newMountRouter(t)
+	defer cleanup()
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody []string
+		excludedBody []string
+	}{
+		{name: "挂载点的index.html", path: "/docs/", expectedCode: http.StatusOK, expectedBody: []string{"docs index"}},
+		{name: "挂载点中的文件", path: "/docs/guide/a.md", expectedCode: http.StatusOK, expectedBody: []string{"guide"}},
+		{name: "多级前缀的挂载点", path: "/builds/nightly/app-1.0.tar", expectedCode: http.StatusOK, expectedBody: []string{"build"}},
+		{name: "挂载点的目录列表使用完整路径", path: "/builds/nightly/", expectedCode: http.StatusOK, expectedBody: []string{"/builds/nightly/app-1.0.tar", "/builds/nightly/old"}},
+		{name: "根路径显示虚拟目录", path: "/", expectedCode: http.StatusOK, expectedBody: []string{"docs", "builds"}, excludedBody: []string{"nightly"}},
+		{name: "中间路径显示虚拟目录", path: "/builds/", expectedCode: http.StatusOK, expectedBody: []string{"/builds/nightly"}},
+		{name: "不属于任何挂载点", path: "/other.txt", expectedCode: http.StatusNotFound},
+		{name: "不能越出挂载点", path: "/docs/../../etc/passwd", expectedCode: http.StatusNotFound},
+		{name: "前缀按路径段匹配", path: "/docsx/a.md", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("响应中应该包含 %q", s)
+				}
+			}
+			for _, s := range tt.excludedBody {
+				if strings.Contains(body, s) {
+					t.Errorf("响应中不应该包含 %q", s)
+				}
+			}
+		})
+	}
+}
+
+// TestMountDuplicatePrefix 测试重复的挂载前缀
+func TestMountDuplicatePrefix(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "servergo-mount-")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	_, err = New(Config{Mounts: []Mount{
+		{Prefix: "/a", Dir: tempDir},
+		{Prefix: "/a/", Dir: tempDir},
+	}})
+	if err == nil {
+		t.Errorf("重复的挂载前缀应该返回错误")
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// errPathForbidden 表示请求的路径越出了服务根目录
+var errPathForbidden = errors.New("path escapes the served directory")
+
+// resolvePath 将请求路径映射为服务目录中的完整文件系统路径
+//
+// 参数:
+//   - reqPath: 请求的URL路径，例如: "/images/logo.png"
+//
+// 返回值:
+//   - string: 文件系统中的完整路径，例如: "/home/user/files/images/logo.png"
+//   - error: 如果清理后的路径越出了根目录，返回 errPathForbidden
+//
+// 注意: 此方法只做字面上的路径检查，不解析符号链接，符号链接需要再调用 checkRealPath
+func (fs *FileServer) resolvePath(reqPath string) (string, error) {
+	// 挂载点需要先去掉URL前缀，例如 "/docs/a.md" 对应挂载目录中的 "/a.md"
+	if fs.prefix != "" {
+		reqPath = strings.TrimPrefix(filepath.Clean("/"+reqPath), fs.prefix)
+	}
+
+	return fs.resolveMountPath(reqPath)
+}
+
+// resolveMountPath 将相对于服务目录的路径映射为完整的文件系统路径
+// 与 resolvePath 不同，不会去掉挂载点的URL前缀，用于WebDAV处理器已经去掉前缀的路径
+func (fs *FileServer) resolveMountPath(reqPath string) (string, error) {
+	// 使用 filepath.Clean 清理路径，移除多余的 . 和 .. 元素
+	cleanPath := filepath.Clean(reqPath)
+	if !strings.HasPrefix(cleanPath, "/") {
+		cleanPath = "/" + cleanPath
+	}
+
+	// 构建完整的文件路径
+	fullPath := filepath.Join(fs.absDir, cleanPath)
+
+	// 使用 filepath.Rel 检查完整路径相对于根目录的位置
+	if !isWithinDir(fs.absDir, fullPath) {
+		logger.Debug("Path traversal attack detected! Request path: %s", reqPath)
+		return "", errPathForbidden
+	}
+
+	return fullPath, nil
+}
+
+// checkRealPath 解析路径上的所有符号链接，确认真实路径仍位于服务目录内
+//
+// 参数:
+//   - fullPath: 由 resolvePath 得到的完整路径
+//
+// 返回值:
+//   - error: 路径不存在时返回底层的 os 错误（可用 os.IsNotExist 判断），
+//     真实路径越出根目录时返回 errPathForbidden
+func (fs *FileServer) checkRealPath(fullPath string) error {
+	realPath, err := filepath.EvalSymlinks(fullPath)
+	if err != nil {
+		return err
+	}
+
+	if !isWithinDir(fs.realDir, realPath) {
+		logger.Debug("Symlink path traversal detected! Real path: %s", realPath)
+		return errPathForbidden
+	}
+
+	return nil
+}
+
+// isWithinDir 判断 target 是否位于 root 目录内（包括 root 本身）
+func isWithinDir(root, target string) bool {
+	relPath, err := filepath.Rel(root, target)
+	if err != nil {
+		return false
+	}
+	return relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/http/httpguts"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// ProxyRule 表示把一个URL前缀下的请求转发到上游服务
+type ProxyRule struct {
+	Prefix   string        // URL前缀，例如: "/api"
+	Target   string        // 上游地址，例如: "http://127.0.0.1:3000"
+	Request  HeaderRewrite // 转发给上游前对请求头的改写
+	Response HeaderRewrite // 返回给客户端前对响应头的改写
+}
+
+// HeaderRewrite 转发时对请求头或响应头的改写，先删除再设置
+type HeaderRewrite struct {
+	Set    http.Header // 设置的头部，覆盖已有的值，例如: {"X-Env": {"dev"}}
+	Remove []string    // 删除的头部，例如: []string{"Server"}
+}
+
+// apply 改写头部
+func (h HeaderRewrite) apply(header http.Header) {
+	for _, name := range h.Remove {
+		header.Del(name)
+	}
+	for name, values := range h.Set {
+		header[name] = append([]string(nil), values...)
+	}
+}
+
+// proxyRoute 解析后的转发规则
+type proxyRoute struct {
+	prefix   string        // 规范化后的URL前缀，例如: "/api"
+	target   *url.URL      // 上游地址
+	request  HeaderRewrite // 请求头的改写
+	response HeaderRewrite // 响应头的改写
+}
+
+// ParseProxyRule 解析 "前缀=上游地址[;选项...]" 格式的转发参数
+//
+// 支持的选项，可以重复指定，值中不能包含分号:
+//   - set-header=名称:值: 设置转发给上游的请求头，设置 Host 时改写发给上游的 Host
+//   - remove-header=名称: 删除转发给上游的请求头
+//   - set-response-header=名称:值: 设置返回给客户端的响应头
+//   - remove-response-header=名称: 删除返回给客户端的响应头
+//
+// 参数:
+//   - spec: 转发参数，例如: "/api=http://127.0.0.1:3000;set-header=X-Env:dev;remove-response-header=Server"
+//
+// 返回值:
+//   - ProxyRule: 解析后的转发规则
+//   - error: 格式错误时返回错误
+func ParseProxyRule(spec string) (ProxyRule, error) {
+	parts := strings.Split(spec, ";")
+	prefix, target, ok := strings.Cut(parts[0], "=")
+	if !ok || strings.TrimSpace(target) == "" {
+		return ProxyRule{}, fmt.Errorf(i18n.Tf("error.proxy_invalid", spec))
+	}
+	rule := ProxyRule{Prefix: normalizeMountPrefix(prefix), Target: strings.TrimSpace(target)}
+
+	for _, option := range parts[1:] {
+		key, value, _ := strings.Cut(strings.TrimSpace(option), "=")
+		var err error
+		switch key {
+		case "set-header":
+			err = rule.Request.parseSet(value)
+		case "remove-header":
+			err = rule.Request.parseRemove(value)
+		case "set-response-header":
+			err = rule.Response.parseSet(value)
+		case "remove-response-header":
+			err = rule.Response.parseRemove(value)
+		default:
+			err = errProxyOption
+		}
+		if err != nil {
+			return ProxyRule{}, fmt.Errorf(i18n.Tf("error.proxy_option_invalid", option, spec))
+		}
+	}
+	return rule, nil
+}
+
+// errProxyOption 转发参数中的选项格式错误
+var errProxyOption = errors.New("invalid proxy option")
+
+// parseSet 解析 "名称:值" 格式的设置选项，值可以为空
+func (h *HeaderRewrite) parseSet(value string) error {
+	name, headerValue, ok := strings.Cut(value, ":")
+	name, headerValue = strings.TrimSpace(name), strings.TrimSpace(headerValue)
+	if !ok || !httpguts.ValidHeaderFieldName(name) || !httpguts.ValidHeaderFieldValue(headerValue) {
+		return errProxyOption
+	}
+	if h.Set == nil {
+		h.Set = http.Header{}
+	}
+	h.Set.Add(name, headerValue)
+	return nil
+}
+
+// parseRemove 解析删除选项中的头部名称
+func (h *HeaderRewrite) parseRemove(value string) error {
+	name := strings.TrimSpace(value)
+	if !httpguts.ValidHeaderFieldName(name) {
+		return errProxyOption
+	}
+	h.Remove = append(h.Remove, name)
+	return nil
+}
+
+// setupProxies 解析并检查所有转发规则
+// 上游地址支持 http、https，以及等价的 ws、wss
+func (fs *FileServer) setupProxies() error {
+	for _, rule := range fs.config.Proxies {
+		prefix := normalizeMountPrefix(rule.Prefix)
+		target, err := url.Parse(rule.Target)
+		if err != nil || target.Host == "" || prefix == "/" {
+			return fmt.Errorf(i18n.Tf("error.proxy_invalid", rule.Prefix+"="+rule.Target))
+		}
+
+		switch target.Scheme {
+		case "http", "https":
+		case "ws":
+			target.Scheme = "http"
+		case "wss":
+			target.Scheme = "https"
+		default:
+			return fmt.Errorf(i18n.Tf("error.proxy_invalid", rule.Prefix+"="+rule.Target))
+		}
+
+		fs.proxies = append(fs.proxies, proxyRoute{
+			prefix:   prefix,
+			target:   target,
+			request:  rule.Request,
+			response: rule.Response,
+		})
+	}
+
+	// 前缀长的规则优先匹配，例如 "/api/v2" 优先于 "/api"
+	sort.SliceStable(fs.proxies, func(i, j int) bool {
+		return len(fs.proxies[i].prefix) > len(fs.proxies[j].prefix)
+	})
+	return nil
+}
+
+// findProxy 查找匹配请求路径的转发规则，前缀按路径段匹配，没有匹配的规则时返回nil
+func (fs *FileServer) findProxy(reqPath string) *proxyRoute {
+	for i := range fs.proxies {
+		prefix := fs.proxies[i].prefix
+		if reqPath == prefix || strings.HasPrefix(reqPath, prefix+"/") {
+			return &fs.proxies[i]
+		}
+	}
+	return nil
+}
+
+// proxyMiddleware 返回转发中间件，匹配转发规则的请求转发到上游服务，其他请求继续交给文件处理函数
+// 转发时保留原始路径，支持WebSocket升级和SSE等流式响应；
+// 与静态文件一样检查权限，GET、HEAD、OPTIONS需要读取权限，其他方法需要写入权限
+func (fs *FileServer) proxyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := fs.findProxy(c.Request.URL.Path)
+		if route == nil {
+			c.Next()
+			return
+		}
+
+		perm := auth.PermWrite
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			perm = auth.PermRead
+		}
+		if !fs.authorize(c, c.Request.URL.Path, perm) {
+			c.Abort()
+			return
+		}
+
+		fs.newReverseProxy(c, route).ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
+
+// newReverseProxy 为一次请求创建反向代理
+//
+// 请求头的改写:
+//  1. Host 改为上游地址，原始的 Host 放在 X-Forwarded-Host 中
+//  2. 添加 X-Forwarded-For 和 X-Forwarded-Proto
+//  3. 启用了认证时去掉 Authorization 请求头、?token= 查询参数和会话Cookie，避免把访问 servergo 的凭据发给上游
+//  4. 最后应用规则中的 set-header、remove-header 选项，可以覆盖以上的改写
+//
+// 响应头的改写:
+//  1. 指向上游地址的 Location 和 Content-Location 改为相对路径，使重定向仍然经过 servergo
+//  2. 最后应用规则中的 set-response-header、remove-response-header 选项
+func (fs *FileServer) newReverseProxy(c *gin.Context, route *proxyRoute) *httputil.ReverseProxy {
+	target := route.target
+	upstreamOrigin := target.Scheme + "://" + target.Host
+
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(target)
+			r.SetXForwarded()
+			if fs.authenticator != nil && fs.authenticator.AuthType() != auth.NoAuth {
+				r.Out.Header.Del("Authorization")
+				stripCredentials(r.Out)
+			}
+			route.request.apply(r.Out.Header)
+			// Host 不在请求头中，需要单独改写，例如上游的开发服务器只接受 localhost
+			if host := route.request.Set.Get("Host"); host != "" {
+				r.Out.Host = host
+			}
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			for _, name := range []string{"Location", "Content-Location"} {
+				if value := resp.Header.Get(name); strings.HasPrefix(value, upstreamOrigin) {
+					rest := strings.TrimPrefix(value, upstreamOrigin)
+					if !strings.HasPrefix(rest, "/") {
+						rest = "/" + rest
+					}
+					resp.Header.Set(name, rest)
+				}
+			}
+			route.response.apply(resp.Header)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Warning(i18n.Tf("server.proxy_failed", r.URL.Path, target, err))
+			fs.respondError(c, http.StatusBadGateway, i18n.Tf("http.502_proxy", target))
+		},
+	}
+}
+
+// stripCredentials 去掉转发请求中令牌认证的 token 查询参数和表单认证的会话Cookie，其他查询参数和Cookie保持不变
+func stripCredentials(req *http.Request) {
+	if query := req.URL.Query(); query.Has("token") {
+		query.Del("token")
+		req.URL.RawQuery = query.Encode()
+	}
+
+	var kept []string
+	for _, line := range req.Header.Values("Cookie") {
+		for _, cookie := range strings.Split(line, ";") {
+			cookie = strings.TrimSpace(cookie)
+			name, _, _ := strings.Cut(cookie, "=")
+			if cookie != "" && name != auth.SessionCookieName {
+				kept = append(kept, cookie)
+			}
+		}
+	}
+	req.Header.Del("Cookie")
+	if len(kept) > 0 {
+		req.Header.Set("Cookie", strings.Join(kept, "; "))
+	}
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+)
+
+// newUpstream 创建测试用的上游服务
+// 普通请求返回路径和转发相关的请求头，/api/redirect 返回指向上游自身的重定向，/api/ws 模拟WebSocket升级后回显数据
+func newUpstream(t *testing.T) *httptest.Server {
+	var upstream *httptest.Server
+	upstream = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/redirect":
+			http.Redirect(w, r, upstream.URL+"/api/target", http.StatusFound)
+		case "/api/ws":
+			conn, rw, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("Hijack失败: %v", err)
+				return
+			}
+			defer conn.Close()
+			rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+			rw.Flush()
+			line, _ := rw.ReadString('\n')
+			rw.WriteString("echo " + line)
+			rw.Flush()
+		default:
+			fmt.Fprintf(w, "path=%s host=%s forwarded-host=%s auth=%s query=%s cookie=%s",
+				r.URL.Path, r.Host, r.Header.Get("X-Forwarded-Host"), r.Header.Get("Authorization"), r.URL.RawQuery, r.Header.Get("Cookie"))
+		}
+	}))
+	return upstream
+}
+
+// newProxyRouter 创建把 /api 转发到上游服务的测试路由
+func newProxyRouter(t *testing.T, upstreamURL string) *gin.Engine {
+	srv, _, cleanup := setupTestServer(t)
+	t.Cleanup(cleanup)
+
+	srv.config.Proxies = []ProxyRule{{Prefix: "/api", Target: upstreamURL}}
+	if err := srv.setupProxies(); err != nil {
+		t.Fatalf("解析转发规则失败: %v", err)
+	}
+
+	router := gin.New()
+	router.Use(srv.proxyMiddleware())
+	router.NoRoute(srv.handleRequest)
+	return router
+}
+
+// TestParseProxyRule 测试转发参数的解析和检查
+func TestParseProxyRule(t *testing.T) {
+	tests := []struct {
+		spec      string
+		expectErr bool
+	}{
+		{spec: "/api=http://127.0.0.1:3000"},
+		{spec: "api/=https://example.com/base"},
+		{spec: "/ws=ws://127.0.0.1:3001"},
+		{spec: "/api", expectErr: true},
+		{spec: "/api=127.0.0.1:3000", expectErr: true},
+		{spec: "/api=ftp://127.0.0.1", expectErr: true},
+		{spec: "/=http://127.0.0.1:3000", expectErr: true},
+		{spec: "/api=http://127.0.0.1:3000;set-header=X-Env:dev;remove-header=Cookie"},
+		{spec: "/api=http://127.0.0.1:3000; set-response-header=Access-Control-Allow-Origin:*; remove-response-header=Server"},
+		{spec: "/api=http://127.0.0.1:3000;set-header=X-Empty:"},
+		{spec: "/api=http://127.0.0.1:3000;set-header=X-Env", expectErr: true},
+		{spec: "/api=http://127.0.0.1:3000;set-header=Bad Name:x", expectErr: true},
+		{spec: "/api=http://127.0.0.1:3000;remove-header=", expectErr: true},
+		{spec: "/api=http://127.0.0.1:3000;rewrite-header=X-Env:dev", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		rule, err := ParseProxyRule(tt.spec)
+		if err == nil {
+			fs := &FileServer{config: Config{Proxies: []ProxyRule{rule}}}
+			err = fs.setupProxies()
+		}
+		if (err != nil) != tt.expectErr {
+			t.Errorf("%q 错误 = %v, 期望出错 = %v", tt.spec, err, tt.expectErr)
+		}
+	}
+}
+
+// TestProxyRequests 测试匹配的请求被转发，其他请求仍然提供静态文件
+func TestProxyRequests(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+	// httptest.ResponseRecorder 不支持 CloseNotify，转发测试需要使用真实的HTTP服务器
+	proxyServer := httptest.NewServer(newProxyRouter(t, upstream.URL))
+	defer proxyServer.Close()
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	tests := []struct {
+		name             string
+		path             string
+		expectedCode     int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{name: "转发并保留路径", path: "/api/users?id=1", expectedCode: http.StatusOK, expectedBody: "path=/api/users"},
+		{name: "Host改为上游地址", path: "/api", expectedCode: http.StatusOK, expectedBody: "host=" + strings.TrimPrefix(upstream.URL, "http://") + " forwarded-host=example.test"},
+		{name: "重定向改写为相对路径", path: "/api/redirect", expectedCode: http.StatusFound, expectedLocation: "/api/target"},
+		{name: "前缀按路径段匹配", path: "/apix", expectedCode: http.StatusNotFound},
+		{name: "其他路径提供静态文件", path: "/test.txt", expectedCode: http.StatusOK, expectedBody: "Test File Content"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, proxyServer.URL+tt.path, nil)
+			req.Host = "example.test"
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", resp.StatusCode, tt.expectedCode, body)
+			}
+			if !strings.Contains(string(body), tt.expectedBody) {
+				t.Errorf("响应内容 = %q, 期望包含 %q", body, tt.expectedBody)
+			}
+			if location := resp.Header.Get("Location"); location != tt.expectedLocation {
+				t.Errorf("Location = %q, 期望 %q", location, tt.expectedLocation)
+			}
+		})
+	}
+}
+
+// TestProxyHeaderRewrite 测试转发规则中设置和删除请求头、响应头的选项
+func TestProxyHeaderRewrite(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "upstream/1.0")
+		w.Header().Set("X-Upstream", "yes")
+		fmt.Fprintf(w, "host=%s env=%s debug=%s forwarded-for=%s",
+			r.Host, r.Header.Get("X-Env"), r.Header.Get("X-Debug"), r.Header.Get("X-Forwarded-For"))
+	}))
+	defer upstream.Close()
+
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	rule, err := ParseProxyRule("/api=" + upstream.URL +
+		";set-header=Host:localhost;set-header=X-Env:dev;remove-header=X-Debug;remove-header=X-Forwarded-For" +
+		";set-response-header=Access-Control-Allow-Origin:*;remove-response-header=Server")
+	if err != nil {
+		t.Fatalf("ParseProxyRule() 错误: %v", err)
+	}
+	srv.config.Proxies = []ProxyRule{rule}
+	if err := srv.setupProxies(); err != nil {
+		t.Fatalf("解析转发规则失败: %v", err)
+	}
+	router := gin.New()
+	router.Use(srv.proxyMiddleware())
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxyServer.URL+"/api/headers", nil)
+	req.Header.Set("X-Env", "prod")
+	req.Header.Set("X-Debug", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if expected := "host=localhost env=dev debug= forwarded-for="; string(body) != expected {
+		t.Errorf("上游收到的请求头 = %q, 期望 %q", body, expected)
+	}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "Access-Control-Allow-Origin", expected: "*"},
+		{name: "Server", expected: ""},
+		{name: "X-Upstream", expected: "yes"},
+	}
+	for _, tt := range tests {
+		if value := resp.Header.Get(tt.name); value != tt.expected {
+			t.Errorf("响应头 %s = %q, 期望 %q", tt.name, value, tt.expected)
+		}
+	}
+}
+
+// TestProxyStripsCredentials 测试启用认证时不把访问 servergo 的凭据转发给上游
+func TestProxyStripsCredentials(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.authenticator = auth.NewTokenAuth(auth.Config{Token: "tok"})
+	srv.config.Proxies = []ProxyRule{{Prefix: "/api", Target: upstream.URL}}
+	if err := srv.setupProxies(); err != nil {
+		t.Fatalf("解析转发规则失败: %v", err)
+	}
+	router := gin.New()
+	router.Use(srv.proxyMiddleware())
+	proxyServer := httptest.NewServer(router)
+	defer proxyServer.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, proxyServer.URL+"/api/users?token=tok&id=1", nil)
+	req.Header.Set("Authorization", "Bearer tok")
+	req.Header.Set("Cookie", "theme=dark; "+auth.SessionCookieName+"=secret; lang=zh")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if !strings.Contains(string(body), "auth= query=id=1 cookie=theme=dark; lang=zh") {
+		t.Errorf("上游收到的请求 = %q", body)
+	}
+}
+
+// TestProxyUpstreamDown 测试上游服务不可用时返回502
+func TestProxyUpstreamDown(t *testing.T) {
+	upstream := newUpstream(t)
+	upstreamURL := upstream.URL
+	upstream.Close()
+	proxyServer := httptest.NewServer(newProxyRouter(t, upstreamURL))
+	defer proxyServer.Close()
+
+	resp, err := http.Get(proxyServer.URL + "/api/users")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("状态码 = %d, 期望 %d", resp.StatusCode, http.StatusBadGateway)
+	}
+}
+
+// TestProxyWebSocket 测试WebSocket升级请求的转发
+func TestProxyWebSocket(t *testing.T) {
+	upstream := newUpstream(t)
+	defer upstream.Close()
+	proxyServer := httptest.NewServer(newProxyRouter(t, upstream.URL))
+	defer proxyServer.Close()
+
+	conn, err := net.DialTimeout("tcp", strings.TrimPrefix(proxyServer.URL, "http://"), 5*time.Second)
+	if err != nil {
+		t.Fatalf("连接失败: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	io.WriteString(conn, "GET /api/ws HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("读取升级响应失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("状态码 = %d, 期望 %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	io.WriteString(conn, "ping\n")
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("读取回显失败: %v", err)
+	}
+	if line != "echo ping\n" {
+		t.Errorf("回显 = %q, 期望 %q", line, "echo ping\n")
+	}
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/fulltext"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/utils"
+)
+
+// searchPath 文件名搜索的地址
+const searchPath = "/_servergo/search"
+
+// searchMaxResults 搜索结果数量的上限，超过时只返回前面的结果
+const searchMaxResults = 500
+
+// searchRoot 一个需要搜索的目录
+type searchRoot struct {
+	server *FileServer // 负责该目录的服务器（挂载点），用于判断忽略规则
+	dir    string      // 目录的完整路径
+	urlDir string      // 目录对应的URL路径，例如: "/docs/guide"
+}
+
+// itemPath 返回搜索目录中的文件对应的URL路径
+func (root searchRoot) itemPath(fullPath string) (string, bool) {
+	rel, err := filepath.Rel(root.dir, fullPath)
+	if err != nil {
+		return "", false
+	}
+	return path.Join(root.urlDir, filepath.ToSlash(rel)), true
+}
+
+// handleSearch 在目录树中按文件名或文件内容搜索
+// 参数 q 是搜索词，不区分大小写地匹配文件名，包含 *、? 或 [ 时按通配符匹配；参数 path 是开始搜索的目录，默认为根目录
+// 参数 type 为 content 时在全文索引中搜索包含搜索词的行，结果包含文件、行号和该行的内容，需要启用全文索引，搜索词至少3个字符
+// 隐藏文件、被忽略的文件和当前用户没有读取权限的文件不会出现在结果中，被忽略的目录不会进入
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+func (fs *FileServer) handleSearch(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	reqPath := path.Clean("/" + c.DefaultQuery("path", "/"))
+
+	// 搜索会列出文件名，与目录列表一样需要启用目录列表功能
+	if !fs.config.EnableDirListing {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return
+	}
+	if query == "" {
+		fs.respondError(c, http.StatusBadRequest, i18n.T("http.400_search_query"))
+		return
+	}
+	content := c.Query("type") == "content"
+	if content && fs.fullText == nil {
+		fs.respondError(c, http.StatusBadRequest, i18n.T("http.400_fulltext_disabled"))
+		return
+	}
+	if content && utf8.RuneCountInString(query) < fulltext.MinQueryLength {
+		fs.respondError(c, http.StatusBadRequest, i18n.Tf("http.400_search_query_short", fulltext.MinQueryLength))
+		return
+	}
+
+	// 搜索与目录列表一样需要搜索目录的读取和列表权限
+	if !fs.authorize(c, reqPath, auth.PermRead) || !fs.authorize(c, reqPath, auth.PermList) {
+		return
+	}
+
+	roots := fs.searchRoots(reqPath)
+	if len(roots) == 0 {
+		fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
+		return
+	}
+
+	data := dirlist.TemplateData{
+		DirPath:     reqPath,
+		ParentDir:   reqPath, // 返回链接指向搜索的目录
+		CurrentTime: time.Now().Format("2006-01-02 15:04:05"),
+		Search:      &dirlist.SearchInfo{Query: query},
+	}
+
+	base := strings.TrimSuffix(reqPath, "/") + "/"
+	if content {
+		matches, truncated := fs.fullText.search(c.Request.Context(), roots, base, query, func(itemPath string) bool {
+			return fs.allowed(c, itemPath, auth.PermRead) && fs.ancestorsListable(c, base, itemPath)
+		})
+		data.Search.Content = true
+		data.Search.Indexing = !fs.fullText.ready.Load()
+		data.Search.Matches = matches
+		data.Search.Count = len(matches)
+		data.Search.Truncated = truncated
+	} else {
+		match := newNameMatcher(query)
+		items := make([]dirlist.FileItem, 0)
+		truncated := false
+		for _, root := range roots {
+			truncated = root.server.searchTree(c, root, base, match, &items)
+			if truncated {
+				break
+			}
+		}
+		data.Items = items
+		data.Search.Count = len(items)
+		data.Search.Truncated = truncated
+	}
+
+	// 客户端偏好JSON时，即使使用HTML主题也返回JSON格式的结果
+	if prefersJSON(c.GetHeader("Accept")) {
+		body, err := dirlist.RenderSearchJSON(data)
+		if err != nil {
+			fs.respondError(c, http.StatusInternalServerError, i18n.Tf("http.500_template", err))
+			return
+		}
+		c.Data(http.StatusOK, "application/json", []byte(body))
+		return
+	}
+	fs.renderPage(c, data)
+}
+
+// searchRoots 返回请求路径对应的搜索目录
+// 配置了挂载点时，挂载点之外的虚拟目录（例如根路径）会搜索位于其下的所有挂载点
+func (fs *FileServer) searchRoots(reqPath string) []searchRoot {
+	if len(fs.mounts) == 0 {
+		if dir, ok := fs.searchDir(reqPath); ok {
+			return []searchRoot{{server: fs, dir: dir, urlDir: reqPath}}
+		}
+		return nil
+	}
+
+	if m := fs.findMount(reqPath); m != nil {
+		return m.searchRoots(reqPath)
+	}
+
+	base := strings.TrimSuffix(reqPath, "/") + "/"
+	var roots []searchRoot
+	for _, m := range fs.mounts {
+		if strings.HasPrefix(m.prefix, base) {
+			roots = append(roots, searchRoot{server: m, dir: m.absDir, urlDir: m.prefix})
+		}
+	}
+	return roots
+}
+
+// searchDir 把请求路径解析为可以搜索的目录，路径不存在、不是目录或被忽略时返回false
+func (fs *FileServer) searchDir(reqPath string) (string, bool) {
+	fullPath, err := fs.resolvePath(reqPath)
+	if err != nil || fs.checkRealPath(fullPath) != nil {
+		return "", false
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil || !info.IsDir() || fs.isExcluded(fullPath, true) {
+		return "", false
+	}
+	return fullPath, true
+}
+
+// searchTree 遍历目录树，把匹配的文件添加到 items 中
+// 不跟随符号链接，客户端断开连接时停止搜索
+//
+// 返回值:
+//   - bool: 结果数量达到上限时返回true
+func (fs *FileServer) searchTree(c *gin.Context, root searchRoot, base string, match func(string) bool, items *[]dirlist.FileItem) bool {
+	truncated := false
+	filepath.WalkDir(root.dir, func(p string, d os.DirEntry, err error) error {
+		if c.Request.Context().Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		if p == root.dir {
+			return nil
+		}
+		if fs.isExcluded(p, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, _ := filepath.Rel(root.dir, p)
+		itemPath := path.Join(root.urlDir, filepath.ToSlash(rel))
+		if !fs.allowed(c, itemPath, auth.PermRead) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// 与打包下载一样，不搜索没有列表权限的子目录中的内容，目录本身仍然可以出现在结果中
+		var next error
+		if d.IsDir() && !fs.allowed(c, itemPath, auth.PermList) {
+			next = filepath.SkipDir
+		}
+		if !match(d.Name()) {
+			return next
+		}
+		if len(*items) >= searchMaxResults {
+			truncated = true
+			return filepath.SkipAll
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return next
+		}
+
+		item := dirlist.FileItem{
+			Name:         strings.TrimPrefix(itemPath, base), // 相对于搜索目录的路径，例如: "a/b/report.pdf"
+			IsDir:        d.IsDir(),
+			Size:         "-",
+			LastModified: info.ModTime().Format("2006-01-02 15:04:05"),
+			Path:         itemPath,
+		}
+		if !d.IsDir() {
+			item.SizeBytes = info.Size()
+			item.Size = utils.FormatSize(info.Size())
+			item.ViewURL = fs.sourceViewURL(itemPath, d.Name(), info.Size())
+		}
+		*items = append(*items, item)
+		return next
+	})
+	return truncated
+}
+
+// newNameMatcher 根据搜索词创建文件名匹配函数
+// 搜索词包含 *、? 或 [ 时按通配符匹配整个文件名，否则按子串匹配，都不区分大小写
+func newNameMatcher(query string) func(string) bool {
+	query = strings.ToLower(query)
+	if strings.ContainsAny(query, "*?[") {
+		return func(name string) bool {
+			matched, _ := path.Match(query, strings.ToLower(name))
+			return matched
+		}
+	}
+	return func(name string) bool {
+		return strings.Contains(strings.ToLower(name), query)
+	}
+}
+
+// ancestorsListable 判断搜索目录 base 与 itemPath 之间的每一级子目录是否都可以读取和查看列表
+// 全文搜索的结果来自索引，不经过 searchTree 的目录检查，需要逐级检查
+func (fs *FileServer) ancestorsListable(c *gin.Context, base, itemPath string) bool {
+	for dir := path.Dir(itemPath); len(dir) >= len(base) && dir != "/"; dir = path.Dir(dir) {
+		if !fs.allowed(c, dir, auth.PermRead) || !fs.allowed(c, dir, auth.PermList) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newSearchRouter 创建带有搜索路由的测试路由，测试目录中额外包含多级目录和隐藏目录
+func newSearchRouter(t *testing.T) (*FileServer, *gin.Engine) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	t.Cleanup(cleanup)
+
+	files := []string{
+		"builds/2024/q1/Report-final.pdf",
+		"builds/2024/q2/report.txt",
+		".secret/report.txt",
+	}
+	for _, name := range files {
+		fullPath := filepath.Join(tempDir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(fullPath), 0755)
+		if err := os.WriteFile(fullPath, []byte(name), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+
+	router := gin.New()
+	router.GET(searchPath, srv.handleSearch)
+	router.NoRoute(srv.handleRequest)
+	return srv, router
+}
+
+// TestSearch 测试文件名搜索
+func TestSearch(t *testing.T) {
+	_, router := newSearchRouter(t)
+
+	tests := []struct {
+		name         string
+		query        string
+		expectedCode int
+		expectedBody []string
+		excludedBody []string
+	}{
+		{
+			name:         "不区分大小写的子串匹配",
+			query:        "?q=report",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"builds/2024/q1/Report-final.pdf", "/builds/2024/q2/report.txt", "2 个结果"},
+			excludedBody: []string{".secret"},
+		},
+		{
+			name:         "通配符匹配",
+			query:        "?q=*.pdf",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"Report-final.pdf"},
+			excludedBody: []string{"report.txt"},
+		},
+		{
+			name:         "在子目录中搜索",
+			query:        "?q=report&path=/builds/2024/q2",
+			expectedCode: http.StatusOK,
+			expectedBody: []string{`href="/builds/2024/q2/report.txt"`},
+			excludedBody: []string{"Report-final.pdf"},
+		},
+		{name: "缺少搜索词", query: "?path=/builds", expectedCode: http.StatusBadRequest},
+		{name: "目录不存在", query: "?q=report&path=/missing", expectedCode: http.StatusNotFound},
+		{name: "不能搜索隐藏目录", query: "?q=report&path=/.secret", expectedCode: http.StatusNotFound},
+		{name: "不能越出根目录", query: "?q=passwd&path=/../../etc", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, searchPath+tt.query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("响应中应该包含 %q", s)
+				}
+			}
+			for _, s := range tt.excludedBody {
+				if strings.Contains(body, s) {
+					t.Errorf("响应中不应该包含 %q", s)
+				}
+			}
+		})
+	}
+}
+
+// TestSearchJSON 测试客户端偏好JSON时返回JSON格式的搜索结果
+func TestSearchJSON(t *testing.T) {
+	_, router := newSearchRouter(t)
+
+	req, _ := http.NewRequest(http.MethodGet, searchPath+"?q=report&path=/builds", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var result struct {
+		Query   string `json:"query"`
+		Path    string `json:"path"`
+		Count   int    `json:"count"`
+		Results []struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("响应不是JSON: %v, 响应: %s", err, w.Body.String())
+	}
+	if result.Query != "report" || result.Path != "/builds" || result.Count != 2 {
+		t.Errorf("搜索信息 = %+v", result)
+	}
+	if len(result.Results) != 2 || result.Results[0].Name != "2024/q1/Report-final.pdf" || result.Results[0].URL != "/builds/2024/q1/Report-final.pdf" {
+		t.Errorf("搜索结果 = %+v", result.Results)
+	}
+}
+
+// TestSearchDisabledListing 测试未启用目录列表时不能搜索
+func TestSearchDisabledListing(t *testing.T) {
+	srv, router := newSearchRouter(t)
+	srv.config.EnableDirListing = false
+
+	req, _ := http.NewRequest(http.MethodGet, searchPath+"?q=report", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("状态码 = %d, 期望 %d", w.Code, http.StatusForbidden)
+	}
+}
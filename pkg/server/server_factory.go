@@ -41,19 +41,14 @@ import (
 //
 // ```
 func New(config Config) (*FileServer, error) {
-	// 获取绝对路径
-	absDir, err := filepath.Abs(config.Dir)
-	if err != nil {
-		return nil, fmt.Errorf(i18n.T("error.dir_not_exist"), config.Dir)
-	}
-
-	// 检查目录是否存在
-	info, err := os.Stat(absDir)
-	if err != nil {
-		return nil, fmt.Errorf(i18n.Tf("error.dir_not_exist", absDir))
-	}
-	if !info.IsDir() {
-		return nil, fmt.Errorf(i18n.Tf("error.not_a_directory", absDir))
+	// 配置了挂载点时，Dir 不再使用，每个挂载点的目录在 setupMounts 中检查
+	var absDir, realDir string
+	if len(config.Mounts) == 0 {
+		var err error
+		absDir, realDir, err = resolveServedDir(config.Dir)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// 设置Gin为生产模式，避免debug信息
@@ -62,18 +57,63 @@ func New(config Config) (*FileServer, error) {
 	// 创建一个默认的Gin引擎
 	engine := gin.New()
 
-	// 使用自定义的日志中间件和恢复中间件
-	engine.Use(logger.DefaultGinLogger(), gin.Recovery())
+	// 设置可信代理，访问日志中的客户端地址只使用可信代理转发的请求头
+	trusted, err := setupTrustedProxies(engine, config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	// PROXY协议头部中的客户端地址无法验证，必须限制只有可信代理可以发送，否则任何客户端都可以伪造来源地址
+	if config.ProxyProtocol && len(trusted) == 0 {
+		return nil, fmt.Errorf(i18n.T("error.proxy_protocol_untrusted"))
+	}
+
+	// 使用自定义的日志中间件和恢复中间件，来自Unix套接字的请求先设置客户端地址
+	engine.Use(unixClientIPMiddleware(trusted), logger.DefaultGinLogger(), gin.Recovery())
+
+	// 读取htpasswd用户文件
+	var users *auth.HtpasswdFile
+	if config.Htpasswd != "" {
+		users, err = auth.LoadHtpasswd(config.Htpasswd)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.Tf("error.htpasswd_load_failed", err))
+		}
+	}
+
+	// 读取托管令牌文件，文件不存在时没有托管令牌，之后通过 servergo token create 创建的令牌无需重启即可使用
+	var tokens *auth.TokenStore
+	if config.TokenFile != "" {
+		tokens, err = auth.OpenTokenStore(config.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.Tf("error.token_store_load_failed", err))
+		}
+	}
 
 	// 创建认证器
 	authenticator := auth.NewAuthenticator(auth.Config{
-		Type:            config.AuthType,
-		Username:        config.Username,
-		Password:        config.Password,
-		Token:           config.Token,
-		EnableLoginPage: config.EnableLoginPage,
+		Type:               config.AuthType,
+		Types:              config.AuthTypes,
+		Username:           config.Username,
+		Password:           config.Password,
+		Token:              config.Token,
+		EnableLoginPage:    config.EnableLoginPage,
+		SecureCookie:       config.TLSEnabled(),
+		SessionMaxAge:      config.SessionMaxAge,
+		SessionIdleTimeout: config.SessionIdleTimeout,
+		RememberMeMaxAge:   config.RememberMeMaxAge,
+		Users:              users,
+		Tokens:             tokens,
 	})
 
+	// 读取访问控制策略，没有配置时不限制权限
+	var authorizer auth.Authorizer = auth.NewAllowAll()
+	if config.ACLPolicy != "" {
+		authorizer, err = auth.LoadPolicy(config.ACLPolicy)
+		if err != nil {
+			return nil, fmt.Errorf(i18n.Tf("error.acl_load_failed", err))
+		}
+	}
+
 	// 如果未设置主题，使用默认主题
 	theme := config.Theme
 	if theme == "" {
@@ -98,11 +138,81 @@ func New(config Config) (*FileServer, error) {
 	// 额外记录成功加载的主题信息
 	logger.Info(fmt.Sprintf("成功加载目录列表主题: %s", dirTemplate.GetTheme()))
 
-	return &FileServer{
-		config:        config,
-		absDir:        absDir,
-		engine:        engine,
-		authenticator: authenticator,
-		dirTemplate:   dirTemplate,
-	}, nil
+	fs := &FileServer{
+		config:         config,
+		absDir:         absDir,
+		realDir:        realDir,
+		engine:         engine,
+		authenticator:  authenticator,
+		authorizer:     authorizer,
+		dirTemplate:    dirTemplate,
+		trustedProxies: trusted,
+	}
+
+	// 读取忽略规则，配置了挂载点时每个挂载点分别读取
+	if len(config.Mounts) > 0 {
+		if err := fs.setupMounts(); err != nil {
+			return nil, err
+		}
+	} else if err := fs.loadIgnoreRules(); err != nil {
+		return nil, err
+	}
+
+	// 如果启用了实时刷新，创建实时刷新器，启动服务器时才开始监视文件
+	if config.LiveReload {
+		fs.liveReload = fs.newLiveReloader()
+	}
+
+	// 如果启用了全文索引，创建索引器，启动服务器时才开始建立索引
+	if config.FullTextIndex {
+		fs.fullText = fs.newContentIndexer()
+	}
+
+	// 解析反向代理规则
+	if err := fs.setupProxies(); err != nil {
+		return nil, err
+	}
+
+	// 如果启用了HTTPS，加载或生成证书
+	if err := fs.setupTLS(); err != nil {
+		return nil, err
+	}
+
+	// 如果启用了WebDAV，创建WebDAV处理器
+	if config.EnableWebDAV && len(config.Mounts) == 0 {
+		fs.davHandler = fs.newWebDAVHandler()
+	}
+
+	return fs, nil
+}
+
+// resolveServedDir 检查要提供服务的目录是否存在
+//
+// 返回值:
+//   - absDir: 目录的绝对路径，例如: "/home/user/files"
+//   - realDir: 解析符号链接后的路径，用于符号链接安全检查
+//   - err: 目录不存在或不是目录时返回错误
+func resolveServedDir(dir string) (absDir, realDir string, err error) {
+	// 获取绝对路径
+	absDir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf(i18n.T("error.dir_not_exist"), dir)
+	}
+
+	// 检查目录是否存在
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return "", "", fmt.Errorf(i18n.Tf("error.dir_not_exist", absDir))
+	}
+	if !info.IsDir() {
+		return "", "", fmt.Errorf(i18n.Tf("error.not_a_directory", absDir))
+	}
+
+	// 解析服务目录本身的符号链接，例如macOS上的 /tmp -> /private/tmp
+	realDir, err = filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return "", "", fmt.Errorf(i18n.Tf("error.dir_not_exist", absDir))
+	}
+
+	return absDir, realDir, nil
 }
@@ -1,8 +1,12 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/CC11001100/servergo/pkg/auth"
@@ -12,22 +16,254 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// DefaultShutdownTimeout 未配置 Config.ShutdownTimeout 时，优雅关闭等待进行中请求完成的时间
+const DefaultShutdownTimeout = 10 * time.Second
+
 // Start 启动文件服务器
-// 此方法会阻塞执行，直到服务器停止或出错
+// 此方法会阻塞执行，直到 ctx 被取消或调用了 Shutdown；ctx 取消后会在宽限期内
+// 等待进行中的请求（例如大文件下载）完成再返回
+//
+// 参数:
+//   - ctx: 控制服务器生命周期的上下文，取消后服务器开始优雅关闭
 //
 // 返回值:
-//   - error: 如果服务器启动失败，返回错误信息
+//   - error: 如果服务器启动失败，返回错误信息；正常关闭时返回nil
 //
 // 使用示例:
 // ```
-// err := srv.Start()
+// ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+// defer stop()
+// err := srv.Start(ctx)
 //
 //	if err != nil {
 //	    log.Fatalf("服务器启动失败: %v", err)
 //	}
 //
 // ```
-func (fs *FileServer) Start() error {
+func (fs *FileServer) Start(ctx context.Context) error {
+	// 如果还没有监听端口，先监听
+	if err := fs.Listen(); err != nil {
+		return err
+	}
+
+	fs.routesOnce.Do(fs.setupRoutes)
+	fs.printStartupInfo()
+
+	// 开始监视文件变化，监视失败时只是不能自动刷新，不影响提供文件
+	if fs.liveReload != nil {
+		if err := fs.liveReload.start(); err != nil {
+			logger.Warning(i18n.Tf("server.live_reload_error", err))
+		}
+	}
+
+	// 在后台建立全文索引，建立完成前搜索结果可能不完整
+	if fs.fullText != nil {
+		if err := fs.fullText.start(); err != nil {
+			logger.Warning(i18n.Tf("server.fulltext_error", err))
+		}
+	}
+
+	httpServer := &http.Server{
+		Handler:     fs.engine.Handler(),
+		ConnContext: markUnixConn,
+	}
+	fs.mu.Lock()
+	fs.httpServer = httpServer
+	listeners := fs.listeners
+	fs.mu.Unlock()
+
+	// ctx 取消后在后台执行优雅关闭
+	stopped := make(chan struct{})
+	shutdownErr := make(chan error, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			logger.Info(i18n.Tf("server.shutting_down", fs.shutdownTimeout()))
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), fs.shutdownTimeout())
+			defer cancel()
+			shutdownErr <- fs.Shutdown(shutdownCtx)
+		case <-stopped:
+			shutdownErr <- nil
+		}
+	}()
+
+	// 所有监听器共用同一个HTTP服务器
+	serveErr := make(chan error, len(listeners))
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			if fs.certFile != "" {
+				serveErr <- httpServer.ServeTLS(listener, fs.certFile, fs.keyFile)
+			} else {
+				serveErr <- httpServer.Serve(listener)
+			}
+		}(listener)
+	}
+
+	// 任何一个监听器出错时关闭整个服务器
+	var err error
+	for range listeners {
+		if e := <-serveErr; err == nil && !errors.Is(e, http.ErrServerClosed) {
+			err = e
+			httpServer.Close()
+		}
+	}
+	close(stopped)
+
+	// Serve 在 Shutdown 开始时立即返回，需要等待进行中的请求处理完毕
+	if err == nil {
+		return <-shutdownErr
+	}
+	return err
+}
+
+// Listen 在配置的地址上开始监听，但还不处理请求
+// 配置了 Config.Bind 时在每个地址上分别监听，否则监听所有接口
+// 端口为0时由系统分配一个空闲端口，不带端口的地址共用这个端口，可以通过 Addr 获取实际地址，便于测试
+// Start 会在需要时自动调用此方法
+//
+// 返回值:
+//   - error: 地址格式错误、端口被占用或无权限监听时返回错误，已经打开的监听器会被关闭
+func (fs *FileServer) Listen() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if len(fs.listeners) > 0 {
+		return nil
+	}
+
+	addrs, err := fs.bindAddrs()
+	if err != nil {
+		return err
+	}
+
+	port := fs.config.Port
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		listener, err := fs.listenAddr(addr, port)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+		if tcpAddr, ok := listener.Addr().(*net.TCPAddr); ok && addr.port == 0 && port == 0 {
+			port = tcpAddr.Port
+		}
+		listeners = append(listeners, listener)
+	}
+
+	fs.listeners = listeners
+	for _, listener := range listeners {
+		if tcpAddr, ok := listener.Addr().(*net.TCPAddr); ok {
+			fs.config.Port = tcpAddr.Port
+			break
+		}
+	}
+	return nil
+}
+
+// listenAddr 监听一个地址，启用了PROXY协议时TCP监听器会先读取代理发送的头部
+//
+// 参数:
+//   - addr: 监听地址
+//   - port: 监听地址没有指定端口时使用的端口，为0时由系统分配
+func (fs *FileServer) listenAddr(addr bindAddr, port int) (net.Listener, error) {
+	if addr.unixPath != "" {
+		return listenUnix(addr.unixPath)
+	}
+
+	if addr.port != 0 {
+		port = addr.port
+	}
+	listener, err := net.Listen("tcp", net.JoinHostPort(addr.host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+	if fs.config.ProxyProtocol {
+		listener = fs.proxyProtocolListener(listener)
+	}
+	return listener, nil
+}
+
+// Addr 返回服务器实际监听的第一个地址，尚未监听时返回nil
+func (fs *FileServer) Addr() net.Addr {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if len(fs.listeners) == 0 {
+		return nil
+	}
+	return fs.listeners[0].Addr()
+}
+
+// Addrs 返回服务器实际监听的所有地址，尚未监听时返回nil
+func (fs *FileServer) Addrs() []net.Addr {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	addrs := make([]net.Addr, 0, len(fs.listeners))
+	for _, listener := range fs.listeners {
+		addrs = append(addrs, listener.Addr())
+	}
+	if len(addrs) == 0 {
+		return nil
+	}
+	return addrs
+}
+
+// Shutdown 优雅地关闭服务器
+// 立即停止接受新连接，并等待进行中的请求完成；ctx 到期后强制断开剩余连接
+//
+// 参数:
+//   - ctx: 控制等待时长的上下文
+//
+// 返回值:
+//   - error: 宽限期内没有完成所有请求时返回 ctx 的错误
+func (fs *FileServer) Shutdown(ctx context.Context) error {
+	// 先结束实时刷新的SSE长连接，否则优雅关闭会一直等待这些连接
+	if fs.liveReload != nil {
+		fs.liveReload.close()
+	}
+	if fs.fullText != nil {
+		fs.fullText.close()
+	}
+
+	fs.mu.Lock()
+	httpServer := fs.httpServer
+	listeners := fs.listeners
+	fs.mu.Unlock()
+
+	// 只监听了端口还没有开始服务，直接关闭监听器
+	if httpServer == nil {
+		var err error
+		for _, listener := range listeners {
+			if e := listener.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+		return err
+	}
+
+	if err := httpServer.Shutdown(ctx); err != nil {
+		logger.Warning(i18n.T("server.shutdown_forced"))
+		httpServer.Close()
+		return err
+	}
+
+	logger.Info(i18n.T("server.stopped"))
+	return nil
+}
+
+// shutdownTimeout 返回优雅关闭的宽限期
+func (fs *FileServer) shutdownTimeout() time.Duration {
+	if fs.config.ShutdownTimeout > 0 {
+		return fs.config.ShutdownTimeout
+	}
+	return DefaultShutdownTimeout
+}
+
+// setupRoutes 注册中间件和路由，只会执行一次
+func (fs *FileServer) setupRoutes() {
 	// 如果是表单认证并且启用了登录页面，设置表单认证的路由
 	if fs.authenticator.AuthType() == auth.FormAuth && fs.authenticator.LoginPageEnabled() {
 		formAuth, ok := fs.authenticator.(*auth.FormAuthenticator)
@@ -42,6 +278,7 @@ func (fs *FileServer) Start() error {
 	// 添加一个自定义路由，记录文件访问信息
 	fs.engine.Use(func(c *gin.Context) {
 		start := time.Now()
+		method := c.Request.Method
 		path := c.Request.URL.Path
 
 		// 继续处理请求
@@ -53,21 +290,71 @@ func (fs *FileServer) Start() error {
 		clientIP := c.ClientIP()
 		latency := time.Since(start)
 
-		logger.Info("GET | %s | %d | %d | %s | %.3fms",
-			path, status, size, clientIP, float64(latency.Microseconds())/1000.0)
+		logger.Info("%s | %s | %d | %d | %s | %.3fms",
+			method, path, status, size, clientIP, float64(latency.Microseconds())/1000.0)
 	})
 
+	// 启用压缩时，所有响应都经过压缩中间件
+	if fs.config.EnableCompression {
+		fs.engine.Use(fs.compressionMiddleware())
+	}
+
+	// 配置了反向代理时，匹配的请求在文件处理函数之前转发到上游服务
+	if len(fs.proxies) > 0 {
+		fs.engine.Use(fs.proxyMiddleware())
+	}
+
+	// 实时刷新的SSE事件地址
+	if fs.liveReload != nil {
+		fs.engine.GET(liveReloadPath, fs.handleLiveReloadEvents)
+	}
+
+	// 文件名搜索和全文搜索，与其他请求一样经过认证中间件
+	fs.engine.GET(searchPath, fs.handleSearch)
+
 	// 提供模板静态资源，使用特定路由前缀
 	// /_servergo_assets 路径下的资源会被提供给客户端，如CSS、JS文件
 	staticFS := dirlist.GetStaticAssets()
 	fs.engine.StaticFS("/_servergo_assets", http.FS(staticFS))
 
 	// 使用NoRoute处理所有未匹配的路由
-	fs.engine.NoRoute(fs.handleFileRequest)
+	fs.engine.NoRoute(fs.handleRequest)
+}
 
+// printStartupInfo 打印服务器地址、功能开关和认证信息
+func (fs *FileServer) printStartupInfo() {
 	// 打印服务器信息
-	logger.Info(i18n.Tf("server.starting", fs.config.Port))
-	logger.Info(i18n.Tf("server.serving_dir", fs.absDir))
+	logger.Info(i18n.Tf("server.starting", fs.URL()))
+	if len(fs.config.Bind) > 0 {
+		for _, addr := range fs.Addrs() {
+			if addr.Network() == "unix" {
+				logger.Info(i18n.Tf("server.listening", unixPrefix+addr.String()))
+				continue
+			}
+			logger.Info(i18n.Tf("server.listening", addr))
+		}
+	}
+	if len(fs.mounts) == 0 {
+		logger.Info(i18n.Tf("server.serving_dir", fs.absDir))
+	}
+	for _, m := range fs.config.Mounts {
+		logger.Info(i18n.Tf("server.mount", normalizeMountPrefix(m.Prefix), m.Dir))
+	}
+	for _, p := range fs.proxies {
+		logger.Info(i18n.Tf("server.proxy", p.prefix, p.target))
+	}
+	if len(fs.config.TrustedProxies) > 0 {
+		logger.Info(i18n.Tf("server.trusted_proxies", strings.Join(fs.config.TrustedProxies, ", ")))
+	}
+	if fs.config.ProxyProtocol {
+		logger.Info(i18n.T("server.proxy_protocol_enabled"))
+	}
+
+	// 打印HTTPS证书信息，方便客户端核对自签名证书
+	if fs.certFile != "" {
+		logger.Info(i18n.Tf("server.tls_enabled", fs.certFile))
+		logger.Info(i18n.Tf("server.tls_fingerprint", fs.certFingerprint))
+	}
 
 	// 打印目录列表状态
 	if fs.config.EnableDirListing {
@@ -76,36 +363,84 @@ func (fs *FileServer) Start() error {
 		logger.Info(i18n.T("server.dir_listing_disabled"))
 	}
 
-	// 打印认证信息
-	switch fs.authenticator.AuthType() {
-	case auth.NoAuth:
-		logger.Info(i18n.T("auth.disabled"))
-	case auth.BasicAuth:
-		logger.Info(i18n.T("auth.basic_enabled"))
-		username, password := fs.authenticator.GetCredentials()
-		logger.Info("\033[1;32m认证信息:\033[0m")
-		logger.Info("\033[1;34m用户名:\033[0m \033[1;33m%s\033[0m", username)
-		logger.Info("\033[1;34m密  码:\033[0m \033[1;33m%s\033[0m", password)
-	case auth.TokenAuth:
-		logger.Info(i18n.T("auth.token_enabled"))
-		_, token := fs.authenticator.GetCredentials()
-		logger.Info(i18n.Tf("auth.token_access", token))
-	case auth.FormAuth:
-		logger.Info(i18n.T("auth.form_enabled"))
-		if fs.authenticator.LoginPageEnabled() {
-			logger.Info(i18n.T("auth.login_page_enabled"))
-			username, password := fs.authenticator.GetCredentials()
+	// 打印单页应用模式状态
+	if fs.config.SPA {
+		logger.Info(i18n.T("server.spa_enabled"))
+	}
+
+	// 打印文件上传状态
+	if fs.config.EnableUpload {
+		logger.Info(i18n.T("server.upload_enabled"))
+	}
+
+	// 打印实时刷新状态
+	if fs.liveReload != nil {
+		logger.Info(i18n.T("server.live_reload_enabled"))
+	}
+
+	// 打印全文索引状态
+	if fs.fullText != nil {
+		logger.Info(i18n.T("server.fulltext_enabled"))
+	}
+
+	// 打印压缩状态
+	if fs.config.EnableCompression {
+		logger.Info(i18n.T("server.compression_enabled"))
+	}
+
+	// 打印WebDAV状态
+	if fs.config.EnableWebDAV {
+		logger.Info(i18n.Tf("server.webdav_enabled", fs.URL()))
+	}
+
+	// 打印认证信息，同时启用多种认证方式时依次打印每种方式
+	authenticators := []auth.Authenticator{fs.authenticator}
+	if chain, ok := fs.authenticator.(*auth.ChainAuthenticator); ok {
+		authenticators = chain.Authenticators()
+	}
+	for _, authenticator := range authenticators {
+		switch authenticator.AuthType() {
+		case auth.NoAuth:
+			logger.Info(i18n.T("auth.disabled"))
+		case auth.BasicAuth:
+			logger.Info(i18n.T("auth.basic_enabled"))
+			if fs.config.Htpasswd != "" {
+				logger.Info(i18n.Tf("auth.htpasswd_users", fs.config.Htpasswd))
+				break
+			}
+			username, password := authenticator.GetCredentials()
 			logger.Info("\033[1;32m认证信息:\033[0m")
-			logger.Info("\033[1;34m登录地址:\033[0m \033[1;36mhttp://localhost:%d/auth/login\033[0m", fs.config.Port)
 			logger.Info("\033[1;34m用户名:\033[0m \033[1;33m%s\033[0m", username)
 			logger.Info("\033[1;34m密  码:\033[0m \033[1;33m%s\033[0m", password)
+		case auth.TokenAuth:
+			logger.Info(i18n.T("auth.token_enabled"))
+			_, token := authenticator.GetCredentials()
+			logger.Info(i18n.Tf("auth.token_access", token))
+			if ta, ok := authenticator.(*auth.TokenAuthenticator); ok && ta.Tokens() != nil {
+				logger.Info(i18n.Tf("auth.managed_tokens", len(ta.Tokens().Tokens()), ta.Tokens().Path()))
+			}
+		case auth.FormAuth:
+			logger.Info(i18n.T("auth.form_enabled"))
+			if authenticator.LoginPageEnabled() {
+				logger.Info(i18n.T("auth.login_page_enabled"))
+				logger.Info("\033[1;32m认证信息:\033[0m")
+				logger.Info("\033[1;34m登录地址:\033[0m \033[1;36m%s/auth/login\033[0m", fs.URL())
+				if fs.config.Htpasswd != "" {
+					logger.Info(i18n.Tf("auth.htpasswd_users", fs.config.Htpasswd))
+					break
+				}
+				username, password := authenticator.GetCredentials()
+				logger.Info("\033[1;34m用户名:\033[0m \033[1;33m%s\033[0m", username)
+				logger.Info("\033[1;34m密  码:\033[0m \033[1;33m%s\033[0m", password)
+			}
 		}
 	}
 
+	// 打印访问控制策略
+	if fs.config.ACLPolicy != "" {
+		logger.Info(i18n.Tf("auth.acl_enabled", fs.config.ACLPolicy))
+	}
+
 	// 提示用户如何停止服务器
 	logger.Info(i18n.T("server.press_ctrl_c"))
-
-	// 启动服务器
-	hostAddr := ":" + strconv.Itoa(fs.config.Port)
-	return fs.engine.Run(hostAddr)
 }
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -9,6 +11,7 @@ import (
 	"time"
 
 	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/gin-gonic/gin"
 )
 
 // TestNew 测试服务器实例的创建
@@ -113,10 +116,9 @@ func TestStartServer(t *testing.T) {
 		t.Fatalf("创建测试文件失败: %v", err)
 	}
 
-	// 使用一个随机端口创建服务器
-	port := 8090 // 假设这个端口可用
+	// 端口为0时由系统分配空闲端口
 	config := Config{
-		Port:             port,
+		Port:             0,
 		Dir:              tempDir,
 		AuthType:         auth.NoAuth,
 		EnableDirListing: true,
@@ -127,22 +129,22 @@ func TestStartServer(t *testing.T) {
 	if err != nil {
 		t.Fatalf("创建服务器失败: %v", err)
 	}
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("监听端口失败: %v", err)
+	}
 
 	// 在后台启动服务器
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
 	go func() {
-		err := srv.Start()
-		if err != nil && err != http.ErrServerClosed {
-			t.Errorf("启动服务器失败: %v", err)
-		}
+		done <- srv.Start(ctx)
 	}()
 
-	// 给服务器一点启动时间
-	time.Sleep(100 * time.Millisecond)
-
 	// 测试访问文件
-	url := "http://localhost:8090/test.txt"
+	url := fmt.Sprintf("http://%s/test.txt", srv.Addr())
 	resp, err := http.Get(url)
 	if err != nil {
+		cancel()
 		t.Fatalf("请求文件失败: %v", err)
 	}
 	defer resp.Body.Close()
@@ -161,6 +163,70 @@ func TestStartServer(t *testing.T) {
 		t.Errorf("文件内容 = %q, 期望 %q", string(body), testContent)
 	}
 
-	// 注意：这个测试不会实际停止服务器，因为没有好的方法在测试中停止它
-	// 在实际应用中，我们通常会使用 context.Context 和 server.Shutdown() 进行优雅关闭
+	// 取消上下文后服务器应该优雅关闭并释放端口
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("服务器关闭返回错误: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("服务器没有在取消后关闭")
+	}
+
+	if _, err := http.Get(url); err == nil {
+		t.Errorf("服务器关闭后仍然可以访问")
+	}
+}
+
+// TestShutdownDrainsRequests 测试优雅关闭会等待进行中的请求完成
+func TestShutdownDrainsRequests(t *testing.T) {
+	tempDir := t.TempDir()
+
+	srv, err := New(Config{Dir: tempDir, AuthType: auth.NoAuth})
+	if err != nil {
+		t.Fatalf("创建服务器失败: %v", err)
+	}
+
+	// 注册一个慢速路由，模拟进行中的下载
+	started := make(chan struct{})
+	srv.engine.GET("/slow", func(c *gin.Context) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.String(http.StatusOK, "done")
+	})
+
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("监听端口失败: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Start(context.Background())
+	}()
+
+	result := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(fmt.Sprintf("http://%s/slow", srv.Addr()))
+		if err != nil {
+			result <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		result <- string(body)
+	}()
+
+	<-started
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown() 错误: %v", err)
+	}
+
+	if got := <-result; got != "done" {
+		t.Errorf("进行中的请求结果 = %q, 期望 %q", got, "done")
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Start() 返回错误: %v", err)
+	}
 }
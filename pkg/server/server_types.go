@@ -1,10 +1,18 @@
 package server
 
 import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"golang.org/x/net/webdav"
 
 	"github.com/CC11001100/servergo/pkg/auth"
 	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/ignore"
+	"github.com/CC11001100/servergo/pkg/thumbnail"
 )
 
 // Config 保存文件服务器的配置
@@ -13,16 +21,85 @@ type Config struct {
 	Port int    // 服务器监听的端口，例如: 8080
 	Dir  string // 提供服务的目录路径，例如: "/home/user/files"
 
+	// 监听地址，为空时监听所有接口的 Port；不带端口的地址使用 Port，"unix:路径" 表示监听Unix套接字
+	// 例如: []string{"127.0.0.1:8080", "[::1]:8080"}、[]string{"10.8.0.2"} 或 []string{"unix:/run/servergo.sock"}
+	Bind []string
+
+	// 可信代理的IP或网段，只有来自这些地址的请求才使用 X-Forwarded-For、X-Real-IP 中的客户端地址
+	// 例如: []string{"127.0.0.1", "10.0.0.0/8"}；Unix套接字的对端总是被信任
+	TrustedProxies []string
+
+	// 是否在TCP监听器上接受PROXY协议（v1/v2）头部，只接受 TrustedProxies 发送的头部，因此必须同时配置 TrustedProxies
+	ProxyProtocol bool
+
+	// 挂载点配置，不为空时忽略 Dir，每个目录挂载到各自的URL前缀下
+	// 例如: []Mount{{Prefix: "/docs", Dir: "./site"}, {Prefix: "/builds", Dir: "/srv/artifacts"}}
+	Mounts []Mount
+
+	// 反向代理配置，匹配前缀的请求转发到上游服务，其他请求仍然提供静态文件
+	// 例如: []ProxyRule{{Prefix: "/api", Target: "http://127.0.0.1:3000"}}
+	Proxies []ProxyRule
+
 	// 认证相关配置
 	AuthType        auth.AuthType // 认证类型，可选值: auth.NoAuth, auth.BasicAuth, auth.TokenAuth, auth.FormAuth
 	Username        string        // 用户名，用于BasicAuth和FormAuth，例如: "admin"
 	Password        string        // 密码，用于BasicAuth和FormAuth，例如: "password123"
 	Token           string        // 令牌，用于TokenAuth，例如: "abcdef123456"
 	EnableLoginPage bool          // 是否启用登录页面，用于FormAuth，例如: true表示启用
+	Htpasswd        string        // htpasswd用户文件，设置后BasicAuth和FormAuth验证文件中的用户，例如: "/etc/servergo/htpasswd"
+	TokenFile       string        // 托管令牌文件，设置后TokenAuth还接受文件中未过期的令牌，文件可以不存在，例如: "/home/user/.servergo/tokens"
+	ACLPolicy       string        // YAML格式的访问控制策略文件，按用户和路径前缀限制权限，例如: "/etc/servergo/acl.yaml"
+
+	// 同时启用的多种认证方式，任一方式通过即可，不为空时忽略 AuthType
+	// 例如: []auth.AuthType{auth.BasicAuth, auth.TokenAuth}
+	AuthTypes []auth.AuthType
+
+	// 表单认证会话的有效期，为0时使用 auth 包中的默认值
+	SessionMaxAge      time.Duration // 会话的最长有效期，例如: 12 * time.Hour
+	SessionIdleTimeout time.Duration // 超过该时间没有访问会话失效，例如: 30 * time.Minute
+	RememberMeMaxAge   time.Duration // 勾选"记住我"时会话的有效期，例如: 30 * 24 * time.Hour
 
 	// 目录浏览相关配置
 	EnableDirListing bool   // 是否启用目录列表功能，例如: true表示启用
 	Theme            string // 目录列表主题，可选值: "default", "bootstrap", "material" 等
+
+	// 单页应用相关配置
+	SPA        bool     // 是否启用单页应用模式，不存在的路径回退到根目录的index.html，便于前端路由
+	SPAExclude []string // 不回退的路径前缀，例如: []string{"/api"}，这些路径下不存在的文件仍返回404
+
+	// 隐藏文件相关配置
+	ShowHidden bool     // 是否显示以 "." 开头的隐藏文件和目录，例如 .git、.env
+	Exclude    []string // 额外隐藏的路径，gitignore语法，追加在根目录 .servergoignore 的规则之后，例如: []string{"node_modules/", "*.log"}
+
+	// 文件上传相关配置
+	EnableUpload bool // 是否允许通过multipart表单或HTTP PUT上传文件，例如: true表示允许
+
+	// WebDAV相关配置
+	EnableWebDAV bool // 是否启用WebDAV，启用后可以用davfs2、Finder或资源管理器挂载服务目录
+
+	// HTTPS相关配置
+	TLSCertFile   string // 用户提供的证书文件路径，需与TLSKeyFile同时设置，例如: "./cert.pem"
+	TLSKeyFile    string // 用户提供的私钥文件路径，例如: "./key.pem"
+	TLSSelfSigned bool   // 是否使用自动生成的自签名证书，证书缓存在 ~/.servergo/tls 下
+
+	// 压缩相关配置
+	EnableCompression  bool     // 是否根据 Accept-Encoding 压缩响应（br、zstd、gzip），并优先提供预压缩的 .br/.zst/.gz 文件
+	CompressionMinSize int      // 小于该字节数的响应不压缩，为0时使用 DefaultCompressionMinSize
+	CompressionTypes   []string // 允许压缩的MIME类型，以 "/" 结尾表示前缀匹配，为空时使用 DefaultCompressionTypes
+
+	// 缩略图相关配置
+	ThumbnailDir string // 缩略图缓存目录，为空时使用 ~/.servergo/cache/thumbs
+
+	// 开发相关配置
+	LiveReload bool // 是否监视服务目录，文件变化时通过SSE通知浏览器刷新页面
+
+	// 全文搜索相关配置
+	FullTextIndex       bool     // 是否在后台为文本文件建立全文索引，启用后可以按内容搜索文件
+	FullTextMaxFileSize int64    // 大于该字节数的文件不被索引，为0时使用 fulltext.DefaultMaxFileSize
+	FullTextExtensions  []string // 被索引的文件扩展名，例如: []string{".log", ".md"}，"*" 表示所有文本文件，为空时使用 fulltext.DefaultExtensions
+
+	// 关闭相关配置
+	ShutdownTimeout time.Duration // 优雅关闭时等待进行中请求完成的时间，为0时使用 DefaultShutdownTimeout
 }
 
 // FileServer 表示一个文件服务器实例
@@ -30,9 +107,37 @@ type Config struct {
 type FileServer struct {
 	config        Config                   // 服务器配置信息
 	absDir        string                   // 服务目录的绝对路径，例如: "/home/user/files"
+	realDir       string                   // 解析符号链接后的服务目录路径，用于符号链接安全检查
 	engine        *gin.Engine              // Gin引擎实例，用于处理HTTP请求
 	authenticator auth.Authenticator       // 认证器实例，用于处理用户认证
+	authorizer    auth.Authorizer          // 授权器实例，决定用户对每个路径的读取、列表、写入和删除权限
 	dirTemplate   *dirlist.DirListTemplate // 目录列表模板，用于渲染目录页面
+	davHandler    *webdav.Handler          // WebDAV处理器，仅在启用WebDAV时创建
+	ignore        *ignore.Matcher          // .servergoignore 和 Config.Exclude 中的忽略规则
+
+	prefix string        // 挂载点的URL前缀，例如: "/docs"，不是挂载点或挂载到根路径时为空
+	parent *FileServer   // 挂载点所属的父服务器，不是挂载点时为nil
+	mounts []*FileServer // 挂载点对应的子服务器，按前缀长度从长到短排列
+
+	proxies []proxyRoute // 反向代理规则，按前缀长度从长到短排列
+
+	trustedProxies trustedProxies // 可信代理的网段，没有配置时为nil
+
+	liveReload *liveReloader // 实时刷新器，仅在启用实时刷新时创建
+
+	fullText *contentIndexer // 全文索引器，仅在启用全文索引时创建
+
+	thumbnails     *thumbnail.Cache // 缩略图缓存，第一次请求缩略图时创建，挂载点共用父服务器的缓存
+	thumbnailsOnce sync.Once
+
+	certFile        string // 实际使用的证书文件路径，为空表示使用HTTP
+	keyFile         string // 实际使用的私钥文件路径
+	certFingerprint string // 证书的SHA-256指纹，显示在启动信息中
+
+	mu         sync.Mutex     // 保护 listeners 和 httpServer
+	routesOnce sync.Once      // 保证中间件和路由只注册一次
+	listeners  []net.Listener // 监听器，每个监听地址一个，由 Listen 创建
+	httpServer *http.Server   // 正在运行的HTTP服务器，由 Start 创建
 }
 
 // GetAbsDir 获取文件服务器的绝对路径
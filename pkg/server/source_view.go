@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/dirlist"
+	"github.com/CC11001100/servergo/pkg/highlight"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/utils"
+)
+
+// sourceMaxSize 超过此大小的文件不提供源码查看，高亮大文件既慢页面也难以浏览
+const sourceMaxSize = 2 << 20
+
+// sourceViewURL 返回文件的源码查看地址，不能查看的文件返回空字符串
+// 只有HTML主题提供源码查看，文件需要能根据文件名识别为文本或源码
+//
+// 参数:
+//   - itemPath: 文件的访问路径，例如: "/src/main.go"
+//   - name: 文件名，例如: "main.go"
+//   - size: 文件大小
+func (fs *FileServer) sourceViewURL(itemPath, name string, size int64) string {
+	if !fs.htmlTheme() || size > sourceMaxSize || !highlight.IsSource(name) {
+		return ""
+	}
+	// 文件名可能包含 #、? 或 %，需要转义路径
+	return (&url.URL{Path: itemPath, RawQuery: "view=1"}).String()
+}
+
+// shouldViewSource 判断请求是否要查看文件的源码，例如: "/src/main.go?view=1"
+func (fs *FileServer) shouldViewSource(c *gin.Context, fileInfo os.FileInfo) bool {
+	view, _ := strconv.ParseBool(c.Query("view"))
+	return view && fileInfo.Size() <= sourceMaxSize && fs.htmlTheme()
+}
+
+// renderSourceFile 使用当前主题显示带行号和语法高亮的源码
+// 文件不是文本（例如图片、压缩包）时仍然提供原始文件
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - fullPath: 文件的完整路径，例如: "/home/user/src/main.go"
+//   - reqPath: 请求的路径，例如: "/src/main.go"
+func (fs *FileServer) renderSourceFile(c *gin.Context, fullPath, reqPath string) {
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		fs.respondError(c, http.StatusInternalServerError, i18n.T("http.500"))
+		return
+	}
+	if !isText(content) {
+		c.File(fullPath)
+		return
+	}
+
+	var buf bytes.Buffer
+	language, err := highlight.Source(&buf, string(content), filepath.Base(fullPath))
+	if err != nil {
+		fs.respondError(c, http.StatusInternalServerError, i18n.T("http.500"))
+		return
+	}
+
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		lines++
+	}
+
+	fs.renderPage(c, dirlist.TemplateData{
+		DirPath:     reqPath,
+		ParentDir:   path.Dir(path.Clean("/" + reqPath)),
+		CurrentTime: time.Now().Format("2006-01-02 15:04:05"),
+		Source: &dirlist.SourceInfo{
+			Name:     filepath.Base(fullPath),
+			Language: language,
+			Lines:    lines,
+			Size:     utils.FormatSize(int64(len(content))),
+			HTML:     template.HTML(buf.String()),
+			CSS:      template.CSS(highlight.CSS()),
+		},
+	})
+}
+
+// isText 判断文件内容是否是文本：是合法的UTF-8并且开头部分不包含NUL字节
+func isText(content []byte) bool {
+	head := content
+	if len(head) > 8192 {
+		head = head[:8192]
+	}
+	return !bytes.Contains(head, []byte{0}) && utf8.Valid(content)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSourceView 测试源码查看页面和目录列表中的查看链接
+func TestSourceView(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	files := map[string][]byte{
+		"main.go":   []byte("package main\n\nfunc main() {}\n"),
+		"image.png": {0x89, 'P', 'N', 'G', 0, 0, 0, 0},
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tempDir, "subdir", name), content, 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedType string
+		expectedBody []string
+		excludedBody []string
+	}{
+		{
+			name:         "带行号和高亮的源码",
+			path:         "/subdir/main.go?view=1",
+			expectedType: "text/html",
+			expectedBody: []string{`id="L3"`, `href="#L3"`, "sg-source", "3 行"},
+			excludedBody: []string{"<table>"},
+		},
+		{
+			name:         "不带参数时提供原始文件",
+			path:         "/subdir/main.go",
+			expectedBody: []string{"package main"},
+			excludedBody: []string{"sg-source"},
+		},
+		{
+			name:         "二进制文件提供原始文件",
+			path:         "/subdir/image.png?view=1",
+			expectedType: "image/png",
+			excludedBody: []string{"sg-source"},
+		},
+		{
+			name:         "目录列表中的查看链接",
+			path:         "/subdir/",
+			expectedType: "text/html",
+			expectedBody: []string{`href="/subdir/main.go?view=1"`, `href="/subdir/subfile.txt?view=1"`},
+			excludedBody: []string{`image.png?view=1`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.expectedType) {
+				t.Errorf("Content-Type = %q, 期望以 %q 开头", contentType, tt.expectedType)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("响应中应该包含 %q", s)
+				}
+			}
+			for _, s := range tt.excludedBody {
+				if strings.Contains(body, s) {
+					t.Errorf("响应中不应该包含 %q", s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+)
+
+// serveSPAFallback 单页应用模式下，用根目录的index.html响应不存在的路径
+// 真实存在的文件和目录不会经过这里，仍由 handleFileRequest 正常处理
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - reqPath: 请求的路径，例如: "/users/42"
+//
+// 返回值:
+//   - bool: 是否已经返回了index.html；未启用单页应用模式、路径被排除、index.html不存在、
+//     被隐藏或忽略，或者当前用户不能读取时返回false
+func (fs *FileServer) serveSPAFallback(c *gin.Context, reqPath string) bool {
+	if !fs.config.SPA || fs.isSPAExcluded(reqPath) {
+		return false
+	}
+
+	indexPath := filepath.Join(fs.absDir, "index.html")
+	if err := fs.checkRealPath(indexPath); err != nil {
+		return false
+	}
+	if fs.isExcluded(indexPath, false) || !fs.allowed(c, fs.urlPathOf(indexPath), auth.PermRead) {
+		return false
+	}
+
+	// 与目录的index.html一样，启用实时刷新时注入刷新脚本
+	if fs.config.LiveReload {
+		fs.serveHTMLWithLiveReload(c, indexPath)
+		return true
+	}
+	c.File(indexPath)
+	return true
+}
+
+// isSPAExcluded 判断路径是否位于排除的前缀下
+// 前缀按路径段匹配，例如 "/api" 匹配 "/api" 和 "/api/users"，但不匹配 "/apidocs"
+func (fs *FileServer) isSPAExcluded(reqPath string) bool {
+	for _, prefix := range fs.config.SPAExclude {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if prefix == "/" {
+			continue
+		}
+		if reqPath == prefix || strings.HasPrefix(reqPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+)
+
+// TestSPAFallback 测试单页应用模式下的路径回退
+func TestSPAFallback(t *testing.T) {
+	srv, _, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.config.SPA = true
+	srv.config.SPAExclude = []string{"/api", "assets/"}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "前端路由回退到index.html", path: "/users/42", expectedCode: http.StatusOK, expectedBody: "Index Page"},
+		{name: "真实文件正常提供", path: "/test.txt", expectedCode: http.StatusOK, expectedBody: "Test File Content"},
+		{name: "真实目录正常提供", path: "/indexdir/", expectedCode: http.StatusOK, expectedBody: "Index in Subdir"},
+		{name: "排除的前缀返回404", path: "/api/users", expectedCode: http.StatusNotFound},
+		{name: "排除的前缀本身返回404", path: "/api", expectedCode: http.StatusNotFound},
+		{name: "去掉斜线后匹配排除前缀", path: "/assets/app.js", expectedCode: http.StatusNotFound},
+		{name: "前缀按路径段匹配", path: "/apidocs", expectedCode: http.StatusOK, expectedBody: "Index Page"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, tt.expectedCode)
+			}
+			if tt.expectedBody != "" && !strings.Contains(w.Body.String(), tt.expectedBody) {
+				t.Errorf("响应内容 = %q, 期望包含 %q", w.Body.String(), tt.expectedBody)
+			}
+		})
+	}
+
+	// 未启用单页应用模式时仍然返回404
+	srv.config.SPA = false
+	req, _ := http.NewRequest(http.MethodGet, "/users/42", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("未启用单页应用模式时状态码 = %d, 期望 %d", w.Code, http.StatusNotFound)
+	}
+}
+
+// TestSPAFallbackIndexChecks 测试回退的index.html与目录的index.html使用相同的处理：
+// 注入实时刷新脚本，并检查隐藏、忽略规则和读取权限
+func TestSPAFallbackIndexChecks(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.config.SPA = true
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+	get := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/users/42", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	srv.config.LiveReload = true
+	if w := get(); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), liveReloadPath) {
+		t.Errorf("启用实时刷新时应该注入刷新脚本, 状态码 = %d", w.Code)
+	}
+	srv.config.LiveReload = false
+
+	authorizer, err := auth.ParsePolicy([]byte("paths:\n  /index.html:\n    \"*\": []\n"))
+	if err != nil {
+		t.Fatalf("ParsePolicy() 错误: %v", err)
+	}
+	srv.authorizer = authorizer
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Errorf("不能读取index.html时状态码 = %d, 期望 %d", w.Code, http.StatusNotFound)
+	}
+	srv.authorizer = nil
+
+	os.WriteFile(filepath.Join(tempDir, ignoreFileName), []byte("index.html\n"), 0644)
+	if err := srv.loadIgnoreRules(); err != nil {
+		t.Fatalf("loadIgnoreRules() 错误: %v", err)
+	}
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Errorf("index.html被忽略时状态码 = %d, 期望 %d", w.Code, http.StatusNotFound)
+	}
+}
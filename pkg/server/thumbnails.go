@@ -0,0 +1,77 @@
+package server
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/config"
+	"github.com/CC11001100/servergo/pkg/logger"
+	"github.com/CC11001100/servergo/pkg/thumbnail"
+)
+
+// thumbCacheDir 缩略图缓存目录，相对于配置目录 ~/.servergo
+const thumbCacheDir = "cache/thumbs"
+
+// thumbnailCache 返回缩略图缓存，第一次调用时确定缓存目录
+// 无法获取配置目录时使用系统临时目录
+func (fs *FileServer) thumbnailCache() *thumbnail.Cache {
+	root := fs
+	if fs.parent != nil {
+		root = fs.parent
+	}
+
+	root.thumbnailsOnce.Do(func() {
+		dir := root.config.ThumbnailDir
+		if dir == "" {
+			if configDir, err := config.GetConfigDir(); err == nil {
+				dir = filepath.Join(configDir, thumbCacheDir)
+			} else {
+				dir = filepath.Join(os.TempDir(), "servergo-thumbs")
+			}
+		}
+		root.thumbnails = thumbnail.NewCache(dir)
+	})
+	return root.thumbnails
+}
+
+// thumbnailURL 返回图片的缩略图地址，不是图片时返回空字符串
+//
+// 参数:
+//   - itemPath: 文件的访问路径，例如: "/shots/a.png"
+//   - name: 文件名，例如: "a.png"
+func thumbnailURL(itemPath, name string) string {
+	if !thumbnail.IsImage(name) {
+		return ""
+	}
+	// 文件名可能包含 #、? 或 %，需要转义路径
+	return (&url.URL{Path: itemPath, RawQuery: "thumb=1"}).String()
+}
+
+// shouldServeThumbnail 判断请求是否要获取图片的缩略图，例如: "/shots/a.png?thumb=1"
+func (fs *FileServer) shouldServeThumbnail(c *gin.Context, fileInfo os.FileInfo) bool {
+	thumb, _ := strconv.ParseBool(c.Query("thumb"))
+	return thumb && thumbnail.IsImage(fileInfo.Name())
+}
+
+// serveThumbnail 提供图片的缩略图，无法生成缩略图（例如图片损坏或太大）时提供原图
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//   - fullPath: 图片的完整路径
+//   - fileInfo: 图片的文件信息
+func (fs *FileServer) serveThumbnail(c *gin.Context, fullPath string, fileInfo os.FileInfo) {
+	thumbPath, err := fs.thumbnailCache().Get(fullPath, fileInfo)
+	if err != nil {
+		logger.Debug("thumbnail %s: %v", fullPath, err)
+		c.File(fullPath)
+		return
+	}
+
+	// 缩略图的地址不随原图变化，每次都需要向服务器确认缩略图是否更新
+	c.Header("Cache-Control", "no-cache")
+	c.File(thumbPath)
+}
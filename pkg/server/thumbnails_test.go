@@ -0,0 +1,133 @@
+package server
+
+import (
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestThumbnailsAndGallery 测试缩略图和画廊视图
+func TestThumbnailsAndGallery(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.config.ThumbnailDir = t.TempDir()
+
+	file, err := os.Create(filepath.Join(tempDir, "subdir", "shot.png"))
+	if err != nil {
+		t.Fatalf("创建图片失败: %v", err)
+	}
+	png.Encode(file, image.NewRGBA(image.Rect(0, 0, 800, 600)))
+	file.Close()
+	if err := os.WriteFile(filepath.Join(tempDir, "subdir", "broken.jpg"), []byte("broken"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+
+	if err := os.Mkdir(filepath.Join(tempDir, "nothumbs"), 0755); err != nil {
+		t.Fatalf("创建测试目录失败: %v", err)
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedType string
+		expectedBody []string
+		excludedBody []string
+	}{
+		{name: "缩略图", path: "/subdir/shot.png?thumb=1", expectedType: "image/jpeg"},
+		{name: "无法生成缩略图时提供原图", path: "/subdir/broken.jpg?thumb=1", expectedBody: []string{"broken"}},
+		{
+			name:         "列表视图显示画廊链接",
+			path:         "/subdir/",
+			expectedBody: []string{"<table>", `href="?view=gallery"`},
+		},
+		{
+			name:         "画廊视图",
+			path:         "/subdir/?view=gallery",
+			expectedBody: []string{"sg-gallery", `src="/subdir/shot.png?thumb=1"`, "sg-lightbox"},
+			excludedBody: []string{"<table>", `src="/subdir/subfile.txt?thumb=1"`},
+		},
+		{
+			name:         "没有图片的目录不显示画廊链接",
+			path:         "/nothumbs/",
+			expectedBody: []string{"<table>"},
+			excludedBody: []string{`href="?view=gallery"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+			}
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, tt.expectedType) {
+				t.Errorf("Content-Type = %q, 期望以 %q 开头", contentType, tt.expectedType)
+			}
+			body := w.Body.String()
+			for _, s := range tt.expectedBody {
+				if !strings.Contains(body, s) {
+					t.Errorf("响应中应该包含 %q", s)
+				}
+			}
+			for _, s := range tt.excludedBody {
+				if strings.Contains(body, s) {
+					t.Errorf("响应中不应该包含 %q", s)
+				}
+			}
+		})
+	}
+}
+
+// TestItemURLEscaping 测试文件名包含 #、? 或 % 时缩略图和源码查看地址会转义路径
+func TestItemURLEscaping(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+	srv.config.ThumbnailDir = t.TempDir()
+
+	for _, name := range []string{"a#1 ?%.png", "b#?%.go"} {
+		if err := os.WriteFile(filepath.Join(tempDir, "subdir", name), []byte("x"), 0644); err != nil {
+			t.Fatalf("创建测试文件失败: %v", err)
+		}
+	}
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedBody string
+	}{
+		{name: "缩略图地址", path: "/subdir/?view=gallery", expectedBody: `src="/subdir/a%231%20%3F%25.png?thumb=1"`},
+		{name: "源码查看地址", path: "/subdir/", expectedBody: `href="/subdir/b%23%3F%25.go?view=1"`},
+		{name: "打开转义后的地址", path: "/subdir/b%23%3F%25.go?view=1", expectedBody: `id="L1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("状态码 = %d, 期望 %d", w.Code, http.StatusOK)
+			}
+			if body := w.Body.String(); !strings.Contains(body, tt.expectedBody) {
+				t.Errorf("响应中应该包含 %q", tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/CC11001100/servergo/pkg/config"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+	"github.com/CC11001100/servergo/pkg/tlscert"
+)
+
+// tlsCacheDir 自签名证书在配置目录（~/.servergo）下的缓存子目录
+const tlsCacheDir = "tls"
+
+// TLSEnabled 返回配置是否启用了HTTPS
+func (c Config) TLSEnabled() bool {
+	return c.TLSSelfSigned || c.TLSCertFile != "" || c.TLSKeyFile != ""
+}
+
+// setupTLS 根据配置准备HTTPS证书，并计算证书指纹
+// 未启用HTTPS时不做任何事情
+//
+// 返回值:
+//   - error: 证书参数不完整、证书无法加载或自签名证书生成失败时返回错误
+func (fs *FileServer) setupTLS() error {
+	if !fs.config.TLSEnabled() {
+		return nil
+	}
+
+	certFile, keyFile := fs.config.TLSCertFile, fs.config.TLSKeyFile
+	if fs.config.TLSSelfSigned {
+		if certFile != "" || keyFile != "" {
+			return fmt.Errorf(i18n.T("error.tls_conflict"))
+		}
+
+		configDir, err := config.GetConfigDir()
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("error.tls_load_failed", err))
+		}
+
+		var created bool
+		certFile, keyFile, created, err = tlscert.LoadOrCreateSelfSigned(filepath.Join(configDir, tlsCacheDir), tlscert.LocalHosts())
+		if err != nil {
+			return fmt.Errorf(i18n.Tf("error.tls_load_failed", err))
+		}
+		if created {
+			logger.Info(i18n.Tf("server.tls_generated", certFile))
+		}
+	} else {
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf(i18n.T("error.tls_cert_key_pair"))
+		}
+		if err := tlscert.Validate(certFile, keyFile); err != nil {
+			return fmt.Errorf(i18n.Tf("error.tls_load_failed", err))
+		}
+	}
+
+	fingerprint, err := tlscert.Fingerprint(certFile)
+	if err != nil {
+		return fmt.Errorf(i18n.Tf("error.tls_load_failed", err))
+	}
+
+	fs.certFile = certFile
+	fs.keyFile = keyFile
+	fs.certFingerprint = fingerprint
+	return nil
+}
+
+// URL 返回本机访问服务器的地址，启用HTTPS时使用https协议
+//
+// 返回值:
+//   - string: 例如: "https://localhost:8080"，只监听具体的地址时使用该地址，例如: "http://10.8.0.2:8080"，
+//     只监听Unix套接字时返回套接字地址，例如: "unix:/run/servergo.sock"
+func (fs *FileServer) URL() string {
+	if socketPath, ok := fs.unixOnly(); ok {
+		return unixPrefix + socketPath
+	}
+	scheme := "http"
+	if fs.certFile != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, fs.urlHost(), fs.config.Port)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSetupTLS 测试HTTPS证书参数的校验和自签名证书的生成
+func TestSetupTLS(t *testing.T) {
+	// 自签名证书缓存在 ~/.servergo 下，测试时把HOME指向临时目录
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name      string
+		config    Config
+		expectErr bool
+		expectURL string
+	}{
+		{
+			name:      "未启用HTTPS",
+			config:    Config{Port: 8080},
+			expectURL: "http://localhost:8080",
+		},
+		{
+			name:      "只提供证书没有私钥",
+			config:    Config{Port: 8080, TLSCertFile: "cert.pem"},
+			expectErr: true,
+		},
+		{
+			name:      "证书文件不存在",
+			config:    Config{Port: 8080, TLSCertFile: "missing-cert.pem", TLSKeyFile: "missing-key.pem"},
+			expectErr: true,
+		},
+		{
+			name:      "自签名与用户证书冲突",
+			config:    Config{Port: 8080, TLSSelfSigned: true, TLSCertFile: "cert.pem", TLSKeyFile: "key.pem"},
+			expectErr: true,
+		},
+		{
+			name:      "自签名证书",
+			config:    Config{Port: 8443, TLSSelfSigned: true},
+			expectURL: "https://localhost:8443",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := &FileServer{config: tt.config}
+			err := fs.setupTLS()
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("setupTLS() 错误: %v", err)
+			}
+			if url := fs.URL(); url != tt.expectURL {
+				t.Errorf("URL() = %q, 期望 %q", url, tt.expectURL)
+			}
+		})
+	}
+
+	// 自签名证书应该缓存在 ~/.servergo/tls 下，并且可以作为用户证书再次加载
+	certFile := filepath.Join(home, ".servergo", tlsCacheDir, "selfsigned-cert.pem")
+	keyFile := filepath.Join(home, ".servergo", tlsCacheDir, "selfsigned-key.pem")
+	if _, err := os.Stat(certFile); err != nil {
+		t.Fatalf("自签名证书没有被缓存: %v", err)
+	}
+
+	fs := &FileServer{config: Config{Port: 8443, TLSCertFile: certFile, TLSKeyFile: keyFile}}
+	if err := fs.setupTLS(); err != nil {
+		t.Fatalf("加载用户证书失败: %v", err)
+	}
+	if strings.Count(fs.certFingerprint, ":") != 31 {
+		t.Errorf("证书指纹格式不正确: %s", fs.certFingerprint)
+	}
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/i18n"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// uploadTempPattern 上传过程中临时文件的命名模式
+// 文件先写入同目录下的临时文件，完成后再原子地重命名为目标文件
+const uploadTempPattern = ".servergo-upload-*"
+
+// handleMultipartUpload 处理multipart表单上传
+// 请求路径必须指向一个已存在的目录，表单中的所有文件都会保存到该目录下
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+//
+// 功能:
+//  1. 使用与 handleFileRequest 相同的根目录检查定位目标目录
+//  2. 以流的方式读取每个文件字段并写入磁盘，不占用额外的临时空间
+//  3. 浏览器提交时重定向回目录列表，其他客户端返回JSON结果
+func (fs *FileServer) handleMultipartUpload(c *gin.Context) {
+	reqPath := c.Request.URL.Path
+	if !fs.authorize(c, reqPath, auth.PermWrite) {
+		return
+	}
+
+	dirPath, ok := fs.resolveExistingPath(c, reqPath)
+	if !ok {
+		return
+	}
+
+	info, err := os.Stat(dirPath)
+	if err != nil || !info.IsDir() {
+		fs.respondError(c, http.StatusBadRequest, i18n.T("http.upload_not_directory"))
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		fs.respondError(c, http.StatusBadRequest, i18n.Tf("http.upload_bad_request", err))
+		return
+	}
+
+	uploaded := make([]string, 0)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fs.respondError(c, http.StatusBadRequest, i18n.Tf("http.upload_bad_request", err))
+			return
+		}
+
+		// 只处理文件字段，忽略普通表单字段
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		// 目录下更具体的规则可能不允许写入某个文件
+		target := path.Join(reqPath, uploadFileName(part.FileName()))
+		if !fs.authorize(c, target, auth.PermWrite) {
+			part.Close()
+			return
+		}
+
+		// 与PUT上传一样，不允许创建或覆盖隐藏或被忽略的文件，例如 .env 或 .servergoignore
+		if fs.isExcluded(filepath.Join(dirPath, uploadFileName(part.FileName())), false) {
+			part.Close()
+			fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", target))
+			return
+		}
+
+		name, err := saveUploadedPart(dirPath, part)
+		part.Close()
+		if err != nil {
+			logger.Error(i18n.Tf("server.upload_failed", part.FileName(), err))
+			fs.respondError(c, http.StatusInternalServerError, i18n.Tf("http.upload_failed", err))
+			return
+		}
+
+		logger.Info(i18n.Tf("server.upload_saved", path.Join(reqPath, name)))
+		uploaded = append(uploaded, path.Join(reqPath, name))
+	}
+
+	// 来自浏览器表单的提交，重定向回目录列表
+	if strings.Contains(c.GetHeader("Accept"), "text/html") {
+		redirectPath := reqPath
+		if !strings.HasSuffix(redirectPath, "/") {
+			redirectPath += "/"
+		}
+		c.Redirect(http.StatusSeeOther, redirectPath)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"uploaded": uploaded,
+	})
+}
+
+// handlePutUpload 处理HTTP PUT上传，将请求体原样写入请求路径对应的文件
+// 父目录必须已经存在，目标文件存在时会被覆盖
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+func (fs *FileServer) handlePutUpload(c *gin.Context) {
+	reqPath := c.Request.URL.Path
+	if strings.HasSuffix(reqPath, "/") {
+		fs.respondError(c, http.StatusBadRequest, i18n.T("http.upload_bad_target"))
+		return
+	}
+
+	if !fs.authorize(c, reqPath, auth.PermWrite) {
+		return
+	}
+
+	fullPath, err := fs.resolvePath(reqPath)
+	if err != nil || fullPath == fs.absDir {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return
+	}
+
+	// 不允许上传到隐藏或被忽略的路径
+	if fs.isExcluded(fullPath, false) {
+		fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
+		return
+	}
+
+	// 父目录必须存在并且真实路径位于根目录内
+	parentDir := filepath.Dir(fullPath)
+	if err := fs.checkRealPath(parentDir); err != nil {
+		if os.IsNotExist(err) {
+			fs.respondError(c, http.StatusConflict, i18n.T("http.upload_parent_missing"))
+			return
+		}
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return
+	}
+
+	// 不允许用文件覆盖目录
+	existed := false
+	if info, err := os.Lstat(fullPath); err == nil {
+		if info.IsDir() {
+			fs.respondError(c, http.StatusConflict, i18n.T("http.upload_bad_target"))
+			return
+		}
+		existed = true
+	}
+
+	if err := writeFileAtomic(fullPath, c.Request.Body); err != nil {
+		logger.Error(i18n.Tf("server.upload_failed", reqPath, err))
+		fs.respondError(c, http.StatusInternalServerError, i18n.Tf("http.upload_failed", err))
+		return
+	}
+
+	logger.Info(i18n.Tf("server.upload_saved", reqPath))
+	if existed {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.Status(http.StatusCreated)
+}
+
+// resolveExistingPath 定位一个已存在的路径并完成根目录及符号链接检查
+// 检查失败时会直接写入错误响应，并返回false
+func (fs *FileServer) resolveExistingPath(c *gin.Context, reqPath string) (string, bool) {
+	fullPath, err := fs.resolvePath(reqPath)
+	if err != nil {
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return "", false
+	}
+
+	if err := fs.checkRealPath(fullPath); err != nil {
+		if os.IsNotExist(err) {
+			fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
+			return "", false
+		}
+		fs.respondError(c, http.StatusForbidden, i18n.T("http.403"))
+		return "", false
+	}
+
+	// 隐藏文件和被忽略的文件当作不存在
+	if info, err := os.Stat(fullPath); err == nil && fs.isExcluded(fullPath, info.IsDir()) {
+		fs.respondError(c, http.StatusNotFound, i18n.Tf("http.404", reqPath))
+		return "", false
+	}
+
+	return fullPath, true
+}
+
+// saveUploadedPart 把multipart中的一个文件保存到指定目录
+// 只使用客户端文件名的最后一段，防止通过文件名写到其他目录
+//
+// 返回值:
+//   - string: 实际保存的文件名
+//   - error: 文件名非法或写入失败时返回错误
+func saveUploadedPart(dirPath string, part *multipart.Part) (string, error) {
+	name := uploadFileName(part.FileName())
+	if name == "" || name == "." || name == ".." {
+		return "", errors.New(i18n.T("http.upload_bad_filename"))
+	}
+
+	target := filepath.Join(dirPath, name)
+	if info, err := os.Lstat(target); err == nil && info.IsDir() {
+		return "", errors.New(i18n.T("http.upload_bad_target"))
+	}
+
+	return name, writeFileAtomic(target, part)
+}
+
+// uploadFileName 返回客户端文件名的最后一段，浏览器可能提交Windows风格的路径
+func uploadFileName(name string) string {
+	return name[strings.LastIndexAny(name, `/\`)+1:]
+}
+
+// writeFileAtomic 先把内容写入同目录的临时文件，再重命名为目标文件
+// 这样下载方永远不会读到只写了一半的文件
+func writeFileAtomic(target string, src io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), uploadTempPattern)
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	// CreateTemp 创建的文件权限为0600，调整为普通文件的默认权限
+	if err := os.Chmod(tmpName, 0644); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Rename(tmpName, target); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newUploadRouter 创建启用了上传功能的测试路由
+func newUploadRouter(t *testing.T) (*FileServer, *gin.Engine, string, func()) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	srv.config.EnableUpload = true
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+	return srv, router, tempDir, cleanup
+}
+
+// TestHandlePutUpload 测试通过PUT上传文件
+func TestHandlePutUpload(t *testing.T) {
+	_, router, tempDir, cleanup := newUploadRouter(t)
+	defer cleanup()
+
+	// 指向根目录外的符号链接目录
+	outsideDir, err := ioutil.TempDir("", "servergo-outside-")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(outsideDir)
+	if err := os.Symlink(outsideDir, filepath.Join(tempDir, "escape")); err != nil {
+		t.Fatalf("创建符号链接失败: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		body         string
+		expectedCode int
+		expectedFile string // 期望写入的文件（相对于服务目录）
+	}{
+		{
+			name:         "创建新文件",
+			path:         "/new.txt",
+			body:         "new content",
+			expectedCode: http.StatusCreated,
+			expectedFile: "new.txt",
+		},
+		{
+			name:         "覆盖已存在的文件",
+			path:         "/test.txt",
+			body:         "replaced",
+			expectedCode: http.StatusNoContent,
+			expectedFile: "test.txt",
+		},
+		{
+			name:         "上传到子目录",
+			path:         "/subdir/artifact.bin",
+			body:         "binary",
+			expectedCode: http.StatusCreated,
+			expectedFile: "subdir/artifact.bin",
+		},
+		{
+			name:         "父目录不存在",
+			path:         "/missing/file.txt",
+			body:         "x",
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "不能覆盖目录",
+			path:         "/subdir",
+			body:         "x",
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "通过符号链接写到根目录之外",
+			path:         "/escape/evil.txt",
+			body:         "x",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", w.Code, tt.expectedCode, w.Body.String())
+			}
+
+			if tt.expectedFile != "" {
+				content, err := ioutil.ReadFile(filepath.Join(tempDir, tt.expectedFile))
+				if err != nil {
+					t.Fatalf("读取上传的文件失败: %v", err)
+				}
+				if string(content) != tt.body {
+					t.Errorf("文件内容 = %q, 期望 %q", string(content), tt.body)
+				}
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(outsideDir, "evil.txt")); !os.IsNotExist(err) {
+		t.Errorf("文件不应该被写到根目录之外")
+	}
+}
+
+// TestHandleMultipartUpload 测试通过multipart表单上传文件
+func TestHandleMultipartUpload(t *testing.T) {
+	_, router, tempDir, cleanup := newUploadRouter(t)
+	defer cleanup()
+
+	// 构造包含多个文件的表单，部分文件名带有路径
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	files := map[string]string{
+		"a.txt":        "file a",
+		"../../b.txt":  "file b",
+		`C:\tmp\c.txt`: "file c",
+	}
+	for name, content := range files {
+		part, err := writer.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatalf("创建表单字段失败: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	writer.WriteField("comment", "ignored")
+	writer.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, "/subdir/", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+
+	for name, want := range map[string]string{"a.txt": "file a", "b.txt": "file b", "c.txt": "file c"} {
+		content, err := ioutil.ReadFile(filepath.Join(tempDir, "subdir", name))
+		if err != nil {
+			t.Errorf("读取上传的文件 %s 失败: %v", name, err)
+			continue
+		}
+		if string(content) != want {
+			t.Errorf("文件 %s 内容 = %q, 期望 %q", name, string(content), want)
+		}
+	}
+
+	// 浏览器提交时应重定向回目录列表
+	body.Reset()
+	writer = multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "d.txt")
+	part.Write([]byte("file d"))
+	writer.Close()
+
+	req, _ = http.NewRequest(http.MethodPost, "/subdir", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusSeeOther {
+		t.Errorf("状态码 = %d, 期望 %d", w.Code, http.StatusSeeOther)
+	}
+	if location := w.Header().Get("Location"); location != "/subdir/" {
+		t.Errorf("重定向地址 = %q, 期望 %q", location, "/subdir/")
+	}
+}
+
+// TestMultipartUploadExcluded 测试multipart表单不能创建或覆盖隐藏或被忽略的文件
+func TestMultipartUploadExcluded(t *testing.T) {
+	_, router, tempDir, cleanup := newUploadRouter(t)
+	defer cleanup()
+
+	for _, name := range []string{".env", ignoreFileName} {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("file", name)
+		part.Write([]byte("SECRET=1"))
+		writer.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, "/", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("上传 %s 状态码 = %d, 期望 %d", name, w.Code, http.StatusNotFound)
+		}
+		if _, err := os.Stat(filepath.Join(tempDir, name)); err == nil {
+			t.Errorf("不应该创建隐藏文件 %s", name)
+		}
+	}
+}
+
+// TestUploadDisabled 测试未启用上传时拒绝写请求
+func TestUploadDisabled(t *testing.T) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+
+	for _, method := range []string{http.MethodPut, http.MethodPost, http.MethodDelete} {
+		req, _ := http.NewRequest(method, "/blocked.txt", strings.NewReader("x"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s 状态码 = %d, 期望 %d", method, w.Code, http.StatusMethodNotAllowed)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(tempDir, "blocked.txt")); !os.IsNotExist(err) {
+		t.Errorf("未启用上传时不应创建文件")
+	}
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/webdav"
+
+	"github.com/CC11001100/servergo/pkg/auth"
+	"github.com/CC11001100/servergo/pkg/logger"
+)
+
+// webdavMethods 由WebDAV处理器负责的请求方法
+// GET/HEAD 仍然由 handleFileRequest 处理，以保留目录列表和主题
+var webdavMethods = map[string]bool{
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	"PROPFIND":         true,
+	"PROPPATCH":        true,
+	"MKCOL":            true,
+	"COPY":             true,
+	"MOVE":             true,
+	"LOCK":             true,
+	"UNLOCK":           true,
+}
+
+// newWebDAVHandler 创建挂载在服务根目录上的WebDAV处理器
+// 文件系统经过 confinedFileSystem 包装，与 handleFileRequest 使用相同的根目录限制
+func (fs *FileServer) newWebDAVHandler() *webdav.Handler {
+	return &webdav.Handler{
+		Prefix: fs.prefix,
+		FileSystem: &confinedFileSystem{
+			server: fs,
+			dir:    webdav.Dir(fs.absDir),
+		},
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				logger.Debug("WebDAV %s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+}
+
+// handleWebDAV 将请求交给WebDAV处理器
+//
+// 参数:
+//   - c: Gin的上下文，包含请求和响应信息
+func (fs *FileServer) handleWebDAV(c *gin.Context) {
+	// Gin的NoRoute流程会预先把状态码设为404，而部分WebDAV响应（例如OPTIONS、LOCK）
+	// 依赖net/http默认的200状态码，这里先恢复为200
+	c.Status(http.StatusOK)
+
+	// 先按请求方法检查权限，WebDAV处理器会把文件系统返回的权限错误转换为404或405
+	if !fs.authorizeWebDAV(c) {
+		return
+	}
+
+	// 文件系统的每次访问仍然按请求的用户检查权限，例如PROPFIND不列出没有读取权限的文件
+	fs.davHandler.ServeHTTP(c.Writer, withRequestUser(c))
+
+	// 只设置了响应头而没有响应体的情况，确保状态码被写出，避免Gin再输出默认的404页面
+	if !c.Writer.Written() {
+		c.Writer.WriteHeaderNow()
+	}
+}
+
+// authorizeWebDAV 检查WebDAV请求需要的权限，没有权限时写入错误响应并返回false
+// PUT、MKCOL、PROPPATCH、LOCK需要写入权限，DELETE需要删除权限，
+// COPY需要目标路径的写入权限，MOVE还需要源路径的删除权限
+func (fs *FileServer) authorizeWebDAV(c *gin.Context) bool {
+	reqPath := c.Request.URL.Path
+	switch c.Request.Method {
+	case http.MethodPut, "MKCOL", "PROPPATCH", "LOCK":
+		return fs.authorize(c, reqPath, auth.PermWrite)
+	case http.MethodDelete:
+		return fs.authorize(c, reqPath, auth.PermDelete)
+	case "COPY", "MOVE":
+		if c.Request.Method == "MOVE" && !fs.authorize(c, reqPath, auth.PermDelete) {
+			return false
+		}
+		if dest, err := url.Parse(c.GetHeader("Destination")); err == nil && dest.Path != "" {
+			return fs.authorize(c, dest.Path, auth.PermWrite)
+		}
+	}
+	return true
+}
+
+// confinedFileSystem 包装 webdav.Dir，在每次访问前检查路径是否位于服务目录内，以及请求的用户是否有权限
+// webdav.Dir 本身只做字面上的路径清理，会跟随指向根目录之外的符号链接
+type confinedFileSystem struct {
+	server *FileServer
+	dir    webdav.Dir
+}
+
+// check 检查WebDAV路径（以及尚不存在路径的最近已存在祖先目录）的真实位置
+// 越出根目录时返回 os.ErrPermission，WebDAV处理器会将其转换为403或在PROPFIND中跳过该项
+func (cfs *confinedFileSystem) check(name string) error {
+	fullPath, err := cfs.server.resolveMountPath(name)
+	if err != nil {
+		return os.ErrPermission
+	}
+
+	// 隐藏文件和被忽略的文件当作不存在，PROPFIND中也会跳过
+	info, statErr := os.Stat(fullPath)
+	if cfs.server.isExcluded(fullPath, statErr == nil && info.IsDir()) {
+		return &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	// 目标可能尚未创建（PUT、MKCOL、MOVE的目标），逐级向上检查已存在的祖先目录
+	for current := fullPath; ; current = filepath.Dir(current) {
+		err := cfs.server.checkRealPath(current)
+		if err == nil {
+			return nil
+		}
+		if !os.IsNotExist(err) || current == cfs.server.absDir {
+			return os.ErrPermission
+		}
+	}
+}
+
+// authorize 检查请求的用户对WebDAV路径是否有指定的权限，没有权限时返回 os.ErrPermission
+// WebDAV处理器会将其转换为403，PROPFIND中则跳过该项，与目录列表一样不显示没有读取权限的路径
+func (cfs *confinedFileSystem) authorize(ctx context.Context, name string, perm auth.Permission) error {
+	if !cfs.server.userAllowed(contextUser(ctx), contextToken(ctx), path.Join("/", cfs.server.prefix, name), perm) {
+		return os.ErrPermission
+	}
+	return nil
+}
+
+// Mkdir 创建目录
+func (cfs *confinedFileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	if err := cfs.authorize(ctx, name, auth.PermWrite); err != nil {
+		return err
+	}
+	if err := cfs.check(name); err != nil {
+		return err
+	}
+	return cfs.dir.Mkdir(ctx, name, perm)
+}
+
+// OpenFile 打开或创建文件，以写入方式打开时需要写入权限，否则需要读取权限
+func (cfs *confinedFileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	need := auth.PermRead
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		need = auth.PermWrite
+	}
+	if err := cfs.authorize(ctx, name, need); err != nil {
+		return nil, err
+	}
+	if err := cfs.check(name); err != nil {
+		return nil, err
+	}
+	f, err := cfs.dir.OpenFile(ctx, name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &authorizedFile{File: f, listable: cfs.authorize(ctx, name, auth.PermList) == nil}, nil
+}
+
+// RemoveAll 删除文件或目录
+func (cfs *confinedFileSystem) RemoveAll(ctx context.Context, name string) error {
+	if err := cfs.authorize(ctx, name, auth.PermDelete); err != nil {
+		return err
+	}
+	if err := cfs.check(name); err != nil {
+		return err
+	}
+	return cfs.dir.RemoveAll(ctx, name)
+}
+
+// Rename 重命名或移动文件，源路径和目标路径都必须位于服务目录内
+func (cfs *confinedFileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	// 移动相当于删除源路径并写入目标路径
+	if err := cfs.authorize(ctx, oldName, auth.PermDelete); err != nil {
+		return err
+	}
+	if err := cfs.authorize(ctx, newName, auth.PermWrite); err != nil {
+		return err
+	}
+	if err := cfs.check(oldName); err != nil {
+		return err
+	}
+	if err := cfs.check(newName); err != nil {
+		return err
+	}
+	return cfs.dir.Rename(ctx, oldName, newName)
+}
+
+// Stat 获取文件信息
+func (cfs *confinedFileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	if err := cfs.authorize(ctx, name, auth.PermRead); err != nil {
+		return nil, err
+	}
+	if err := cfs.check(name); err != nil {
+		return nil, err
+	}
+	return cfs.dir.Stat(ctx, name)
+}
+
+// authorizedFile 包装打开的文件，没有列表权限时不能读取目录中的文件项，PROPFIND只返回目录本身
+type authorizedFile struct {
+	webdav.File
+	listable bool
+}
+
+// Readdir 读取目录中的文件项
+func (f *authorizedFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.listable {
+		return nil, os.ErrPermission
+	}
+	return f.File.Readdir(count)
+}
@@ -0,0 +1,177 @@
+package server
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newWebDAVRouter 创建启用了WebDAV的测试路由
+func newWebDAVRouter(t *testing.T) (*gin.Engine, string, func()) {
+	srv, tempDir, cleanup := setupTestServer(t)
+	srv.config.EnableWebDAV = true
+	srv.davHandler = srv.newWebDAVHandler()
+
+	router := gin.New()
+	router.NoRoute(srv.handleRequest)
+	return router, tempDir, cleanup
+}
+
+// TestWebDAVMethods 测试常用的WebDAV方法
+func TestWebDAVMethods(t *testing.T) {
+	router, tempDir, cleanup := newWebDAVRouter(t)
+	defer cleanup()
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		headers      map[string]string
+		expectedCode int
+		check        func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			name:         "OPTIONS返回DAV头",
+			method:       http.MethodOptions,
+			path:         "/",
+			expectedCode: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				if dav := w.Header().Get("DAV"); !strings.Contains(dav, "1") {
+					t.Errorf("DAV头 = %q, 期望包含 1", dav)
+				}
+			},
+		},
+		{
+			name:         "PROPFIND列出根目录",
+			method:       "PROPFIND",
+			path:         "/",
+			headers:      map[string]string{"Depth": "1"},
+			expectedCode: http.StatusMultiStatus,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				if !strings.Contains(w.Body.String(), "test.txt") {
+					t.Errorf("PROPFIND响应中没有test.txt: %s", w.Body.String())
+				}
+			},
+		},
+		{
+			name:         "MKCOL创建目录",
+			method:       "MKCOL",
+			path:         "/newdir",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "PUT写入文件",
+			method:       http.MethodPut,
+			path:         "/newdir/doc.txt",
+			body:         "dav content",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "COPY复制文件",
+			method:       "COPY",
+			path:         "/newdir/doc.txt",
+			headers:      map[string]string{"Destination": "/newdir/copy.txt"},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "MOVE移动文件",
+			method:       "MOVE",
+			path:         "/newdir/copy.txt",
+			headers:      map[string]string{"Destination": "/moved.txt"},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "LOCK锁定文件",
+			method:       "LOCK",
+			path:         "/moved.txt",
+			body:         `<?xml version="1.0" encoding="utf-8"?><D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope><D:locktype><D:write/></D:locktype></D:lockinfo>`,
+			expectedCode: http.StatusOK,
+			check: func(t *testing.T, w *httptest.ResponseRecorder) {
+				if w.Header().Get("Lock-Token") == "" {
+					t.Errorf("LOCK响应缺少Lock-Token头")
+				}
+			},
+		},
+		{
+			name:         "DELETE删除目录",
+			method:       http.MethodDelete,
+			path:         "/newdir",
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("状态码 = %d, 期望 %d, 响应: %s", w.Code, tt.expectedCode, w.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, w)
+			}
+		})
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(tempDir, "moved.txt"))
+	if err != nil || string(content) != "dav content" {
+		t.Errorf("移动后的文件内容 = %q, 错误: %v", string(content), err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "newdir")); !os.IsNotExist(err) {
+		t.Errorf("目录newdir应该已被删除")
+	}
+}
+
+// TestWebDAVConfinement 测试WebDAV不能通过符号链接访问根目录之外的文件
+func TestWebDAVConfinement(t *testing.T) {
+	router, tempDir, cleanup := newWebDAVRouter(t)
+	defer cleanup()
+
+	outsideDir, err := ioutil.TempDir("", "servergo-outside-")
+	if err != nil {
+		t.Fatalf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(outsideDir)
+	if err := ioutil.WriteFile(filepath.Join(outsideDir, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatalf("创建测试文件失败: %v", err)
+	}
+	if err := os.Symlink(outsideDir, filepath.Join(tempDir, "escape")); err != nil {
+		t.Fatalf("创建符号链接失败: %v", err)
+	}
+
+	for _, method := range []string{http.MethodPut, "MKCOL", http.MethodDelete} {
+		req, _ := http.NewRequest(method, "/escape/secret.txt", strings.NewReader("x"))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code < 400 {
+			t.Errorf("%s 状态码 = %d, 期望失败", method, w.Code)
+		}
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(outsideDir, "secret.txt"))
+	if err != nil || string(content) != "secret" {
+		t.Errorf("根目录之外的文件被修改: %q, 错误: %v", string(content), err)
+	}
+
+	// PROPFIND 不应该列出符号链接指向的外部目录
+	req, _ := http.NewRequest("PROPFIND", "/", strings.NewReader(""))
+	req.Header.Set("Depth", "1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if strings.Contains(w.Body.String(), "escape") {
+		t.Errorf("PROPFIND不应该列出越界的符号链接: %s", w.Body.String())
+	}
+}
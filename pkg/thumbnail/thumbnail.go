@@ -0,0 +1,153 @@
+package thumbnail
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // 注册GIF解码器
+	"image/jpeg"
+	_ "image/png" // 注册PNG解码器
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // 注册WebP解码器
+)
+
+// MaxSize 缩略图的最大宽度和高度（像素），原图更小时不放大
+const MaxSize = 320
+
+// maxPixels 原图像素数的上限，超过时不生成缩略图，防止解码超大图片占用过多内存
+const maxPixels = 50_000_000
+
+// imageExts 支持生成缩略图的图片扩展名
+var imageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".gif":  true,
+	".webp": true,
+}
+
+// IsImage 根据扩展名判断是否是支持生成缩略图的图片，例如 .jpg、.png、.gif、.webp
+func IsImage(name string) bool {
+	return imageExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// Cache 缩略图缓存
+// 缩略图以原图路径、修改时间和大小为键保存在缓存目录中，原图修改后会重新生成
+type Cache struct {
+	dir string
+	sem chan struct{} // 限制同时生成缩略图的数量，打开图片很多的目录时避免占用过多内存和CPU
+}
+
+// NewCache 创建缩略图缓存，缓存目录在第一次生成缩略图时创建
+//
+// 参数:
+//   - dir: 缓存目录，例如: "~/.servergo/cache/thumbs"
+func NewCache(dir string) *Cache {
+	return &Cache{
+		dir: dir,
+		sem: make(chan struct{}, runtime.NumCPU()),
+	}
+}
+
+// Get 返回图片的缩略图文件路径，缓存中没有时生成缩略图
+//
+// 参数:
+//   - src: 原图的完整路径
+//   - info: 原图的文件信息，用于判断缓存是否过期
+//
+// 返回值:
+//   - string: 缩略图（JPEG）文件的路径
+//   - error: 图片无法解码或太大时返回错误
+func (c *Cache) Get(src string, info os.FileInfo) (string, error) {
+	thumbPath := c.path(src, info)
+	if _, err := os.Stat(thumbPath); err == nil {
+		return thumbPath, nil
+	}
+
+	c.sem <- struct{}{}
+	defer func() { <-c.sem }()
+
+	// 等待期间其他请求可能已经生成了同一个缩略图
+	if _, err := os.Stat(thumbPath); err == nil {
+		return thumbPath, nil
+	}
+	if err := generate(src, thumbPath); err != nil {
+		return "", err
+	}
+	return thumbPath, nil
+}
+
+// path 计算缩略图在缓存目录中的路径，按哈希的前两个字符分子目录，避免单个目录中文件过多
+func (c *Cache) path(src string, info os.FileInfo) string {
+	sum := sha256.Sum256([]byte(src + "\x00" + strconv.FormatInt(info.ModTime().UnixNano(), 10) + "\x00" + strconv.FormatInt(info.Size(), 10)))
+	key := hex.EncodeToString(sum[:16])
+	return filepath.Join(c.dir, key[:2], key+".jpg")
+}
+
+// generate 读取原图，按比例缩小后以JPEG格式写入dst
+// 透明的部分填充为白色；先写入临时文件再重命名，其他请求不会读到写了一半的缩略图
+func generate(src, dst string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return err
+	}
+	if config.Width*config.Height > maxPixels {
+		return fmt.Errorf("image too large: %dx%d", config.Width, config.Height)
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return err
+	}
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return err
+	}
+
+	bounds := img.Bounds()
+	width, height := fit(bounds.Dx(), bounds.Dy())
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(thumb, thumb.Bounds(), image.White, image.Point{}, draw.Src)
+	xdraw.CatmullRom.Scale(thumb, thumb.Bounds(), img, bounds, xdraw.Over, nil)
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "thumb-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := jpeg.Encode(tmp, thumb, &jpeg.Options{Quality: 80}); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// fit 计算按比例缩小到 MaxSize 以内的尺寸，原图更小时保持原尺寸
+func fit(width, height int) (int, int) {
+	if width <= MaxSize && height <= MaxSize {
+		return max(width, 1), max(height, 1)
+	}
+	if width >= height {
+		return MaxSize, max(height*MaxSize/width, 1)
+	}
+	return max(width*MaxSize/height, 1), MaxSize
+}
@@ -0,0 +1,100 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePNG 创建指定尺寸的PNG测试图片
+func writePNG(t *testing.T, path string, width, height int) {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("创建图片失败: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("编码图片失败: %v", err)
+	}
+}
+
+// TestCacheGet 测试缩略图的生成、缓存和过期
+func TestCacheGet(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "shot.png")
+	writePNG(t, src, 1280, 640)
+
+	cache := NewCache(filepath.Join(tempDir, "thumbs"))
+	info, _ := os.Stat(src)
+	thumbPath, err := cache.Get(src, info)
+	if err != nil {
+		t.Fatalf("生成缩略图失败: %v", err)
+	}
+
+	file, err := os.Open(thumbPath)
+	if err != nil {
+		t.Fatalf("打开缩略图失败: %v", err)
+	}
+	config, err := jpeg.DecodeConfig(file)
+	file.Close()
+	if err != nil {
+		t.Fatalf("缩略图不是JPEG: %v", err)
+	}
+	if config.Width != MaxSize || config.Height != MaxSize/2 {
+		t.Errorf("缩略图尺寸 = %dx%d, 期望 %dx%d", config.Width, config.Height, MaxSize, MaxSize/2)
+	}
+
+	// 原图没有修改时使用缓存
+	again, err := cache.Get(src, info)
+	if err != nil || again != thumbPath {
+		t.Errorf("第二次获取 = %q, %v, 期望使用缓存 %q", again, err, thumbPath)
+	}
+
+	// 原图修改后重新生成
+	later := info.ModTime().Add(time.Second)
+	os.Chtimes(src, later, later)
+	info, _ = os.Stat(src)
+	if changed, _ := cache.Get(src, info); changed == thumbPath {
+		t.Errorf("原图修改后应该使用新的缩略图")
+	}
+}
+
+// TestCacheGetInvalid 测试无法解码的图片
+func TestCacheGetInvalid(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "broken.jpg")
+	os.WriteFile(src, []byte("not an image"), 0644)
+
+	info, _ := os.Stat(src)
+	if _, err := NewCache(filepath.Join(tempDir, "thumbs")).Get(src, info); err == nil {
+		t.Errorf("无法解码的图片应该返回错误")
+	}
+}
+
+// TestFit 测试缩略图尺寸的计算
+func TestFit(t *testing.T) {
+	tests := []struct {
+		width, height                 int
+		expectedWidth, expectedHeight int
+	}{
+		{width: 100, height: 50, expectedWidth: 100, expectedHeight: 50},
+		{width: 1920, height: 1080, expectedWidth: 320, expectedHeight: 180},
+		{width: 1080, height: 1920, expectedWidth: 180, expectedHeight: 320},
+		{width: 5000, height: 1, expectedWidth: 320, expectedHeight: 1},
+	}
+	for _, tt := range tests {
+		w, h := fit(tt.width, tt.height)
+		if w != tt.expectedWidth || h != tt.expectedHeight {
+			t.Errorf("fit(%d, %d) = %dx%d, 期望 %dx%d", tt.width, tt.height, w, h, tt.expectedWidth, tt.expectedHeight)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Package tlscert 负责HTTPS证书的加载、自签名证书的生成与缓存
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// CertFileName 自签名证书文件名
+	CertFileName = "selfsigned-cert.pem"
+	// KeyFileName 自签名证书私钥文件名
+	KeyFileName = "selfsigned-key.pem"
+
+	// validity 自签名证书的有效期
+	validity = 365 * 24 * time.Hour
+	// renewBefore 证书在到期前多久重新生成
+	renewBefore = 7 * 24 * time.Hour
+)
+
+// LocalHosts 返回自签名证书需要覆盖的主机名和IP地址
+// 包括 localhost、回环地址以及本机所有局域网IP
+func LocalHosts() []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return hosts
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLoopback() || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		hosts = append(hosts, ipNet.IP.String())
+	}
+	return hosts
+}
+
+// LoadOrCreateSelfSigned 在指定目录中加载缓存的自签名证书，必要时重新生成
+// 缓存的证书缺失、即将过期或没有覆盖全部 hosts（例如局域网IP变化）时会重新生成
+//
+// 参数:
+//   - dir: 证书缓存目录，例如: "~/.servergo/tls"
+//   - hosts: 证书需要覆盖的主机名或IP，例如: LocalHosts() 的返回值
+//
+// 返回值:
+//   - certFile: 证书文件路径
+//   - keyFile: 私钥文件路径
+//   - created: 是否新生成了证书
+//   - err: 读取或生成失败时返回错误
+func LoadOrCreateSelfSigned(dir string, hosts []string) (certFile, keyFile string, created bool, err error) {
+	certFile = filepath.Join(dir, CertFileName)
+	keyFile = filepath.Join(dir, KeyFileName)
+
+	if cert, err := loadCertificate(certFile, keyFile); err == nil && isUsable(cert, hosts) {
+		return certFile, keyFile, false, nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", false, fmt.Errorf("failed to create certificate directory: %v", err)
+	}
+	if err := generateSelfSigned(certFile, keyFile, hosts); err != nil {
+		return "", "", false, err
+	}
+	return certFile, keyFile, true, nil
+}
+
+// Fingerprint 计算证书文件中第一张证书的SHA-256指纹
+// 返回值格式为以冒号分隔的大写十六进制，例如: "AB:CD:..."
+func Fingerprint(certFile string) (string, error) {
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return "", err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return "", fmt.Errorf("no certificate found in %s", certFile)
+	}
+
+	sum := sha256.Sum256(block.Bytes)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+// Validate 检查用户提供的证书和私钥能否正常加载且相互匹配
+func Validate(certFile, keyFile string) error {
+	_, err := tls.LoadX509KeyPair(certFile, keyFile)
+	return err
+}
+
+// loadCertificate 加载证书文件和私钥，返回解析后的叶子证书
+func loadCertificate(certFile, keyFile string) (*x509.Certificate, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(pair.Certificate[0])
+}
+
+// isUsable 判断缓存的证书是否仍然有效并覆盖了所有 hosts
+// 旧版本生成的CA证书也需要重新生成
+func isUsable(cert *x509.Certificate, hosts []string) bool {
+	if cert.IsCA || time.Now().Add(renewBefore).After(cert.NotAfter) {
+		return false
+	}
+	for _, host := range hosts {
+		if cert.VerifyHostname(host) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// generateSelfSigned 生成ECDSA P-256自签名证书并写入文件
+func generateSelfSigned(certFile, keyFile string, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate private key: %v", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %v", err)
+	}
+
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"servergo"}, CommonName: "servergo self-signed"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageDigitalSignature, // ECDSA密钥不需要 KeyEncipherment
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  false, // 只签发叶子证书，信任它不等于信任一个可以为任意网站签发证书的CA
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %v", err)
+	}
+
+	// 私钥只允许当前用户读取
+	if err := writePEM(keyFile, "EC PRIVATE KEY", keyDER, 0600); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der, 0644)
+}
+
+// writePEM 将DER数据以PEM格式写入文件
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(path, data, perm); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package tlscert
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestLoadOrCreateSelfSigned 测试自签名证书的生成与缓存
+func TestLoadOrCreateSelfSigned(t *testing.T) {
+	dir := t.TempDir()
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+
+	certFile, keyFile, created, err := LoadOrCreateSelfSigned(dir, hosts)
+	if err != nil {
+		t.Fatalf("生成自签名证书失败: %v", err)
+	}
+	if !created {
+		t.Errorf("首次调用应该生成新证书")
+	}
+	if err := Validate(certFile, keyFile); err != nil {
+		t.Fatalf("生成的证书无法加载: %v", err)
+	}
+
+	info, err := os.Stat(keyFile)
+	if err != nil {
+		t.Fatalf("读取私钥文件失败: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("私钥文件权限 = %o, 期望 600", perm)
+	}
+
+	cert := readCert(t, certFile)
+	for _, host := range hosts {
+		if err := cert.VerifyHostname(host); err != nil {
+			t.Errorf("证书没有覆盖 %s: %v", host, err)
+		}
+	}
+
+	if cert.IsCA || cert.KeyUsage&x509.KeyUsageCertSign != 0 {
+		t.Errorf("自签名证书应该是叶子证书而不是CA证书")
+	}
+	if len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageServerAuth {
+		t.Errorf("ExtKeyUsage = %v, 期望只有 ServerAuth", cert.ExtKeyUsage)
+	}
+
+	// 第二次调用应该复用缓存的证书
+	first, _ := Fingerprint(certFile)
+	_, _, created, err = LoadOrCreateSelfSigned(dir, hosts)
+	if err != nil {
+		t.Fatalf("加载缓存证书失败: %v", err)
+	}
+	if created {
+		t.Errorf("证书仍然有效时不应重新生成")
+	}
+	if second, _ := Fingerprint(certFile); second != first {
+		t.Errorf("缓存的证书被替换了")
+	}
+
+	// 主机列表变化（例如局域网IP变化）时应该重新生成
+	_, _, created, err = LoadOrCreateSelfSigned(dir, append(hosts, "192.168.1.23"))
+	if err != nil {
+		t.Fatalf("重新生成证书失败: %v", err)
+	}
+	if !created {
+		t.Errorf("主机列表变化后应该重新生成证书")
+	}
+	if err := readCert(t, certFile).VerifyHostname("192.168.1.23"); err != nil {
+		t.Errorf("新证书没有覆盖新的IP: %v", err)
+	}
+}
+
+// TestFingerprint 测试证书指纹格式
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	certFile, _, _, err := LoadOrCreateSelfSigned(dir, []string{"localhost"})
+	if err != nil {
+		t.Fatalf("生成自签名证书失败: %v", err)
+	}
+
+	fingerprint, err := Fingerprint(certFile)
+	if err != nil {
+		t.Fatalf("计算指纹失败: %v", err)
+	}
+	// SHA-256 共32字节，每字节2个十六进制字符，加31个冒号
+	if len(fingerprint) != 32*3-1 || strings.Count(fingerprint, ":") != 31 {
+		t.Errorf("指纹格式不正确: %s", fingerprint)
+	}
+
+	if _, err := Fingerprint(dir); err == nil {
+		t.Errorf("读取不存在的证书应该返回错误")
+	}
+}
+
+// TestLocalHosts 测试本机主机列表包含回环地址
+func TestLocalHosts(t *testing.T) {
+	hosts := LocalHosts()
+	for _, want := range []string{"localhost", "127.0.0.1", "::1"} {
+		found := false
+		for _, host := range hosts {
+			if host == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("LocalHosts() 缺少 %s: %v", want, hosts)
+		}
+	}
+}
+
+// readCert 读取并解析PEM证书文件
+func readCert(t *testing.T, certFile string) *x509.Certificate {
+	t.Helper()
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("读取证书失败: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatalf("证书文件不是PEM格式")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("解析证书失败: %v", err)
+	}
+	return cert
+}
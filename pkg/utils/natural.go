@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NaturalLess 按自然顺序比较两个字符串，不区分大小写
+// 字符串中的连续数字按数值比较，例如 "file2" 排在 "file10" 之前；
+// 忽略大小写后相同的字符串再按原始字符串比较，保证排序结果稳定
+//
+// 示例:
+//   - NaturalLess("file2.txt", "file10.txt") => true
+//   - NaturalLess("IMG_9.jpg", "img_10.jpg") => true
+func NaturalLess(a, b string) bool {
+	if c := naturalCompare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+// naturalCompare 按自然顺序比较两个字符串，返回 -1、0 或 1
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		ra, _ := utf8.DecodeRuneInString(a)
+		rb, _ := utf8.DecodeRuneInString(b)
+
+		if unicode.IsDigit(ra) && unicode.IsDigit(rb) {
+			var na, nb string
+			na, a = splitDigits(a)
+			nb, b = splitDigits(b)
+			if c := compareNumbers(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+
+		if ra != rb {
+			if ra < rb {
+				return -1
+			}
+			return 1
+		}
+		a = a[utf8.RuneLen(ra):]
+		b = b[utf8.RuneLen(rb):]
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+// splitDigits 把字符串分为开头的连续数字和剩余部分
+func splitDigits(s string) (string, string) {
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	if i < 0 {
+		return s, ""
+	}
+	return s[:i], s[i:]
+}
+
+// compareNumbers 比较两个数字串的数值，数值相同时前导零少的排在前面，例如 "7" 在 "007" 之前
+func compareNumbers(a, b string) int {
+	ta := strings.TrimLeft(a, "0")
+	tb := strings.TrimLeft(b, "0")
+	if len(ta) != len(tb) {
+		if len(ta) < len(tb) {
+			return -1
+		}
+		return 1
+	}
+	if c := strings.Compare(ta, tb); c != 0 {
+		return c
+	}
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestNaturalLess 测试自然顺序比较
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected bool
+	}{
+		{"数字按数值比较", "file2", "file10", true},
+		{"数字按数值比较（反向）", "file10", "file2", false},
+		{"不区分大小写", "IMG_9.jpg", "img_10.jpg", true},
+		{"前缀在前", "report", "report-final", true},
+		{"前导零少的在前", "v7", "v007", true},
+		{"多段数字", "1.2.10", "1.10.1", true},
+		{"相同字符串", "a1", "a1", false},
+		{"大小写不同时按原始字符串", "A1", "a1", true},
+		{"中文", "第2章", "第10章", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NaturalLess(tt.a, tt.b); got != tt.expected {
+				t.Errorf("NaturalLess(%q, %q) = %v, 期望值 %v", tt.a, tt.b, got, tt.expected)
+			}
+		})
+	}
+
+	names := []string{"file10.txt", "File1.txt", "file2.txt", "file1.txt", "file02.txt"}
+	sort.Slice(names, func(i, j int) bool { return NaturalLess(names[i], names[j]) })
+	expected := "File1.txt,file1.txt,file2.txt,file02.txt,file10.txt"
+	if got := strings.Join(names, ","); got != expected {
+		t.Errorf("排序结果 = %s, 期望值 %s", got, expected)
+	}
+}
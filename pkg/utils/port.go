@@ -23,17 +23,23 @@ func init() {
 
 // IsPortAvailable 检查指定端口是否可用
 // 简单版本，直接返回布尔值
-func IsPortAvailable(port int) bool {
+// 指定了 hosts 时检查端口在这些地址上是否都可以监听，例如 "127.0.0.1"、"::1"；否则检查所有接口
+func IsPortAvailable(port int, hosts ...string) bool {
 	// 验证端口范围
 	if port < 0 || port > 65535 {
 		return false
 	}
 
-	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
-	if err != nil {
-		return false
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+	for _, host := range hosts {
+		ln, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			return false
+		}
+		ln.Close()
 	}
-	ln.Close()
 	return true
 }
 
@@ -73,9 +79,10 @@ func CheckPort(port int, protocol string) (available bool, err error) {
 // 如果首选端口不可用或未指定，行为取决于preferredPort的值:
 // - 如果preferredPort > 0: 从该端口开始递增搜索可用端口
 // - 如果preferredPort = 0: 从随机端口开始探测(原有行为)
-func FindAvailablePort(preferredPort int) (int, error) {
+// 指定了 hosts 时只检查这些地址，例如只在 127.0.0.1 上提供服务时，其他接口上被占用的端口仍然可以使用
+func FindAvailablePort(preferredPort int, hosts ...string) (int, error) {
 	// 如果指定了首选端口并且可用，直接返回
-	if preferredPort > 0 && IsPortAvailable(preferredPort) {
+	if preferredPort > 0 && IsPortAvailable(preferredPort, hosts...) {
 		return preferredPort, nil
 	}
 
@@ -100,7 +107,7 @@ func FindAvailablePort(preferredPort int) (int, error) {
 
 	// 从起始端口开始，向上循环查找
 	for port := startPort; port <= MaxPort; port++ {
-		if IsPortAvailable(port) {
+		if IsPortAvailable(port, hosts...) {
 			return port, nil
 		}
 	}
@@ -108,7 +115,7 @@ func FindAvailablePort(preferredPort int) (int, error) {
 	// 如果到达最大端口仍未找到，从最小端口到起始端口再次尝试
 	if startPort > MinPort {
 		for port := MinPort; port < startPort; port++ {
-			if IsPortAvailable(port) {
+			if IsPortAvailable(port, hosts...) {
 				return port, nil
 			}
 		}
@@ -3,6 +3,7 @@ package utils
 import (
 	"fmt"
 	"net"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
@@ -152,6 +153,39 @@ func TestFindAvailablePort(t *testing.T) {
 	}
 }
 
+// TestFindAvailablePortOnHost 测试只检查指定地址上的端口
+func TestFindAvailablePortOnHost(t *testing.T) {
+	// 占用 127.0.0.1 上的一个端口
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("无法创建监听器: %v", err)
+	}
+	defer listener.Close()
+	usedPort := listener.Addr().(*net.TCPAddr).Port
+
+	if IsPortAvailable(usedPort, "127.0.0.1") {
+		t.Errorf("端口 %d 在 127.0.0.1 上已被占用，但函数返回它可用", usedPort)
+	}
+
+	port, err := FindAvailablePort(usedPort, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("未能找到可用端口: %v", err)
+	}
+	if port == usedPort || !IsPortAvailable(port, "127.0.0.1") {
+		t.Errorf("FindAvailablePort(%d, 127.0.0.1) = %d, 期望 127.0.0.1 上的其他可用端口", usedPort, port)
+	}
+
+	// Linux 上整个 127.0.0.0/8 都是本机地址，其他地址上的同一个端口不受影响
+	if runtime.GOOS == "linux" {
+		if !IsPortAvailable(usedPort, "127.0.0.2") {
+			t.Errorf("端口 %d 只在 127.0.0.1 上被占用，127.0.0.2 上应该可用", usedPort)
+		}
+		if port, err := FindAvailablePort(usedPort, "127.0.0.2"); err != nil || port != usedPort {
+			t.Errorf("FindAvailablePort(%d, 127.0.0.2) = %d, %v, 期望首选端口", usedPort, port, err)
+		}
+	}
+}
+
 // TestPortRangeBoundary 测试端口范围边界值
 func TestPortRangeBoundary(t *testing.T) {
 	// 测试场景1：最小端口号